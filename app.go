@@ -1,16 +1,17 @@
 package main
 
 import (
-	"errors"
+	"context"
 	"fmt"
 	"log"
 	"marketplace-api/config"
 	"marketplace-api/delivery"
 	"marketplace-api/helper"
+	"marketplace-api/model"
 	"marketplace-api/repository"
 	"marketplace-api/usecase"
 	"os"
-	"path/filepath"
+	"sync"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/logger"
@@ -24,18 +25,21 @@ type (
 	}
 
 	Server interface {
-		Run()
+		// Run serves until ctx is done, background workers are added to wg
+		Run(ctx context.Context, wg *sync.WaitGroup)
 	}
 )
 
 func InitServer(cfg config.Config) Server {
-	app := fiber.New()
+	app := fiber.New(fiber.Config{BodyLimit: model.UPLOAD_BODY_LIMIT})
 
 	// Middleware
 	app.Use(logger.New())
 
-	// Serving uploads folder
-	app.Static("/uploads", "./uploads")
+	// Serving uploads folder, the s3 backend serves its objects itself
+	if cfg.StorageBackend() != model.STORAGE_BACKEND_S3 {
+		app.Static(model.LOCAL_STORAGE_ROUTE, cfg.LocalStorage().Dir)
+	}
 
 	return &server{
 		httpServer: app,
@@ -43,37 +47,10 @@ func InitServer(cfg config.Config) Server {
 	}
 }
 
-func (s *server) Run() {
+func (s *server) Run(ctx context.Context, wg *sync.WaitGroup) {
 	api := s.httpServer.Group(os.Getenv("API_PREFIX"))
 
-	rootFolderPath, err := filepath.Abs("./")
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	uploadFolderPath := filepath.Join(rootFolderPath, "uploads")
-	if _, err := os.Stat(uploadFolderPath); errors.Is(err, os.ErrNotExist) {
-		err := os.Mkdir(uploadFolderPath, os.ModePerm)
-		if err != nil {
-			log.Fatal(err)
-		}
-	}
-
-	tokoFolderPath := filepath.Join(uploadFolderPath, "toko")
-	if _, err := os.Stat(tokoFolderPath); errors.Is(err, os.ErrNotExist) {
-		err := os.Mkdir(tokoFolderPath, os.ModePerm)
-		if err != nil {
-			log.Fatal(err)
-		}
-	}
-
-	produkFolderPath := filepath.Join(uploadFolderPath, "produk")
-	if _, err := os.Stat(produkFolderPath); errors.Is(err, os.ErrNotExist) {
-		err := os.Mkdir(produkFolderPath, os.ModePerm)
-		if err != nil {
-			log.Fatal(err)
-		}
-	}
+	storage := newStorage(s.cfg)
 
 	jwtMiddleware := jwtware.New(
 		jwtware.Config{
@@ -82,6 +59,10 @@ func (s *server) Run() {
 		},
 	)
 
+	idempotencyKeyRepository := repository.NewIdempotencyKeyRepository(s.cfg)
+	idempotencyKeyUsecase := usecase.NewIdempotencyKeyUsecase(idempotencyKeyRepository)
+	idempotencyMiddleware := delivery.NewIdempotencyMiddleware(idempotencyKeyUsecase)
+
 	provinceRepository := repository.NewProvinceRepository(s.cfg)
 	provinceUsecase := usecase.NewProvinceUsecase(provinceRepository)
 	provinceDelivery := delivery.NewProvinceDelivery(provinceUsecase)
@@ -94,11 +75,18 @@ func (s *server) Run() {
 	provinceDelivery.MountUnprotectedRoutes(provinceCityGroup)
 	cityDelivery.MountUnprotectedRoutes(provinceCityGroup)
 
+	fotoProdukRepository := repository.NewFotoProdukRepository(s.cfg)
+	produkRepository := repository.NewProdukRepository(s.cfg)
+	produkVariantRepository := repository.NewProdukVariantRepository(s.cfg)
+	produkSearchIndex := repository.NewProdukSearchIndex(s.cfg)
+	// the in-memory index starts empty, it is filled from the database once the usecase exists
+	memoryProdukSearch := s.cfg.ProdukSearchBackend() == model.PRODUK_SEARCH_BACKEND_MEMORY
+	if memoryProdukSearch {
+		produkSearchIndex = repository.NewMemoryProdukSearchIndex()
+	}
+
 	tokoRepository := repository.NewTokoRepository(s.cfg)
-	tokoUsecase := usecase.NewTokoUsecase(tokoRepository)
-	tokoDelivery := delivery.NewTokoDelivery(tokoUsecase)
-	tokoGroup := api.Group("/toko")
-	tokoDelivery.MountProtectedRoutes(jwtMiddleware, tokoGroup)
+	tokoUsecase := usecase.NewTokoUsecase(tokoRepository, produkRepository, produkSearchIndex, storage)
 
 	userRepository := repository.NewUserRepository(s.cfg)
 	userUsecase := usecase.NewUserUsecase(userRepository, tokoRepository, provinceRepository, cityRepository)
@@ -117,32 +105,77 @@ func (s *server) Run() {
 	authDelivery.MountUnprotectedRoutes(authGroup)
 
 	categoryRepository := repository.NewCategoryRepository(s.cfg)
-	categoryUsecase := usecase.NewCategoryUsecase(categoryRepository)
+	categoryUsecase := usecase.NewCategoryUsecase(categoryRepository, produkRepository, produkSearchIndex)
 	categoryDelivery := delivery.NewCategoryDelivery(categoryUsecase)
 	categoryGroup := api.Group("/category")
 	categoryDelivery.MountUnprotectedRoutes(categoryGroup)
 	categoryDelivery.MountProtectedRoutes(jwtMiddleware, categoryGroup)
 
-	fotoProdukRepository := repository.NewFotoProdukRepository(s.cfg)
-	produkRepository := repository.NewProdukRepository(s.cfg)
 	produkUsecase := usecase.NewProdukUsecase(
 		produkRepository,
 		fotoProdukRepository,
+		produkVariantRepository,
 		tokoRepository,
 		categoryRepository,
+		produkSearchIndex,
+		storage,
 	)
-	produkDelivery := delivery.NewProdukDelivery(produkUsecase)
+	if memoryProdukSearch {
+		if err := produkUsecase.RebuildSearchIndex(ctx); err != nil {
+			log.Fatal(err)
+		}
+	}
+	produkDelivery := delivery.NewProdukDelivery(produkUsecase, storage)
 	produkGroup := api.Group("/product")
 	produkDelivery.MountUnprotectedRoutes(produkGroup)
-	produkDelivery.MountProtectedRoutes(jwtMiddleware, produkGroup)
+	produkDelivery.MountProtectedRoutes(jwtMiddleware, idempotencyMiddleware, produkGroup)
+
+	fotoProdukUsecase := usecase.NewFotoProdukUsecase(fotoProdukRepository, produkRepository, tokoRepository, storage)
+	fotoProdukDelivery := delivery.NewFotoProdukDelivery(fotoProdukUsecase, storage)
+	fotoProdukDelivery.MountProtectedRoutes(jwtMiddleware, idempotencyMiddleware, produkGroup)
+
+	produkVariantUsecase := usecase.NewProdukVariantUsecase(produkVariantRepository, produkRepository, tokoRepository)
+	produkVariantDelivery := delivery.NewProdukVariantDelivery(produkVariantUsecase)
+	produkVariantDelivery.MountUnprotectedRoutes(produkGroup)
+	produkVariantDelivery.MountProtectedRoutes(jwtMiddleware, idempotencyMiddleware, produkGroup)
 
 	logProdukRepository := repository.NewLogProdukRepository(s.cfg)
 
 	detailTrxRepository := repository.NewDetailTrxRepository(s.cfg)
 
+	trxStatusHistoryRepository := repository.NewTrxStatusHistoryRepository(s.cfg)
+
+	invoiceCounterRepository := repository.NewInvoiceCounterRepository(s.cfg)
+	kodeInvoiceGenerator := usecase.NewKodeInvoiceGenerator(invoiceCounterRepository)
+
+	paymentWebhookSecret := os.Getenv("PAYMENT_WEBHOOK_SECRET")
+	paymentProviderRegistry := usecase.NewPaymentProviderRegistry(
+		repository.NewFakePaymentProvider(model.PAYMENT_METHOD_BANK_TRANSFER, false, paymentWebhookSecret),
+		repository.NewFakePaymentProvider(model.PAYMENT_METHOD_COD, true, paymentWebhookSecret),
+		repository.NewFakePaymentProvider(model.PAYMENT_METHOD_E_WALLET, false, paymentWebhookSecret),
+	)
+	for _, method := range paymentProviderRegistry.Methods() {
+		paymentProvider, _ := paymentProviderRegistry.Get(method)
+		if !paymentProvider.PayOnDelivery() && paymentWebhookSecret == "" {
+			log.Fatalf("PAYMENT_WEBHOOK_SECRET must be set, %s payments are settled through the webhook", method)
+		}
+	}
+	paymentRepository := repository.NewPaymentRepository(s.cfg)
+	paymentUsecase := usecase.NewPaymentUsecase(paymentRepository, paymentProviderRegistry)
+	paymentDelivery := delivery.NewPaymentDelivery(paymentUsecase)
+	paymentGroup := api.Group("/payment")
+	paymentDelivery.MountUnprotectedRoutes(paymentGroup)
+
+	voucherRepository := repository.NewVoucherRepository(s.cfg)
+	voucherUsecase := usecase.NewVoucherUsecase(voucherRepository, tokoRepository, categoryRepository)
+	voucherDelivery := delivery.NewVoucherDelivery(voucherUsecase)
+	voucherGroup := api.Group("/voucher")
+	voucherDelivery.MountProtectedRoutes(jwtMiddleware, idempotencyMiddleware, voucherGroup)
+
 	trxRepository := repository.NewTrxRepository(s.cfg)
 	trxUsecase := usecase.NewTrxUsecase(
 		trxRepository,
+		userRepository,
 		alamatRepository,
 		detailTrxRepository,
 		logProdukRepository,
@@ -150,12 +183,78 @@ func (s *server) Run() {
 		categoryRepository,
 		fotoProdukRepository,
 		produkRepository,
+		produkVariantRepository,
+		trxStatusHistoryRepository,
+		kodeInvoiceGenerator,
+		paymentRepository,
+		paymentProviderRegistry,
+		voucherRepository,
+		storage,
 	)
+	tokoDelivery := delivery.NewTokoDelivery(tokoUsecase, trxUsecase, storage)
+	tokoGroup := api.Group("/toko")
+	tokoDelivery.MountProtectedRoutes(jwtMiddleware, tokoGroup)
+
 	trxDelivery := delivery.NewTrxDelivery(trxUsecase)
 	trxGroup := api.Group("/trx")
-	trxDelivery.MountProtectedRoutes(jwtMiddleware, trxGroup)
+	trxDelivery.MountProtectedRoutes(jwtMiddleware, idempotencyMiddleware, trxGroup)
+
+	cartRepository := repository.NewCartRepository(s.cfg)
+	cartUsecase := usecase.NewCartUsecase(
+		cartRepository,
+		userRepository,
+		produkRepository,
+		produkVariantRepository,
+		tokoRepository,
+		fotoProdukRepository,
+		storage,
+		trxUsecase,
+	)
+	cartDelivery := delivery.NewCartDelivery(cartUsecase)
+	cartGroup := api.Group("/cart")
+	cartDelivery.MountProtectedRoutes(jwtMiddleware, idempotencyMiddleware, cartGroup)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		runPaymentExpirySweeper(ctx, trxUsecase, s.cfg.PaymentDeadline(), s.cfg.PaymentSweepInterval())
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		runIdempotencyKeyCleaner(ctx, idempotencyKeyUsecase, model.IDEMPOTENCY_KEY_CLEANUP_INTERVAL)
+	}()
+
+	if s.cfg.UploadGcInterval() > 0 {
+		orphanUploadUsecase := usecase.NewOrphanUploadUsecase(fotoProdukRepository, tokoRepository, storage)
+		orphanUploadRequest := &model.OrphanUploadRequest{
+			GracePeriod: s.cfg.UploadGcGracePeriod(),
+			Delete:      s.cfg.UploadGcDelete(),
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runOrphanUploadCollector(ctx, orphanUploadUsecase, s.cfg.UploadGcInterval(), orphanUploadRequest)
+		}()
+	}
+
+	go func() {
+		<-ctx.Done()
+		if err := s.httpServer.Shutdown(); err != nil {
+			log.Println(err)
+		}
+	}()
 
 	if err := s.httpServer.Listen(fmt.Sprintf(":%d", s.cfg.ServicePort())); err != nil {
 		log.Panic(err)
 	}
 }
+
+// newStorage picks the storage of the uploaded photos, the local disk unless STORAGE_BACKEND is s3
+func newStorage(cfg config.Config) model.Storage {
+	if cfg.StorageBackend() == model.STORAGE_BACKEND_S3 {
+		return repository.NewS3Storage(cfg.S3Storage())
+	}
+	return repository.NewLocalStorage(cfg.LocalStorage())
+}
@@ -2,28 +2,65 @@ package config
 
 import (
 	"marketplace-api/config/mysql"
+	"marketplace-api/model"
 	"os"
 	"strconv"
+	"time"
 
 	"gorm.io/gorm"
 )
 
+const (
+	DEFAULT_DB_MAX_OPEN_CONNS    = 25
+	DEFAULT_DB_MAX_IDLE_CONNS    = 25
+	DEFAULT_DB_CONN_MAX_LIFETIME = 5 * time.Minute
+
+	DEFAULT_PAYMENT_DEADLINE       = 24 * time.Hour
+	DEFAULT_PAYMENT_SWEEP_INTERVAL = time.Minute
+
+	DEFAULT_LOCAL_STORAGE_DIR = "./uploads"
+	DEFAULT_S3_REGION         = "us-east-1"
+
+	DEFAULT_UPLOAD_GC_GRACE_PERIOD = 24 * time.Hour
+)
+
 type (
 	config struct {
+		db *gorm.DB
 	}
 
 	Config interface {
 		ServicePort() int
 		Database() *gorm.DB
+		// PaymentDeadline is how long a trx may wait for its payment before it is cancelled
+		PaymentDeadline() time.Duration
+		PaymentSweepInterval() time.Duration
+		// ProdukSearchBackend is one of model.PRODUK_SEARCH_BACKEND_MYSQL and model.PRODUK_SEARCH_BACKEND_MEMORY
+		ProdukSearchBackend() string
+		// StorageBackend is one of model.STORAGE_BACKEND_LOCAL and model.STORAGE_BACKEND_S3
+		StorageBackend() string
+		LocalStorage() model.LocalStorageConfig
+		S3Storage() model.S3StorageConfig
+		// UploadGcInterval is how often orphaned uploads are collected in the background, 0 disables it
+		UploadGcInterval() time.Duration
+		UploadGcGracePeriod() time.Duration
+		// UploadGcDelete lets the background collector delete orphans instead of only reporting them
+		UploadGcDelete() bool
 	}
 )
 
+// NewConfig opens the database connection pool once, every repository shares the same pool
 func NewConfig() Config {
-	return &config{}
+	db := mysql.InitGorm(mysql.PoolConfig{
+		MaxOpenConns:    intFromEnv("DB_MAX_OPEN_CONNS", DEFAULT_DB_MAX_OPEN_CONNS),
+		MaxIdleConns:    intFromEnv("DB_MAX_IDLE_CONNS", DEFAULT_DB_MAX_IDLE_CONNS),
+		ConnMaxLifetime: durationFromEnv("DB_CONN_MAX_LIFETIME", DEFAULT_DB_CONN_MAX_LIFETIME),
+	})
+	return &config{db: db}
 }
 
 func (c *config) Database() *gorm.DB {
-	return mysql.InitGorm()
+	return c.db
 }
 
 func (c *config) ServicePort() int {
@@ -31,3 +68,83 @@ func (c *config) ServicePort() int {
 	port, _ := strconv.Atoi(v)
 	return port
 }
+
+func (c *config) PaymentDeadline() time.Duration {
+	return durationFromEnv("PAYMENT_DEADLINE", DEFAULT_PAYMENT_DEADLINE)
+}
+
+func (c *config) PaymentSweepInterval() time.Duration {
+	v := durationFromEnv("PAYMENT_SWEEP_INTERVAL", DEFAULT_PAYMENT_SWEEP_INTERVAL)
+	if v == 0 {
+		return DEFAULT_PAYMENT_SWEEP_INTERVAL
+	}
+	return v
+}
+
+func (c *config) ProdukSearchBackend() string {
+	if os.Getenv("PRODUK_SEARCH_BACKEND") == model.PRODUK_SEARCH_BACKEND_MEMORY {
+		return model.PRODUK_SEARCH_BACKEND_MEMORY
+	}
+	return model.PRODUK_SEARCH_BACKEND_MYSQL
+}
+
+func (c *config) StorageBackend() string {
+	if os.Getenv("STORAGE_BACKEND") == model.STORAGE_BACKEND_S3 {
+		return model.STORAGE_BACKEND_S3
+	}
+	return model.STORAGE_BACKEND_LOCAL
+}
+
+func (c *config) LocalStorage() model.LocalStorageConfig {
+	return model.LocalStorageConfig{
+		Dir:       stringFromEnv("LOCAL_STORAGE_DIR", DEFAULT_LOCAL_STORAGE_DIR),
+		PublicUrl: stringFromEnv("STORAGE_PUBLIC_URL", model.LOCAL_STORAGE_ROUTE),
+	}
+}
+
+func (c *config) S3Storage() model.S3StorageConfig {
+	return model.S3StorageConfig{
+		Endpoint:  os.Getenv("S3_ENDPOINT"),
+		Region:    stringFromEnv("S3_REGION", DEFAULT_S3_REGION),
+		Bucket:    os.Getenv("S3_BUCKET"),
+		AccessKey: os.Getenv("S3_ACCESS_KEY"),
+		SecretKey: os.Getenv("S3_SECRET_KEY"),
+		PublicUrl: os.Getenv("STORAGE_PUBLIC_URL"),
+	}
+}
+
+func (c *config) UploadGcInterval() time.Duration {
+	return durationFromEnv("UPLOAD_GC_INTERVAL", 0)
+}
+
+func (c *config) UploadGcGracePeriod() time.Duration {
+	return durationFromEnv("UPLOAD_GC_GRACE_PERIOD", DEFAULT_UPLOAD_GC_GRACE_PERIOD)
+}
+
+func (c *config) UploadGcDelete() bool {
+	return os.Getenv("UPLOAD_GC_DELETE") == "true"
+}
+
+func stringFromEnv(key string, defaultValue string) string {
+	v := os.Getenv(key)
+	if v == "" {
+		return defaultValue
+	}
+	return v
+}
+
+func intFromEnv(key string, defaultValue int) int {
+	v, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || v < 0 {
+		return defaultValue
+	}
+	return v
+}
+
+func durationFromEnv(key string, defaultValue time.Duration) time.Duration {
+	v, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || v < 0 {
+		return defaultValue
+	}
+	return v
+}
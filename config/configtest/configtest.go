@@ -0,0 +1,127 @@
+// Package configtest provides a config.Config backed by a throwaway SQLite database, so repositories and
+// usecases can be tested without a MySQL server
+package configtest
+
+import (
+	"fmt"
+	"marketplace-api/config"
+	"marketplace-api/model"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// uniqueIndexes are created by the migrations but not declared on the models
+var uniqueIndexes = []string{
+	"CREATE UNIQUE INDEX `idx_cart_item_id_cart_id_produk_variant_key` ON `cart_item` (`id_cart`, `id_produk`, COALESCE(`id_produk_variant`, 0))",
+	"CREATE UNIQUE INDEX `idx_idempotency_key_id_user_idempotency_key` ON `idempotency_key` (`id_user`, `idempotency_key`)",
+	"CREATE UNIQUE INDEX `idx_produk_variant_sku` ON `produk_variant` (`sku`)",
+	"CREATE UNIQUE INDEX `idx_voucher_category_id_voucher_id_category` ON `voucher_category` (`id_voucher`, `id_category`)",
+}
+
+type testConfig struct {
+	db         *gorm.DB
+	storageDir string
+}
+
+// NewConfig creates the tables of every model in a new database file that is removed after the test.
+// Transactions take the write lock when they begin, so concurrent transactions wait for each other
+// instead of failing on a lock upgrade. They never interleave, concurrency tests need NewMySQLConfig
+// to catch a lost update
+func NewConfig(t testing.TB) config.Config {
+	t.Helper()
+	dir := t.TempDir()
+	dsn := fmt.Sprintf(
+		"file:%s?_busy_timeout=10000&_journal_mode=WAL&_txlock=immediate",
+		filepath.Join(dir, "test.db"),
+	)
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	err = db.AutoMigrate(
+		&model.User{},
+		&model.Category{},
+		&model.Toko{},
+		&model.Alamat{},
+		&model.Produk{},
+		&model.ProdukVariant{},
+		&model.FotoProduk{},
+		&model.LogProduk{},
+		&model.Trx{},
+		&model.DetailTrx{},
+		&model.TrxStatusHistory{},
+		&model.Payment{},
+		&model.Cart{},
+		&model.CartItem{},
+		&model.IdempotencyKey{},
+		&model.InvoiceCounter{},
+		&model.Voucher{},
+		&model.VoucherCategory{},
+		&model.VoucherPemakaian{},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, statement := range uniqueIndexes {
+		if err := db.Exec(statement).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return &testConfig{db: db, storageDir: filepath.Join(dir, "uploads")}
+}
+
+func (c *testConfig) ServicePort() int {
+	return 0
+}
+
+func (c *testConfig) Database() *gorm.DB {
+	return c.db
+}
+
+func (c *testConfig) PaymentDeadline() time.Duration {
+	return config.DEFAULT_PAYMENT_DEADLINE
+}
+
+func (c *testConfig) PaymentSweepInterval() time.Duration {
+	return config.DEFAULT_PAYMENT_SWEEP_INTERVAL
+}
+
+func (c *testConfig) ProdukSearchBackend() string {
+	return model.PRODUK_SEARCH_BACKEND_MEMORY
+}
+
+func (c *testConfig) StorageBackend() string {
+	return model.STORAGE_BACKEND_LOCAL
+}
+
+func (c *testConfig) LocalStorage() model.LocalStorageConfig {
+	return model.LocalStorageConfig{Dir: c.storageDir, PublicUrl: model.LOCAL_STORAGE_ROUTE}
+}
+
+func (c *testConfig) S3Storage() model.S3StorageConfig {
+	return model.S3StorageConfig{}
+}
+
+func (c *testConfig) UploadGcInterval() time.Duration {
+	return 0
+}
+
+func (c *testConfig) UploadGcGracePeriod() time.Duration {
+	return config.DEFAULT_UPLOAD_GC_GRACE_PERIOD
+}
+
+func (c *testConfig) UploadGcDelete() bool {
+	return false
+}
@@ -0,0 +1,71 @@
+package configtest
+
+import (
+	"fmt"
+	"marketplace-api/config"
+	"marketplace-api/migration"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// TEST_MYSQL_DSN_ENV names a MySQL server without a database, for example
+// "root:secret@tcp(127.0.0.1:3306)/"
+const TEST_MYSQL_DSN_ENV = "TEST_MYSQL_DSN"
+
+// NewMySQLConfig creates a database on the server of TEST_MYSQL_DSN, applies the migrations, and drops it
+// after the test. Unlike SQLite, concurrent transactions interleave there, so it is the database that shows
+// whether the row locking holds. The test is skipped when TEST_MYSQL_DSN is not set
+func NewMySQLConfig(t testing.TB) config.Config {
+	t.Helper()
+	dsn := os.Getenv(TEST_MYSQL_DSN_ENV)
+	if dsn == "" {
+		t.Skipf("%s is not set", TEST_MYSQL_DSN_ENV)
+	}
+	mysqlConfig, err := mysqldriver.ParseDSN(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mysqlConfig.DBName = ""
+	mysqlConfig.ParseTime = true
+	server, err := gorm.Open(mysql.Open(mysqlConfig.FormatDSN()), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverDB, err := server.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { serverDB.Close() })
+
+	mysqlConfig.DBName = fmt.Sprintf("marketplace_test_%d", time.Now().UnixNano())
+	if err := server.Exec(fmt.Sprintf("CREATE DATABASE `%s`", mysqlConfig.DBName)).Error; err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := server.Exec(fmt.Sprintf("DROP DATABASE `%s`", mysqlConfig.DBName)).Error; err != nil {
+			t.Error(err)
+		}
+	})
+
+	db, err := gorm.Open(mysql.Open(mysqlConfig.FormatDSN()), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if _, err := migration.Up(db); err != nil {
+		t.Fatal(err)
+	}
+	return &testConfig{db: db, storageDir: filepath.Join(t.TempDir(), "uploads")}
+}
@@ -2,29 +2,32 @@ package mysql
 
 import (
 	"log"
-	"marketplace-api/model"
 	"os"
+	"time"
 
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
 
-func InitGorm() *gorm.DB {
+type PoolConfig struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+}
+
+// InitGorm only opens the connection pool, schema changes are applied by the migrate subcommand
+func InitGorm(poolConfig PoolConfig) *gorm.DB {
 	connection := os.Getenv("DATABASE_URL")
 	db, err := gorm.Open(mysql.Open(connection))
 	if err != nil {
 		log.Panic(err)
 	}
-	db.AutoMigrate(
-		&model.User{},
-		&model.Category{},
-		&model.Toko{},
-		&model.Alamat{},
-		&model.Produk{},
-		&model.FotoProduk{},
-		&model.LogProduk{},
-		&model.DetailTrx{},
-		&model.Trx{},
-	)
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Panic(err)
+	}
+	sqlDB.SetMaxOpenConns(poolConfig.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(poolConfig.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(poolConfig.ConnMaxLifetime)
 	return db
 }
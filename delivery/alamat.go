@@ -29,6 +29,7 @@ func (p *alamatDelivery) MountProtectedRoutes(jwtMiddleware func(*fiber.Ctx) err
 	group.Get("/:id", jwtMiddleware, p.DetailAlamatHandler)
 	group.Put("/:id", jwtMiddleware, p.EditAlamatHandler)
 	group.Delete("/:id", jwtMiddleware, p.DeleteAlamatHandler)
+	group.Put("/:id/restore", jwtMiddleware, helper.CheckAdminTokenHandler, p.RestoreAlamatHandler)
 }
 
 func (p *alamatDelivery) StoreAlamatHandler(c *fiber.Ctx) error {
@@ -60,15 +61,24 @@ func (p *alamatDelivery) FetchAndFilterAlamatHandler(c *fiber.Ctx) error {
 	if len(judulAlamat) > 255 {
 		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("judul alamat cannot exceed 255 characters"))
 	}
+	req := new(model.AlamatFetchRequest)
+	req.JudulAlamat = judulAlamat
+
+	paginationRequest, err := model.ParsePaginationRequest(c.Query("limit"), c.Query("page"))
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	req.PaginationRequest = paginationRequest
+
 	userId, err := helper.GetUserIdFromToken(c)
 	if err != nil {
 		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
 	}
-	alamatResponses, err := p.alamatUsecase.FetchAndFilterAlamat(ctx, userId, judulAlamat)
+	alamatFetchResponse, err := p.alamatUsecase.FetchAndFilterAlamat(ctx, req, userId)
 	if err != nil {
 		return helper.ResponseErrorJson(c, fiber.StatusInternalServerError, err)
 	}
-	return helper.ResponseSuccessJson(c, alamatResponses)
+	return helper.ResponseSuccessJson(c, alamatFetchResponse)
 }
 
 func (p *alamatDelivery) DetailAlamatHandler(c *fiber.Ctx) error {
@@ -133,3 +143,17 @@ func (p *alamatDelivery) DeleteAlamatHandler(c *fiber.Ctx) error {
 	}
 	return helper.ResponseSuccessJson(c, "")
 }
+
+func (p *alamatDelivery) RestoreAlamatHandler(c *fiber.Ctx) error {
+	ctx := c.Context()
+	idString := c.Params("id")
+	idInt, err := strconv.Atoi(idString)
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("invalid id"))
+	}
+	alamatResponse, err := p.alamatUsecase.RestoreAlamat(ctx, idInt)
+	if err != nil {
+		return helper.ResponseErrorJson(c, http.StatusBadRequest, err)
+	}
+	return helper.ResponseSuccessJson(c, alamatResponse)
+}
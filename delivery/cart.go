@@ -0,0 +1,171 @@
+package delivery
+
+import (
+	"errors"
+	"marketplace-api/helper"
+	"marketplace-api/model"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type cartDelivery struct {
+	cartUsecase model.CartUsecase
+}
+
+type CartDelivery interface {
+	MountProtectedRoutes(
+		jwtMiddleware func(*fiber.Ctx) error,
+		idempotencyMiddleware func(*fiber.Ctx) error,
+		group fiber.Router,
+	)
+}
+
+func NewCartDelivery(cartUsecase model.CartUsecase) CartDelivery {
+	return &cartDelivery{cartUsecase: cartUsecase}
+}
+
+func (p *cartDelivery) MountProtectedRoutes(
+	jwtMiddleware func(*fiber.Ctx) error,
+	idempotencyMiddleware func(*fiber.Ctx) error,
+	group fiber.Router,
+) {
+	group.Get("", jwtMiddleware, p.GetCartHandler)
+	group.Delete("", jwtMiddleware, p.ClearCartHandler)
+	group.Post("/items", jwtMiddleware, idempotencyMiddleware, p.StoreCartItemHandler)
+	group.Put("/items/:id", jwtMiddleware, p.EditCartItemHandler)
+	group.Delete("/items/:id", jwtMiddleware, p.DeleteCartItemHandler)
+	group.Post("/checkout", jwtMiddleware, idempotencyMiddleware, p.CheckoutHandler)
+}
+
+func (p *cartDelivery) GetCartHandler(c *fiber.Ctx) error {
+	ctx := c.Context()
+	userId, err := helper.GetUserIdFromToken(c)
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	cartResponse, err := p.cartUsecase.GetCart(ctx, userId)
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	return helper.ResponseSuccessJson(c, cartResponse)
+}
+
+func (p *cartDelivery) ClearCartHandler(c *fiber.Ctx) error {
+	ctx := c.Context()
+	userId, err := helper.GetUserIdFromToken(c)
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	err = p.cartUsecase.ClearCart(ctx, userId)
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	return helper.ResponseSuccessJson(c, "")
+}
+
+func (p *cartDelivery) StoreCartItemHandler(c *fiber.Ctx) error {
+	ctx := c.Context()
+	var req model.CartItemRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	if req.ProductId <= 0 {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("invalid product id"))
+	}
+	if req.VariantId < 0 {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("invalid variant id"))
+	}
+	if req.Kuantitas <= 0 {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("invalid kuantitas"))
+	}
+
+	userId, err := helper.GetUserIdFromToken(c)
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	cartResponse, err := p.cartUsecase.StoreCartItem(ctx, &req, userId)
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	return helper.ResponseSuccessJson(c, cartResponse)
+}
+
+func (p *cartDelivery) EditCartItemHandler(c *fiber.Ctx) error {
+	ctx := c.Context()
+	var req model.CartItemUpdateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	if req.Kuantitas <= 0 {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("invalid kuantitas"))
+	}
+
+	idString := c.Params("id")
+	idInt, err := strconv.Atoi(idString)
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("invalid id"))
+	}
+	userId, err := helper.GetUserIdFromToken(c)
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	cartResponse, err := p.cartUsecase.EditCartItem(ctx, idInt, &req, userId)
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	return helper.ResponseSuccessJson(c, cartResponse)
+}
+
+func (p *cartDelivery) DeleteCartItemHandler(c *fiber.Ctx) error {
+	ctx := c.Context()
+	idString := c.Params("id")
+	idInt, err := strconv.Atoi(idString)
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("invalid id"))
+	}
+	userId, err := helper.GetUserIdFromToken(c)
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	cartResponse, err := p.cartUsecase.DestroyCartItem(ctx, idInt, userId)
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	return helper.ResponseSuccessJson(c, cartResponse)
+}
+
+func (p *cartDelivery) CheckoutHandler(c *fiber.Ctx) error {
+	ctx := c.Context()
+	var req model.CartCheckoutRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+
+	req.MethodBayar = strings.TrimSpace(req.MethodBayar)
+	if req.MethodBayar == "" {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("method bayar must not be empty"))
+	}
+	if len(req.MethodBayar) > 255 {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("method bayar must not exceed 255"))
+	}
+
+	if req.AlamatPengiriman <= 0 {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("invalid alamat kirim"))
+	}
+
+	userId, err := helper.GetUserIdFromToken(c)
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	trxGetByIDResponses, err := p.cartUsecase.Checkout(ctx, &req, userId)
+	if err != nil && len(trxGetByIDResponses) > 0 {
+		// the buyer owes the created transactions, a retry with the same idempotency key replays them
+		return helper.ResponsePartialJson(c, trxGetByIDResponses, err)
+	}
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	return helper.ResponseSuccessJson(c, trxGetByIDResponses)
+}
@@ -0,0 +1,69 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"io"
+	"marketplace-api/config/configtest"
+	"marketplace-api/model"
+	"marketplace-api/repository"
+	"marketplace-api/usecase"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// partialCheckoutUsecase creates the trx of the first toko and fails on the second
+type partialCheckoutUsecase struct {
+	model.CartUsecase
+	calls int
+}
+
+func (p *partialCheckoutUsecase) Checkout(ctx context.Context, req *model.CartCheckoutRequest, userId int) ([]*model.TrxGetByIDResponse, error) {
+	p.calls++
+	return []*model.TrxGetByIDResponse{{ID: 7}}, errors.New("checkout stopped after 1 transaction(s) were created: kuantitas melebihi stok produk")
+}
+
+func TestCheckoutHandlerPartialCheckout(t *testing.T) {
+	cfg := configtest.NewConfig(t)
+	idempotencyMiddleware := NewIdempotencyMiddleware(usecase.NewIdempotencyKeyUsecase(repository.NewIdempotencyKeyRepository(cfg)))
+	authenticate := func(c *fiber.Ctx) error {
+		c.Locals("user", &jwt.Token{Claims: jwt.MapClaims{"idString": "1", "isAdmin": false}})
+		return c.Next()
+	}
+	cartUsecase := new(partialCheckoutUsecase)
+	app := fiber.New()
+	NewCartDelivery(cartUsecase).MountProtectedRoutes(authenticate, idempotencyMiddleware, app.Group("/cart"))
+
+	post := func() (int, string) {
+		req := httptest.NewRequest(http.MethodPost, "/cart/checkout", strings.NewReader(`{"method_bayar":"bank_transfer","alamat_kirim":1}`))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		req.Header.Set(model.IDEMPOTENCY_KEY_HEADER, testIdempotencyKey)
+		res, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(res.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.StatusCode, string(body)
+	}
+
+	for _, attempt := range []string{"first request", "retry"} {
+		status, body := post()
+		if status != fiber.StatusMultiStatus {
+			t.Errorf("%s: got status %d, want 207", attempt, status)
+		}
+		if !strings.Contains(body, `"id":7`) || !strings.Contains(body, "checkout stopped") {
+			t.Errorf("%s: body %s does not hold the created trx and the error", attempt, body)
+		}
+	}
+	if cartUsecase.calls != 1 {
+		t.Errorf("checkout ran %d times, want the retry to be replayed", cartUsecase.calls)
+	}
+}
@@ -33,6 +33,7 @@ func (p *categoryDelivery) MountProtectedRoutes(jwtMiddleware func(*fiber.Ctx) e
 	group.Get("/:id", jwtMiddleware, helper.CheckAdminTokenHandler, p.DetailCategoryHandler)
 	group.Put("/:id", jwtMiddleware, helper.CheckAdminTokenHandler, p.EditCategoryHandler)
 	group.Delete("/:id", jwtMiddleware, helper.CheckAdminTokenHandler, p.DeleteCategoryHandler)
+	group.Put("/:id/restore", jwtMiddleware, helper.CheckAdminTokenHandler, p.RestoreCategoryHandler)
 }
 
 func (p *categoryDelivery) StoreCategoryHandler(c *fiber.Ctx) error {
@@ -114,8 +115,25 @@ func (p *categoryDelivery) DeleteCategoryHandler(c *fiber.Ctx) error {
 		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("invalid id"))
 	}
 	err = p.categoryUsecase.DestroyCategory(ctx, idInt)
+	if errors.Is(err, model.ErrCategoryInUse) {
+		return helper.ResponseErrorJson(c, fiber.StatusConflict, err)
+	}
 	if err != nil {
 		return helper.ResponseErrorJson(c, http.StatusBadRequest, err)
 	}
 	return helper.ResponseSuccessJson(c, "")
 }
+
+func (p *categoryDelivery) RestoreCategoryHandler(c *fiber.Ctx) error {
+	ctx := c.Context()
+	idString := c.Params("id")
+	idInt, err := strconv.Atoi(idString)
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("invalid id"))
+	}
+	categoryResponse, err := p.categoryUsecase.RestoreCategory(ctx, idInt)
+	if err != nil {
+		return helper.ResponseErrorJson(c, http.StatusBadRequest, err)
+	}
+	return helper.ResponseSuccessJson(c, categoryResponse)
+}
@@ -0,0 +1,158 @@
+package delivery
+
+import (
+	"errors"
+	"marketplace-api/helper"
+	"marketplace-api/model"
+	"net/http"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type fotoProdukDelivery struct {
+	fotoProdukUsecase model.FotoProdukUsecase
+	storage           model.Storage
+}
+
+type FotoProdukDelivery interface {
+	MountProtectedRoutes(
+		jwtMiddleware func(*fiber.Ctx) error,
+		idempotencyMiddleware func(*fiber.Ctx) error,
+		group fiber.Router,
+	)
+}
+
+func NewFotoProdukDelivery(fotoProdukUsecase model.FotoProdukUsecase, storage model.Storage) FotoProdukDelivery {
+	return &fotoProdukDelivery{fotoProdukUsecase: fotoProdukUsecase, storage: storage}
+}
+
+func (p *fotoProdukDelivery) MountProtectedRoutes(
+	jwtMiddleware func(*fiber.Ctx) error,
+	idempotencyMiddleware func(*fiber.Ctx) error,
+	group fiber.Router,
+) {
+	group.Post("/:id/photos", jwtMiddleware, idempotencyMiddleware, p.StoreFotoProdukHandler)
+	group.Put("/:id/photos/order", jwtMiddleware, p.ReorderFotoProdukHandler)
+	group.Put("/:id/photos/:photoId/primary", jwtMiddleware, p.SetPrimaryFotoProdukHandler)
+	group.Delete("/:id/photos/:photoId", jwtMiddleware, p.DeleteFotoProdukHandler)
+}
+
+func (p *fotoProdukDelivery) StoreFotoProdukHandler(c *fiber.Ctx) error {
+	ctx := c.Context()
+	produkId, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("invalid id"))
+	}
+	userId, err := helper.GetUserIdFromToken(c)
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	if len(form.File["photos"]) == 0 {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("photos must not be empty"))
+	}
+	photoKeys := []string{}
+	for _, photo := range form.File["photos"] {
+		photoKey, err := putPhoto(ctx, p.storage, model.STORAGE_FOLDER_PRODUK, photo, model.FOTO_PRODUK_RENDITIONS)
+		if err != nil {
+			// when a photo is rejected or failed to be stored, delete the already stored photos
+			errDelete := deleteFotoProduk(ctx, p.storage, photoKeys)
+			if errDelete != nil {
+				return helper.ResponseErrorJson(c, http.StatusInternalServerError, errDelete)
+			}
+			return helper.ResponseErrorJson(c, putPhotoErrorCode(err), err)
+		}
+		photoKeys = append(photoKeys, photoKey)
+	}
+
+	fotoProdukResponses, err := p.fotoProdukUsecase.StoreFotoProduk(ctx, produkId, userId, photoKeys)
+	if err != nil {
+		errDelete := deleteFotoProduk(ctx, p.storage, photoKeys)
+		if errDelete != nil {
+			return helper.ResponseErrorJson(c, http.StatusInternalServerError, errDelete)
+		}
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	return helper.ResponseSuccessJson(c, fotoProdukResponses)
+}
+
+func (p *fotoProdukDelivery) ReorderFotoProdukHandler(c *fiber.Ctx) error {
+	ctx := c.Context()
+	var req model.FotoProdukOrderRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	if len(req.FotoProdukIds) == 0 {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("photo_ids must not be empty"))
+	}
+
+	produkId, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("invalid id"))
+	}
+	userId, err := helper.GetUserIdFromToken(c)
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+
+	fotoProdukResponses, err := p.fotoProdukUsecase.ReorderFotoProduk(ctx, produkId, userId, &req)
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	return helper.ResponseSuccessJson(c, fotoProdukResponses)
+}
+
+func (p *fotoProdukDelivery) SetPrimaryFotoProdukHandler(c *fiber.Ctx) error {
+	ctx := c.Context()
+	produkId, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("invalid id"))
+	}
+	fotoProdukId, err := strconv.Atoi(c.Params("photoId"))
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("invalid photo id"))
+	}
+	userId, err := helper.GetUserIdFromToken(c)
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+
+	fotoProdukResponses, err := p.fotoProdukUsecase.SetPrimaryFotoProduk(ctx, produkId, fotoProdukId, userId)
+	if err != nil {
+		if errors.Is(err, model.ErrFotoProdukNotFound) {
+			return helper.ResponseErrorJson(c, fiber.StatusNotFound, err)
+		}
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	return helper.ResponseSuccessJson(c, fotoProdukResponses)
+}
+
+func (p *fotoProdukDelivery) DeleteFotoProdukHandler(c *fiber.Ctx) error {
+	ctx := c.Context()
+	produkId, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("invalid id"))
+	}
+	fotoProdukId, err := strconv.Atoi(c.Params("photoId"))
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("invalid photo id"))
+	}
+	userId, err := helper.GetUserIdFromToken(c)
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+
+	err = p.fotoProdukUsecase.DestroyFotoProduk(ctx, produkId, fotoProdukId, userId)
+	if err != nil {
+		if errors.Is(err, model.ErrFotoProdukNotFound) {
+			return helper.ResponseErrorJson(c, fiber.StatusNotFound, err)
+		}
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	return helper.ResponseSuccessJson(c, "")
+}
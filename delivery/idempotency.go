@@ -0,0 +1,68 @@
+package delivery
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"marketplace-api/helper"
+	"marketplace-api/model"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// NewIdempotencyMiddleware replays the stored response when a request is retried with the same Idempotency-Key header,
+// it must be mounted after the jwt middleware because keys are scoped per user
+func NewIdempotencyMiddleware(idempotencyKeyUsecase model.IdempotencyKeyUsecase) func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		ctx := c.Context()
+		key := strings.TrimSpace(c.Get(model.IDEMPOTENCY_KEY_HEADER))
+		if key == "" {
+			return c.Next()
+		}
+		if len(key) > 255 {
+			return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("idempotency key must not exceed 255 characters"))
+		}
+
+		userId, err := helper.GetUserIdFromToken(c)
+		if err != nil {
+			return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+		}
+
+		hash := sha256.New()
+		hash.Write([]byte(c.Method() + " " + c.Path() + "\n"))
+		hash.Write(c.Body())
+
+		req := new(model.IdempotencyKeyRequest)
+		req.IdUser = userId
+		req.Key = key
+		req.Method = c.Method()
+		req.Path = c.Path()
+		req.RequestHash = hex.EncodeToString(hash.Sum(nil))
+
+		idempotencyKey, replay, err := idempotencyKeyUsecase.BeginRequest(ctx, req)
+		if err != nil {
+			if errors.Is(err, model.ErrIdempotencyKeyMismatch) || errors.Is(err, model.ErrIdempotencyKeyInProgress) {
+				return helper.ResponseErrorJson(c, fiber.StatusConflict, err)
+			}
+			return helper.ResponseErrorJson(c, fiber.StatusInternalServerError, err)
+		}
+		if replay {
+			c.Set("Idempotent-Replayed", "true")
+			c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			return c.Status(idempotencyKey.StatusCode).Send(idempotencyKey.ResponseBody)
+		}
+
+		if err := c.Next(); err != nil {
+			idempotencyKeyUsecase.ReleaseRequest(ctx, idempotencyKey.ID)
+			return err
+		}
+
+		statusCode := c.Response().StatusCode()
+		if statusCode >= fiber.StatusInternalServerError {
+			return idempotencyKeyUsecase.ReleaseRequest(ctx, idempotencyKey.ID)
+		}
+		responseBody := append([]byte{}, c.Response().Body()...)
+		return idempotencyKeyUsecase.CompleteRequest(ctx, idempotencyKey.ID, statusCode, responseBody)
+	}
+}
@@ -0,0 +1,168 @@
+package delivery
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"marketplace-api/config/configtest"
+	"marketplace-api/model"
+	"marketplace-api/repository"
+	"marketplace-api/usecase"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"gorm.io/gorm"
+)
+
+const testIdempotencyKey = "key-1"
+
+type idempotencyTest struct {
+	t     *testing.T
+	db    *gorm.DB
+	app   *fiber.App
+	calls int
+	// status is answered by the handler behind the middleware
+	status int
+}
+
+func newIdempotencyTest(t *testing.T) *idempotencyTest {
+	cfg := configtest.NewConfig(t)
+	idempotencyKeyUsecase := usecase.NewIdempotencyKeyUsecase(repository.NewIdempotencyKeyRepository(cfg))
+	it := &idempotencyTest{t: t, db: cfg.Database(), app: fiber.New(), status: fiber.StatusOK}
+
+	authenticate := func(c *fiber.Ctx) error {
+		c.Locals("user", &jwt.Token{Claims: jwt.MapClaims{"idString": "1", "isAdmin": false}})
+		return c.Next()
+	}
+	it.app.Post("/trx", authenticate, NewIdempotencyMiddleware(idempotencyKeyUsecase), func(c *fiber.Ctx) error {
+		it.calls++
+		return c.Status(it.status).JSON(fiber.Map{"call": it.calls})
+	})
+	return it
+}
+
+func (it *idempotencyTest) post(body string) (int, string, *http.Response) {
+	it.t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/trx", strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	req.Header.Set(model.IDEMPOTENCY_KEY_HEADER, testIdempotencyKey)
+	res, err := it.app.Test(req)
+	if err != nil {
+		it.t.Fatal(err)
+	}
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		it.t.Fatal(err)
+	}
+	return res.StatusCode, string(resBody), res
+}
+
+// age moves the stored key back in time, as if its request was made d ago
+func (it *idempotencyTest) age(d time.Duration) {
+	it.t.Helper()
+	if err := it.db.Model(&model.IdempotencyKey{}).
+		Where("idempotency_key = ?", testIdempotencyKey).
+		UpdateColumn("updated_at", time.Now().Add(-d)).Error; err != nil {
+		it.t.Fatal(err)
+	}
+}
+
+// reserve stores a key whose request for body is still in progress
+func (it *idempotencyTest) reserve(body string) {
+	it.t.Helper()
+	hash := sha256.Sum256([]byte(http.MethodPost + " /trx\n" + body))
+	if err := it.db.Create(&model.IdempotencyKey{
+		IdUser:      1,
+		Key:         testIdempotencyKey,
+		Method:      http.MethodPost,
+		Path:        "/trx",
+		RequestHash: hex.EncodeToString(hash[:]),
+	}).Error; err != nil {
+		it.t.Fatal(err)
+	}
+}
+
+func TestIdempotencyMiddlewareReplay(t *testing.T) {
+	it := newIdempotencyTest(t)
+
+	status, body, _ := it.post(`{"a":1}`)
+	if status != fiber.StatusOK || body != `{"call":1}` {
+		t.Fatalf("first request: got %d %s", status, body)
+	}
+	status, body, res := it.post(`{"a":1}`)
+	if status != fiber.StatusOK || body != `{"call":1}` {
+		t.Errorf("replay: got %d %s, want the first response", status, body)
+	}
+	if res.Header.Get("Idempotent-Replayed") != "true" {
+		t.Error("replay is not marked")
+	}
+	if it.calls != 1 {
+		t.Errorf("handler called %d times", it.calls)
+	}
+}
+
+func TestIdempotencyMiddlewareConflict(t *testing.T) {
+	t.Run("different body", func(t *testing.T) {
+		it := newIdempotencyTest(t)
+		it.post(`{"a":1}`)
+		status, body, _ := it.post(`{"a":2}`)
+		if status != fiber.StatusConflict || !strings.Contains(body, model.ErrIdempotencyKeyMismatch.Error()) {
+			t.Errorf("got %d %s, want 409 mismatch", status, body)
+		}
+	})
+
+	t.Run("still in progress", func(t *testing.T) {
+		it := newIdempotencyTest(t)
+		it.reserve(`{"a":1}`)
+		it.age(model.IDEMPOTENCY_KEY_LOCK_TIMEOUT - time.Minute)
+		status, body, _ := it.post(`{"a":1}`)
+		if status != fiber.StatusConflict || !strings.Contains(body, model.ErrIdempotencyKeyInProgress.Error()) {
+			t.Errorf("got %d %s, want 409 in progress", status, body)
+		}
+		if it.calls != 0 {
+			t.Error("handler was called")
+		}
+	})
+}
+
+func TestIdempotencyMiddlewareExpiry(t *testing.T) {
+	t.Run("abandoned reservation is freed", func(t *testing.T) {
+		it := newIdempotencyTest(t)
+		it.reserve(`{"a":1}`)
+		it.age(model.IDEMPOTENCY_KEY_LOCK_TIMEOUT + time.Minute)
+		status, body, _ := it.post(`{"a":1}`)
+		if status != fiber.StatusOK || body != `{"call":1}` {
+			t.Errorf("got %d %s, want the request to run", status, body)
+		}
+	})
+
+	t.Run("completed key past its ttl", func(t *testing.T) {
+		it := newIdempotencyTest(t)
+		it.post(`{"a":1}`)
+		it.age(model.IDEMPOTENCY_KEY_TTL + time.Minute)
+		status, body, _ := it.post(`{"a":2}`)
+		if status != fiber.StatusOK || body != `{"call":2}` {
+			t.Errorf("got %d %s, want the request to run", status, body)
+		}
+		status, body, _ = it.post(`{"a":2}`)
+		if status != fiber.StatusOK || body != `{"call":2}` {
+			t.Errorf("got %d %s, want the reclaimed key to replay", status, body)
+		}
+	})
+
+	t.Run("server error releases the key", func(t *testing.T) {
+		it := newIdempotencyTest(t)
+		it.status = fiber.StatusInternalServerError
+		it.post(`{"a":1}`)
+		it.status = fiber.StatusOK
+		status, body, _ := it.post(`{"a":1}`)
+		if status != fiber.StatusOK || body != `{"call":2}` {
+			t.Errorf("got %d %s, want the retry to run", status, body)
+		}
+	})
+}
@@ -0,0 +1,51 @@
+package delivery
+
+import (
+	"errors"
+	"marketplace-api/helper"
+	"marketplace-api/model"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type paymentDelivery struct {
+	paymentUsecase model.PaymentUsecase
+}
+
+type PaymentDelivery interface {
+	MountUnprotectedRoutes(group fiber.Router)
+}
+
+func NewPaymentDelivery(paymentUsecase model.PaymentUsecase) PaymentDelivery {
+	return &paymentDelivery{paymentUsecase: paymentUsecase}
+}
+
+func (p *paymentDelivery) MountUnprotectedRoutes(group fiber.Router) {
+	group.Get("/methods", p.FetchPaymentMethodHandler)
+	// called by the payment providers, authenticated by the signature header instead of a token
+	group.Post("/webhook/:method", p.PaymentWebhookHandler)
+}
+
+func (p *paymentDelivery) FetchPaymentMethodHandler(c *fiber.Ctx) error {
+	ctx := c.Context()
+	paymentMethodResponses := p.paymentUsecase.FetchMethods(ctx)
+	return helper.ResponseSuccessJson(c, paymentMethodResponses)
+}
+
+func (p *paymentDelivery) PaymentWebhookHandler(c *fiber.Ctx) error {
+	ctx := c.Context()
+	method := model.PaymentMethod(c.Params("method"))
+	signature := c.Get(model.PAYMENT_SIGNATURE_HEADER)
+
+	err := p.paymentUsecase.HandleNotification(ctx, method, signature, c.Body())
+	if err != nil {
+		if errors.Is(err, model.ErrInvalidPaymentSignature) {
+			return helper.ResponseErrorJson(c, fiber.StatusUnauthorized, err)
+		}
+		if errors.Is(err, model.ErrInvalidPaymentMethod) {
+			return helper.ResponseErrorJson(c, fiber.StatusNotFound, err)
+		}
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	return helper.ResponseSuccessJson(c, "")
+}
@@ -2,41 +2,49 @@ package delivery
 
 import (
 	"errors"
+	"fmt"
 	"marketplace-api/helper"
 	"marketplace-api/model"
 	"net/http"
-	"os"
-	"path"
-	"path/filepath"
 	"strconv"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/google/uuid"
 )
 
 type produkDelivery struct {
 	produkUsecase model.ProdukUsecase
+	storage       model.Storage
 }
 
 type ProdukDelivery interface {
 	MountUnprotectedRoutes(group fiber.Router)
-	MountProtectedRoutes(jwtMiddleware func(*fiber.Ctx) error, group fiber.Router)
+	MountProtectedRoutes(
+		jwtMiddleware func(*fiber.Ctx) error,
+		idempotencyMiddleware func(*fiber.Ctx) error,
+		group fiber.Router,
+	)
 }
 
-func NewProdukDelivery(produkUsecase model.ProdukUsecase) ProdukDelivery {
-	return &produkDelivery{produkUsecase: produkUsecase}
+func NewProdukDelivery(produkUsecase model.ProdukUsecase, storage model.Storage) ProdukDelivery {
+	return &produkDelivery{produkUsecase: produkUsecase, storage: storage}
 }
 
 func (p *produkDelivery) MountUnprotectedRoutes(group fiber.Router) {
 	group.Get("", p.FetchProdukHandler)
+	group.Get("/search", p.SearchProdukHandler)
 	group.Get("/:id", p.DetailProdukHandler)
 }
 
-func (p *produkDelivery) MountProtectedRoutes(jwtMiddleware func(*fiber.Ctx) error, group fiber.Router) {
-	group.Post("", jwtMiddleware, p.StoreProdukHandler)
+func (p *produkDelivery) MountProtectedRoutes(
+	jwtMiddleware func(*fiber.Ctx) error,
+	idempotencyMiddleware func(*fiber.Ctx) error,
+	group fiber.Router,
+) {
+	group.Post("", jwtMiddleware, idempotencyMiddleware, p.StoreProdukHandler)
 	group.Put("/:id", jwtMiddleware, p.EditProdukHandler)
 	group.Delete("/:id", jwtMiddleware, p.DeleteProdukHandler)
+	group.Put("/:id/restore", jwtMiddleware, helper.CheckAdminTokenHandler, p.RestoreProdukHandler)
 }
 
 func (p *produkDelivery) StoreProdukHandler(c *fiber.Ctx) error {
@@ -83,14 +91,14 @@ func (p *produkDelivery) StoreProdukHandler(c *fiber.Ctx) error {
 	if len(hargaReseller) > 255 {
 		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("harga reseller must not exceed 255 characters"))
 	}
-	hargaResellerInt, err := strconv.Atoi(hargaReseller)
+	hargaResellerMoney, err := model.ParseMoney(hargaReseller)
 	if err != nil {
 		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("invalid harga reseller"))
 	}
-	if hargaResellerInt <= 0 {
+	if hargaResellerMoney <= 0 {
 		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("invalid harga reseller"))
 	}
-	req.HargaReseller = hargaReseller
+	req.HargaReseller = hargaResellerMoney
 
 	if len(form.Value["harga_konsumen"]) == 0 {
 		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("harga konsumen must not be empty"))
@@ -102,14 +110,14 @@ func (p *produkDelivery) StoreProdukHandler(c *fiber.Ctx) error {
 	if len(hargaKonsumen) > 255 {
 		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("harga konsumen must not exceed 255 characters"))
 	}
-	hargaKonsumenInt, err := strconv.Atoi(hargaKonsumen)
+	hargaKonsumenMoney, err := model.ParseMoney(hargaKonsumen)
 	if err != nil {
 		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("invalid harga konsumen"))
 	}
-	if hargaKonsumenInt <= 0 {
+	if hargaKonsumenMoney <= 0 {
 		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("invalid harga konsumen"))
 	}
-	req.HargaKonsumen = hargaKonsumen
+	req.HargaKonsumen = hargaKonsumenMoney
 
 	if len(form.Value["stok"]) == 0 {
 		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("stok must not be empty"))
@@ -127,6 +135,21 @@ func (p *produkDelivery) StoreProdukHandler(c *fiber.Ctx) error {
 	}
 	req.Stok = stokInt
 
+	// min_kuantitas_reseller is optional, without it resellers get harga reseller for any quantity
+	if len(form.Value["min_kuantitas_reseller"]) > 0 {
+		minKuantitasResellerString := strings.TrimSpace(form.Value["min_kuantitas_reseller"][0])
+		if minKuantitasResellerString != "" {
+			minKuantitasResellerInt, err := strconv.Atoi(minKuantitasResellerString)
+			if err != nil {
+				return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("invalid min kuantitas reseller"))
+			}
+			if minKuantitasResellerInt < 0 {
+				return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("invalid min kuantitas reseller"))
+			}
+			req.MinKuantitasReseller = minKuantitasResellerInt
+		}
+	}
+
 	if len(form.Value["deskripsi"]) == 0 {
 		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("deskripsi must not be empty"))
 	}
@@ -140,40 +163,20 @@ func (p *produkDelivery) StoreProdukHandler(c *fiber.Ctx) error {
 		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("photos must not be empty"))
 	}
 	photos := form.File["photos"]
-	photoFilePaths := []string{}
-	photoUrls := []string{}
+	photoKeys := []string{}
 	for _, photo := range photos {
-		fileExtension := photo.Filename[strings.LastIndex(photo.Filename, ".")+1:]
-		if fileExtension != "jpg" &&
-			fileExtension != "jpeg" &&
-			fileExtension != "png" &&
-			fileExtension != "webp" &&
-			fileExtension != "jfif" {
-			return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("photo must be jpg/jpeg/png/webp/jfif"))
-		}
-		photoFilename := uuid.NewString() + "." + fileExtension
-		rootFolderPath, err := filepath.Abs("./")
+		photoKey, err := putPhoto(ctx, p.storage, model.STORAGE_FOLDER_PRODUK, photo, model.FOTO_PRODUK_RENDITIONS)
 		if err != nil {
-			return helper.ResponseErrorJson(c, fiber.StatusInternalServerError, err)
-		}
-		photoFilePath := filepath.Join(rootFolderPath, "uploads", "produk", photoFilename)
-		photoUrl := c.BaseURL() + "/uploads/produk/" + photoFilename
-
-		err = c.SaveFile(photo, photoFilePath)
-		if err != nil {
-			// when a file is failed to be saved on disk, delete already created photo on the disk
-			for _, createdPhotoFilePath := range photoFilePaths {
-				errRemove := os.Remove(createdPhotoFilePath)
-				if errRemove != nil {
-					return helper.ResponseErrorJson(c, http.StatusInternalServerError, errRemove)
-				}
+			// when a photo is rejected or failed to be stored, delete the already stored photos
+			errDelete := deleteFotoProduk(ctx, p.storage, photoKeys)
+			if errDelete != nil {
+				return helper.ResponseErrorJson(c, http.StatusInternalServerError, errDelete)
 			}
-			return helper.ResponseErrorJson(c, http.StatusInternalServerError, err)
+			return helper.ResponseErrorJson(c, putPhotoErrorCode(err), err)
 		}
-		photoFilePaths = append(photoFilePaths, photoFilePath)
-		photoUrls = append(photoUrls, photoUrl)
+		photoKeys = append(photoKeys, photoKey)
 	}
-	req.PhotoUrls = photoUrls
+	req.PhotoKeys = photoKeys
 
 	userId, err := helper.GetUserIdFromToken(c)
 	if err != nil {
@@ -182,12 +185,10 @@ func (p *produkDelivery) StoreProdukHandler(c *fiber.Ctx) error {
 
 	produkResponse, err := p.produkUsecase.StoreProduk(ctx, &req, userId)
 	if err != nil {
-		// delete already created photos on the disk if the store produk operation is failed
-		for _, createdPhotoFilePath := range photoFilePaths {
-			errRemove := os.Remove(createdPhotoFilePath)
-			if errRemove != nil {
-				return helper.ResponseErrorJson(c, http.StatusInternalServerError, errRemove)
-			}
+		// delete the already stored photos if the store produk operation is failed
+		errDelete := deleteFotoProduk(ctx, p.storage, photoKeys)
+		if errDelete != nil {
+			return helper.ResponseErrorJson(c, http.StatusInternalServerError, errDelete)
 		}
 		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
 	}
@@ -205,33 +206,11 @@ func (p *produkDelivery) FetchProdukHandler(c *fiber.Ctx) error {
 	}
 	req.NamaProduk = namaProduk
 
-	limitString := strings.TrimSpace(c.Query("limit"))
-	pageString := strings.TrimSpace(c.Query("page"))
-	limitInt, pageInt := -1, 1
-	var err error
-	if limitString != "" {
-		limitInt, err = strconv.Atoi(limitString)
-		if err != nil {
-			return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("limit must be integer"))
-		}
-		if limitInt < 1 {
-			return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("limit must be greater than zero"))
-		}
-	}
-	if pageString != "" {
-		if limitString == "" {
-			return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("must use limit query param when using page query param"))
-		}
-		pageInt, err = strconv.Atoi(pageString)
-		if err != nil {
-			return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("page must be integer"))
-		}
-		if pageInt < 1 {
-			return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("page must be greater than zero"))
-		}
+	paginationRequest, err := model.ParsePaginationRequestWithCursor(c.Query("limit"), c.Query("page"), c.Query("cursor"))
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
 	}
-	req.Limit = limitInt
-	req.Page = pageInt
+	req.PaginationRequest = paginationRequest
 
 	categoryIdString := strings.TrimSpace(c.Query("category_id"))
 	if len(categoryIdString) > 255 {
@@ -263,30 +242,90 @@ func (p *produkDelivery) FetchProdukHandler(c *fiber.Ctx) error {
 	if len(maxHargaString) > 255 {
 		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("max harga cannot exceed 255 characters"))
 	}
-	maxHargaInt := -1
+	maxHarga := model.Money(-1)
 	if maxHargaString != "" {
-		maxHargaInt, err = strconv.Atoi(maxHargaString)
+		maxHarga, err = model.ParseMoney(maxHargaString)
 		if err != nil {
 			return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("max harga must be integer"))
 		}
 	}
-	req.MaxHarga = maxHargaInt
+	req.MaxHarga = maxHarga
 
 	minHargaString := strings.TrimSpace(c.Query("min_harga"))
 	if len(minHargaString) > 255 {
 		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("min harga cannot exceed 255 characters"))
 	}
-	minHargaInt := -1
+	minHarga := model.Money(-1)
 	if minHargaString != "" {
-		minHargaInt, err = strconv.Atoi(minHargaString)
+		minHarga, err = model.ParseMoney(minHargaString)
 		if err != nil {
 			return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("min harga must be integer"))
 		}
 	}
-	req.MinHarga = minHargaInt
+	req.MinHarga = minHarga
+
+	hargaBucketsString := strings.TrimSpace(c.Query("harga_buckets"))
+	if len(hargaBucketsString) > 255 {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("harga buckets cannot exceed 255 characters"))
+	}
+	req.HargaBuckets = model.PRODUK_FACET_HARGA_BUCKETS
+	if hargaBucketsString != "" {
+		req.HargaBuckets, err = parseHargaBuckets(hargaBucketsString)
+		if err != nil {
+			return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+		}
+	}
+
+	sort := strings.TrimSpace(c.Query("sort"))
+	if sort == "" {
+		sort = model.PRODUK_SORT_DEFAULT
+	}
+	validSort := false
+	for _, produkSort := range model.PRODUK_SORTS {
+		if sort == produkSort {
+			validSort = true
+			break
+		}
+	}
+	if !validSort {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, fmt.Errorf("sort must be one of %v", model.PRODUK_SORTS))
+	}
+	req.Sort = sort
 
 	produkResponse, err := p.produkUsecase.FetchProduk(ctx, req)
 	if err != nil {
+		if errors.Is(err, model.ErrInvalidCursor) {
+			return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+		}
+		return helper.ResponseErrorJson(c, fiber.StatusInternalServerError, err)
+	}
+	return helper.ResponseSuccessJson(c, produkResponse)
+}
+
+func (p *produkDelivery) SearchProdukHandler(c *fiber.Ctx) error {
+	ctx := c.Context()
+	req := new(model.ProdukSearchRequest)
+
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, model.ErrEmptySearchQuery)
+	}
+	if len(query) > 255 {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("q cannot exceed 255 characters"))
+	}
+	req.Query = query
+
+	paginationRequest, err := model.ParsePaginationRequest(c.Query("limit"), c.Query("page"))
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	req.PaginationRequest = paginationRequest
+
+	produkResponse, err := p.produkUsecase.SearchProduk(ctx, req)
+	if err != nil {
+		if errors.Is(err, model.ErrEmptySearchQuery) {
+			return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+		}
 		return helper.ResponseErrorJson(c, fiber.StatusInternalServerError, err)
 	}
 	return helper.ResponseSuccessJson(c, produkResponse)
@@ -362,14 +401,14 @@ func (p *produkDelivery) EditProdukHandler(c *fiber.Ctx) error {
 	if len(hargaReseller) > 255 {
 		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("harga reseller must not exceed 255 characters"))
 	}
-	hargaResellerInt, err := strconv.Atoi(hargaReseller)
+	hargaResellerMoney, err := model.ParseMoney(hargaReseller)
 	if err != nil {
 		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("invalid harga reseller"))
 	}
-	if hargaResellerInt <= 0 {
+	if hargaResellerMoney <= 0 {
 		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("invalid harga reseller"))
 	}
-	req.HargaReseller = hargaReseller
+	req.HargaReseller = hargaResellerMoney
 
 	if len(form.Value["harga_konsumen"]) == 0 {
 		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("harga konsumen must not be empty"))
@@ -381,14 +420,14 @@ func (p *produkDelivery) EditProdukHandler(c *fiber.Ctx) error {
 	if len(hargaKonsumen) > 255 {
 		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("harga konsumen must not exceed 255 characters"))
 	}
-	hargaKonsumenInt, err := strconv.Atoi(hargaKonsumen)
+	hargaKonsumenMoney, err := model.ParseMoney(hargaKonsumen)
 	if err != nil {
 		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("invalid harga konsumen"))
 	}
-	if hargaKonsumenInt <= 0 {
+	if hargaKonsumenMoney <= 0 {
 		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("invalid harga konsumen"))
 	}
-	req.HargaKonsumen = hargaKonsumen
+	req.HargaKonsumen = hargaKonsumenMoney
 
 	if len(form.Value["stok"]) == 0 {
 		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("stok must not be empty"))
@@ -406,6 +445,21 @@ func (p *produkDelivery) EditProdukHandler(c *fiber.Ctx) error {
 	}
 	req.Stok = stokInt
 
+	// min_kuantitas_reseller is optional, without it resellers get harga reseller for any quantity
+	if len(form.Value["min_kuantitas_reseller"]) > 0 {
+		minKuantitasResellerString := strings.TrimSpace(form.Value["min_kuantitas_reseller"][0])
+		if minKuantitasResellerString != "" {
+			minKuantitasResellerInt, err := strconv.Atoi(minKuantitasResellerString)
+			if err != nil {
+				return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("invalid min kuantitas reseller"))
+			}
+			if minKuantitasResellerInt < 0 {
+				return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("invalid min kuantitas reseller"))
+			}
+			req.MinKuantitasReseller = minKuantitasResellerInt
+		}
+	}
+
 	if len(form.Value["deskripsi"]) == 0 {
 		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("deskripsi must not be empty"))
 	}
@@ -415,82 +469,33 @@ func (p *produkDelivery) EditProdukHandler(c *fiber.Ctx) error {
 	}
 	req.Deskripsi = deskripsi
 
-	if len(form.File["photos"]) == 0 {
-		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("photos must not be empty"))
-	}
+	// photos are optional, without them the current photos are kept
 	photos := form.File["photos"]
-	photoFilePaths := []string{}
-	photoUrls := []string{}
+	photoKeys := []string{}
 	for _, photo := range photos {
-		fileExtension := photo.Filename[strings.LastIndex(photo.Filename, ".")+1:]
-		if fileExtension != "jpg" &&
-			fileExtension != "jpeg" &&
-			fileExtension != "png" &&
-			fileExtension != "webp" &&
-			fileExtension != "jfif" {
-			return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("photo must be jpg/jpeg/png/webp/jfif"))
-		}
-		photoFilename := uuid.NewString() + "." + fileExtension
-		rootFolderPath, err := filepath.Abs("./")
-		if err != nil {
-			return helper.ResponseErrorJson(c, fiber.StatusInternalServerError, err)
-		}
-		photoFilePath := filepath.Join(rootFolderPath, "uploads", "produk", photoFilename)
-		photoUrl := c.BaseURL() + "/uploads/produk/" + photoFilename
-
-		err = c.SaveFile(photo, photoFilePath)
+		photoKey, err := putPhoto(ctx, p.storage, model.STORAGE_FOLDER_PRODUK, photo, model.FOTO_PRODUK_RENDITIONS)
 		if err != nil {
-			// when a file is failed to be saved on disk, delete already created photo on the disk
-			for _, createdPhotoFilePath := range photoFilePaths {
-				errRemove := os.Remove(createdPhotoFilePath)
-				if errRemove != nil {
-					return helper.ResponseErrorJson(c, http.StatusInternalServerError, errRemove)
-				}
+			// when a photo is rejected or failed to be stored, delete the already stored photos
+			errDelete := deleteFotoProduk(ctx, p.storage, photoKeys)
+			if errDelete != nil {
+				return helper.ResponseErrorJson(c, http.StatusInternalServerError, errDelete)
 			}
-			return helper.ResponseErrorJson(c, http.StatusInternalServerError, err)
+			return helper.ResponseErrorJson(c, putPhotoErrorCode(err), err)
 		}
-		photoFilePaths = append(photoFilePaths, photoFilePath)
-		photoUrls = append(photoUrls, photoUrl)
-	}
-	req.PhotoUrls = photoUrls
-
-	// get before updated photo urls to delete on the disk if update is successful
-	oldPhotoFilePaths := []string{}
-	oldProdukResponse, err := p.produkUsecase.GetProdukByID(ctx, produkIdInt)
-	if err != nil {
-		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
-	}
-	for _, fotoProdukResponse := range oldProdukResponse.Photos {
-		photoUrl := fotoProdukResponse.Url
-		photoFilename := path.Base(photoUrl)
-		rootFolderPath, err := filepath.Abs("./")
-		if err != nil {
-			return helper.ResponseErrorJson(c, fiber.StatusInternalServerError, err)
-		}
-		photoFilePath := filepath.Join(rootFolderPath, "uploads", "produk", photoFilename)
-		oldPhotoFilePaths = append(oldPhotoFilePaths, photoFilePath)
+		photoKeys = append(photoKeys, photoKey)
 	}
+	req.PhotoKeys = photoKeys
 
 	produkResponse, err := p.produkUsecase.EditProdukByID(ctx, produkIdInt, userId, &req)
 	if err != nil {
-		// delete already created photos on the disk if the store produk operation is failed
-		for _, createdPhotoFilePath := range photoFilePaths {
-			errRemove := os.Remove(createdPhotoFilePath)
-			if errRemove != nil {
-				return helper.ResponseErrorJson(c, http.StatusInternalServerError, errRemove)
-			}
+		// delete the already stored photos if the store produk operation is failed
+		errDelete := deleteFotoProduk(ctx, p.storage, photoKeys)
+		if errDelete != nil {
+			return helper.ResponseErrorJson(c, http.StatusInternalServerError, errDelete)
 		}
 		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
 	}
 
-	// delete old photo files
-	for _, oldPhotoFilePath := range oldPhotoFilePaths {
-		errRemove := os.Remove(oldPhotoFilePath)
-		if errRemove != nil {
-			return helper.ResponseErrorJson(c, http.StatusInternalServerError, errRemove)
-		}
-	}
-
 	return helper.ResponseSuccessJson(c, produkResponse)
 }
 
@@ -507,35 +512,46 @@ func (p *produkDelivery) DeleteProdukHandler(c *fiber.Ctx) error {
 		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
 	}
 
-	// get old photo files to be deleted later when delete is successful
-	oldPhotoFilePaths := []string{}
-	oldProdukResponse, err := p.produkUsecase.GetProdukByID(ctx, idInt)
+	err = p.produkUsecase.DestroyProduk(ctx, idInt, userId)
 	if err != nil {
-		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+		return helper.ResponseErrorJson(c, http.StatusBadRequest, err)
 	}
-	for _, fotoProdukResponse := range oldProdukResponse.Photos {
-		photoUrl := fotoProdukResponse.Url
-		photoFilename := path.Base(photoUrl)
-		rootFolderPath, err := filepath.Abs("./")
-		if err != nil {
-			return helper.ResponseErrorJson(c, fiber.StatusInternalServerError, err)
-		}
-		photoFilePath := filepath.Join(rootFolderPath, "uploads", "produk", photoFilename)
-		oldPhotoFilePaths = append(oldPhotoFilePaths, photoFilePath)
+
+	return helper.ResponseSuccessJson(c, "")
+}
+
+func (p *produkDelivery) RestoreProdukHandler(c *fiber.Ctx) error {
+	ctx := c.Context()
+	idString := c.Params("id")
+	idInt, err := strconv.Atoi(idString)
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("invalid id"))
 	}
 
-	err = p.produkUsecase.DestroyProduk(ctx, idInt, userId)
+	produkResponse, err := p.produkUsecase.RestoreProduk(ctx, idInt)
 	if err != nil {
 		return helper.ResponseErrorJson(c, http.StatusBadRequest, err)
 	}
 
-	// delete old photo files
-	for _, oldPhotoFilePath := range oldPhotoFilePaths {
-		errRemove := os.Remove(oldPhotoFilePath)
-		if errRemove != nil {
-			return helper.ResponseErrorJson(c, http.StatusInternalServerError, errRemove)
+	return helper.ResponseSuccessJson(c, produkResponse)
+}
+
+// parseHargaBuckets parses the comma separated upper bounds of the price facet buckets, e.g. "100000,500000"
+func parseHargaBuckets(s string) ([]model.Money, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) > model.PRODUK_FACET_MAX_HARGA_BUCKETS {
+		return nil, fmt.Errorf("harga buckets cannot have more than %d bounds", model.PRODUK_FACET_MAX_HARGA_BUCKETS)
+	}
+	bounds := []model.Money{}
+	for _, part := range parts {
+		bound, err := model.ParseMoney(strings.TrimSpace(part))
+		if err != nil {
+			return nil, errors.New("harga buckets must be comma separated integers")
+		}
+		if bound <= 0 || (len(bounds) > 0 && bound <= bounds[len(bounds)-1]) {
+			return nil, errors.New("harga buckets must be positive and ascending")
 		}
+		bounds = append(bounds, bound)
 	}
-
-	return helper.ResponseSuccessJson(c, "")
+	return bounds, nil
 }
@@ -0,0 +1,178 @@
+package delivery
+
+import (
+	"errors"
+	"fmt"
+	"marketplace-api/helper"
+	"marketplace-api/model"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type produkVariantDelivery struct {
+	produkVariantUsecase model.ProdukVariantUsecase
+}
+
+type ProdukVariantDelivery interface {
+	MountUnprotectedRoutes(group fiber.Router)
+	MountProtectedRoutes(
+		jwtMiddleware func(*fiber.Ctx) error,
+		idempotencyMiddleware func(*fiber.Ctx) error,
+		group fiber.Router,
+	)
+}
+
+func NewProdukVariantDelivery(produkVariantUsecase model.ProdukVariantUsecase) ProdukVariantDelivery {
+	return &produkVariantDelivery{produkVariantUsecase: produkVariantUsecase}
+}
+
+func (p *produkVariantDelivery) MountUnprotectedRoutes(group fiber.Router) {
+	group.Get("/:id/variant", p.FetchProdukVariantHandler)
+}
+
+func (p *produkVariantDelivery) MountProtectedRoutes(
+	jwtMiddleware func(*fiber.Ctx) error,
+	idempotencyMiddleware func(*fiber.Ctx) error,
+	group fiber.Router,
+) {
+	group.Post("/:id/variant", jwtMiddleware, idempotencyMiddleware, p.StoreProdukVariantHandler)
+	group.Put("/:id/variant/:variantId", jwtMiddleware, p.EditProdukVariantHandler)
+	group.Delete("/:id/variant/:variantId", jwtMiddleware, p.DeleteProdukVariantHandler)
+}
+
+func (p *produkVariantDelivery) FetchProdukVariantHandler(c *fiber.Ctx) error {
+	ctx := c.Context()
+	produkId, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("invalid id"))
+	}
+	produkVariantResponses, err := p.produkVariantUsecase.FetchProdukVariants(ctx, produkId)
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	return helper.ResponseSuccessJson(c, produkVariantResponses)
+}
+
+func (p *produkVariantDelivery) StoreProdukVariantHandler(c *fiber.Ctx) error {
+	ctx := c.Context()
+	var req model.ProdukVariantRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	if err := validateProdukVariantRequest(&req); err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+
+	produkId, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("invalid id"))
+	}
+	userId, err := helper.GetUserIdFromToken(c)
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	produkVariantResponse, err := p.produkVariantUsecase.StoreProdukVariant(ctx, produkId, userId, &req)
+	if err != nil {
+		if errors.Is(err, model.ErrDuplicateSku) {
+			return helper.ResponseErrorJson(c, fiber.StatusConflict, err)
+		}
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	return helper.ResponseSuccessJson(c, produkVariantResponse)
+}
+
+func (p *produkVariantDelivery) EditProdukVariantHandler(c *fiber.Ctx) error {
+	ctx := c.Context()
+	var req model.ProdukVariantRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	if err := validateProdukVariantRequest(&req); err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+
+	produkId, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("invalid id"))
+	}
+	produkVariantId, err := strconv.Atoi(c.Params("variantId"))
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("invalid variant id"))
+	}
+	userId, err := helper.GetUserIdFromToken(c)
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	produkVariantResponse, err := p.produkVariantUsecase.EditProdukVariant(ctx, produkId, produkVariantId, userId, &req)
+	if err != nil {
+		if errors.Is(err, model.ErrDuplicateSku) {
+			return helper.ResponseErrorJson(c, fiber.StatusConflict, err)
+		}
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	return helper.ResponseSuccessJson(c, produkVariantResponse)
+}
+
+func (p *produkVariantDelivery) DeleteProdukVariantHandler(c *fiber.Ctx) error {
+	ctx := c.Context()
+	produkId, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("invalid id"))
+	}
+	produkVariantId, err := strconv.Atoi(c.Params("variantId"))
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("invalid variant id"))
+	}
+	userId, err := helper.GetUserIdFromToken(c)
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	err = p.produkVariantUsecase.DestroyProdukVariant(ctx, produkId, produkVariantId, userId)
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	return helper.ResponseSuccessJson(c, "")
+}
+
+func validateProdukVariantRequest(req *model.ProdukVariantRequest) error {
+	req.Sku = strings.TrimSpace(req.Sku)
+	if req.Sku == "" {
+		return errors.New("sku must not be empty")
+	}
+	if len(req.Sku) > 255 {
+		return errors.New("sku must not exceed 255 characters")
+	}
+
+	if len(req.Atribut) == 0 {
+		return errors.New("atribut must not be empty")
+	}
+	if len(req.Atribut) > model.PRODUK_VARIANT_MAX_ATRIBUT {
+		return fmt.Errorf("atribut must not have more than %d options", model.PRODUK_VARIANT_MAX_ATRIBUT)
+	}
+	atribut := map[string]string{}
+	for nama, nilai := range req.Atribut {
+		nama = strings.TrimSpace(nama)
+		nilai = strings.TrimSpace(nilai)
+		if nama == "" || nilai == "" {
+			return errors.New("atribut names and values must not be empty")
+		}
+		if len(nama) > 255 || len(nilai) > 255 {
+			return errors.New("atribut names and values must not exceed 255 characters")
+		}
+		atribut[nama] = nilai
+	}
+	req.Atribut = atribut
+
+	if req.HargaReseller <= 0 {
+		return errors.New("invalid harga reseller")
+	}
+	if req.HargaKonsumen <= 0 {
+		return errors.New("invalid harga konsumen")
+	}
+	if req.Stok < 0 {
+		return errors.New("invalid stok")
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package delivery
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"marketplace-api/helper"
+	"marketplace-api/model"
+	"mime/multipart"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// putPhoto checks and re-encodes an uploaded photo, then stores it and its renditions in folder.
+// It returns the key of the photo, the keys of the renditions follow from model.RenditionKey
+func putPhoto(
+	ctx context.Context,
+	storage model.Storage,
+	folder string,
+	photo *multipart.FileHeader,
+	renditions []model.ImageRendition,
+) (string, error) {
+	if photo.Size > model.IMAGE_MAX_BYTES {
+		return "", model.ErrImageTooLarge
+	}
+	file, err := photo.Open()
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	processedImage, err := helper.ProcessImage(file, renditions)
+	if err != nil {
+		return "", err
+	}
+
+	key := folder + "/" + uuid.NewString() + "." + processedImage.Original.Extension
+	encodedImageByKey := map[string]*model.EncodedImage{key: processedImage.Original}
+	for name, encodedImage := range processedImage.Renditions {
+		encodedImageByKey[model.RenditionKey(key, name)] = encodedImage
+	}
+
+	putKeys := []string{}
+	for objectKey, encodedImage := range encodedImageByKey {
+		body := bytes.NewReader(encodedImage.Body)
+		err := storage.Put(ctx, objectKey, body, int64(len(encodedImage.Body)), encodedImage.ContentType)
+		if err != nil {
+			// do not leave a photo with missing renditions behind
+			errDelete := deletePhotos(ctx, storage, putKeys)
+			if errDelete != nil {
+				return "", errDelete
+			}
+			return "", err
+		}
+		putKeys = append(putKeys, objectKey)
+	}
+	return key, nil
+}
+
+// deletePhotos removes photos that were put but are not going to be referenced by the database
+func deletePhotos(ctx context.Context, storage model.Storage, keys []string) error {
+	for _, key := range keys {
+		err := storage.Delete(ctx, key)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteFotoProduk removes product photos together with their renditions
+func deleteFotoProduk(ctx context.Context, storage model.Storage, keys []string) error {
+	for _, key := range keys {
+		err := deletePhotos(ctx, storage, model.FotoProdukObjectKeys(key))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// putPhotoErrorCode tells a rejected photo apart from a failing storage
+func putPhotoErrorCode(err error) int {
+	if errors.Is(err, model.ErrImageTooLarge) {
+		return fiber.StatusRequestEntityTooLarge
+	}
+	if errors.Is(err, model.ErrImageUnsupportedFormat) || errors.Is(err, model.ErrImageDimensionsTooLarge) {
+		return fiber.StatusBadRequest
+	}
+	return fiber.StatusInternalServerError
+}
@@ -5,29 +5,31 @@ import (
 	"marketplace-api/helper"
 	"marketplace-api/model"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 type tokoDelivery struct {
 	tokoUsecase model.TokoUsecase
+	trxUsecase  model.TrxUsecase
+	storage     model.Storage
 }
 
 type TokoDelivery interface {
 	MountProtectedRoutes(jwtMiddleware func(*fiber.Ctx) error, group fiber.Router)
 }
 
-func NewTokoDelivery(tokoUsecase model.TokoUsecase) TokoDelivery {
-	return &tokoDelivery{tokoUsecase: tokoUsecase}
+func NewTokoDelivery(tokoUsecase model.TokoUsecase, trxUsecase model.TrxUsecase, storage model.Storage) TokoDelivery {
+	return &tokoDelivery{tokoUsecase: tokoUsecase, trxUsecase: trxUsecase, storage: storage}
 }
 
 func (p *tokoDelivery) MountProtectedRoutes(jwtMiddleware func(*fiber.Ctx) error, group fiber.Router) {
 	group.Get("", jwtMiddleware, p.FetchAndPaginateTokoHandler)
 	group.Get("/my", jwtMiddleware, p.MyTokoHandler)
+	group.Get("/my/orders", jwtMiddleware, p.MyTokoOrdersHandler)
 	group.Get("/:id_toko", jwtMiddleware, p.DetailTokoHandler)
 	group.Put("/:id_toko", jwtMiddleware, p.EditTokoHandler)
 }
@@ -35,35 +37,12 @@ func (p *tokoDelivery) MountProtectedRoutes(jwtMiddleware func(*fiber.Ctx) error
 func (p *tokoDelivery) FetchAndPaginateTokoHandler(c *fiber.Ctx) error {
 	ctx := c.Context()
 	req := new(model.TokoFetchPaginateRequest)
-	limitString := strings.TrimSpace(c.Query("limit"))
-	pageString := strings.TrimSpace(c.Query("page"))
-	namaString := strings.TrimSpace(c.Query("nama"))
-	limitInt, pageInt := -1, 1
-	var err error
-	if limitString != "" {
-		limitInt, err = strconv.Atoi(limitString)
-		if err != nil {
-			return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("limit must be integer"))
-		}
-		if limitInt < 1 {
-			return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("limit must be greater than zero"))
-		}
-	}
-	if pageString != "" {
-		if limitString == "" {
-			return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("must use limit query param when using page query param"))
-		}
-		pageInt, err = strconv.Atoi(pageString)
-		if err != nil {
-			return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("page must be integer"))
-		}
-		if pageInt < 1 {
-			return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("page must be greater than zero"))
-		}
+	paginationRequest, err := model.ParsePaginationRequest(c.Query("limit"), c.Query("page"))
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
 	}
-	req.Limit = limitInt
-	req.Page = pageInt
-	req.Nama = namaString
+	req.PaginationRequest = paginationRequest
+	req.Nama = strings.TrimSpace(c.Query("nama"))
 	tokoFetchPaginateResponse, err := p.tokoUsecase.FetchAndPaginateToko(ctx, req)
 	if err != nil {
 		return helper.ResponseErrorJson(c, fiber.StatusInternalServerError, err)
@@ -98,6 +77,69 @@ func (p *tokoDelivery) MyTokoHandler(c *fiber.Ctx) error {
 	return helper.ResponseSuccessJson(c, getMyTokoResponse)
 }
 
+func (p *tokoDelivery) MyTokoOrdersHandler(c *fiber.Ctx) error {
+	ctx := c.Context()
+	req := new(model.TokoOrderFetchRequest)
+	var err error
+
+	startDateString := strings.TrimSpace(c.Query("start_date"))
+	if startDateString != "" {
+		req.StartDate, err = time.ParseInLocation(model.TOKO_ORDER_DATE_FORMAT, startDateString, time.Local)
+		if err != nil {
+			return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("start date must be in format: dd/mm/yyyy"))
+		}
+	}
+	endDateString := strings.TrimSpace(c.Query("end_date"))
+	if endDateString != "" {
+		req.EndDate, err = time.ParseInLocation(model.TOKO_ORDER_DATE_FORMAT, endDateString, time.Local)
+		if err != nil {
+			return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("end date must be in format: dd/mm/yyyy"))
+		}
+	}
+	if !req.StartDate.IsZero() && !req.EndDate.IsZero() && req.EndDate.Before(req.StartDate) {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("end date must not be before start date"))
+	}
+
+	status := model.TrxStatus(strings.TrimSpace(c.Query("status")))
+	if status != "" && !status.IsValid() {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("invalid status"))
+	}
+	req.Status = status
+
+	productIdString := strings.TrimSpace(c.Query("product_id"))
+	productIdInt := -1
+	if productIdString != "" {
+		productIdInt, err = strconv.Atoi(productIdString)
+		if err != nil {
+			return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("product id must be integer"))
+		}
+	}
+	req.ProductId = productIdInt
+
+	kodeInvoice := strings.TrimSpace(c.Query("kode_invoice"))
+	if len(kodeInvoice) > 255 {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("kode invoice cannot exceed 255 characters"))
+	}
+	req.KodeInvoice = kodeInvoice
+
+	paginationRequest, err := model.ParsePaginationRequest(c.Query("limit"), c.Query("page"))
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	req.PaginationRequest = paginationRequest
+
+	userId, err := helper.GetUserIdFromToken(c)
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+
+	tokoOrderFetchResponse, err := p.trxUsecase.FetchTokoOrders(ctx, req, userId)
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	return helper.ResponseSuccessJson(c, tokoOrderFetchResponse)
+}
+
 func (p *tokoDelivery) EditTokoHandler(c *fiber.Ctx) error {
 	ctx := c.Context()
 	var req model.TokoUpdateRequest
@@ -128,33 +170,18 @@ func (p *tokoDelivery) EditTokoHandler(c *fiber.Ctx) error {
 	if err != nil {
 		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
 	}
-	fileExtension := photo.Filename[strings.LastIndex(photo.Filename, ".")+1:]
-	if fileExtension != "jpg" &&
-		fileExtension != "jpeg" &&
-		fileExtension != "png" &&
-		fileExtension != "webp" &&
-		fileExtension != "jfif" {
-		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("photo must be jpg/jpeg/png/webp/jfif"))
-	}
-	photoFilename := idTokoString + "." + fileExtension
-	rootFolderPath, err := filepath.Abs("./")
-	if err != nil {
-		return helper.ResponseErrorJson(c, fiber.StatusInternalServerError, err)
-	}
-	photoFilePath := filepath.Join(rootFolderPath, "uploads", "toko", photoFilename)
-	urlFoto := c.BaseURL() + "/uploads/toko/" + photoFilename
-	req.UrlFoto = urlFoto
-
-	err = c.SaveFile(photo, photoFilePath)
+	// a new key for every upload, the old photo is deleted by the usecase once the toko is updated
+	photoKey, err := putPhoto(ctx, p.storage, model.STORAGE_FOLDER_TOKO, photo, model.TOKO_FOTO_RENDITIONS)
 	if err != nil {
-		return helper.ResponseErrorJson(c, http.StatusInternalServerError, err)
+		return helper.ResponseErrorJson(c, putPhotoErrorCode(err), err)
 	}
+	req.UrlFoto = photoKey
 
 	tokoUpdateResponse, err := p.tokoUsecase.EditToko(ctx, &req)
 	if err != nil {
-		errRemove := os.Remove(photoFilePath)
-		if errRemove != nil {
-			return helper.ResponseErrorJson(c, http.StatusInternalServerError, errRemove)
+		errDelete := deletePhotos(ctx, p.storage, model.TokoFotoObjectKeys(photoKey))
+		if errDelete != nil {
+			return helper.ResponseErrorJson(c, http.StatusInternalServerError, errDelete)
 		}
 		return helper.ResponseErrorJson(c, http.StatusBadRequest, err)
 	}
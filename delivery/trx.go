@@ -15,17 +15,28 @@ type trxDelivery struct {
 }
 
 type TrxDelivery interface {
-	MountProtectedRoutes(jwtMiddleware func(*fiber.Ctx) error, group fiber.Router)
+	MountProtectedRoutes(
+		jwtMiddleware func(*fiber.Ctx) error,
+		idempotencyMiddleware func(*fiber.Ctx) error,
+		group fiber.Router,
+	)
 }
 
 func NewTrxDelivery(trxUsecase model.TrxUsecase) TrxDelivery {
 	return &trxDelivery{trxUsecase: trxUsecase}
 }
 
-func (p *trxDelivery) MountProtectedRoutes(jwtMiddleware func(*fiber.Ctx) error, group fiber.Router) {
-	group.Post("", jwtMiddleware, p.StoreTrxHandler)
+func (p *trxDelivery) MountProtectedRoutes(
+	jwtMiddleware func(*fiber.Ctx) error,
+	idempotencyMiddleware func(*fiber.Ctx) error,
+	group fiber.Router,
+) {
+	group.Post("", jwtMiddleware, idempotencyMiddleware, p.StoreTrxHandler)
 	group.Get("", jwtMiddleware, p.FetchTrxHandler)
 	group.Get("/:id", jwtMiddleware, p.GetTrxByIDHandler)
+	group.Get("/:id/status", jwtMiddleware, p.GetTrxStatusHandler)
+	group.Put("/:id/status", jwtMiddleware, idempotencyMiddleware, p.EditTrxStatusHandler)
+	group.Post("/:id/cancel", jwtMiddleware, idempotencyMiddleware, p.CancelTrxHandler)
 }
 
 func (p *trxDelivery) StoreTrxHandler(c *fiber.Ctx) error {
@@ -53,6 +64,9 @@ func (p *trxDelivery) StoreTrxHandler(c *fiber.Ctx) error {
 		if produkId <= 0 {
 			return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("invalid product id"))
 		}
+		if detailTrxRequest.VariantId < 0 {
+			return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("invalid variant id"))
+		}
 
 		kuantitas := detailTrxRequest.Kuantitas
 		if kuantitas <= 0 {
@@ -60,6 +74,12 @@ func (p *trxDelivery) StoreTrxHandler(c *fiber.Ctx) error {
 		}
 	}
 
+	// voucher codes are stored in upper case
+	req.KodeVoucher = strings.ToUpper(strings.TrimSpace(req.KodeVoucher))
+	if len(req.KodeVoucher) > 255 {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("kode voucher must not exceed 255 characters"))
+	}
+
 	userId, err := helper.GetUserIdFromToken(c)
 	if err != nil {
 		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
@@ -83,33 +103,11 @@ func (p *trxDelivery) FetchTrxHandler(c *fiber.Ctx) error {
 	}
 	req.Search = namaProdukSearch
 
-	limitString := strings.TrimSpace(c.Query("limit"))
-	pageString := strings.TrimSpace(c.Query("page"))
-	limitInt, pageInt := -1, 1
-	var err error
-	if limitString != "" {
-		limitInt, err = strconv.Atoi(limitString)
-		if err != nil {
-			return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("limit must be integer"))
-		}
-		if limitInt < 1 {
-			return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("limit must be greater than zero"))
-		}
-	}
-	if pageString != "" {
-		if limitString == "" {
-			return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("must use limit query param when using page query param"))
-		}
-		pageInt, err = strconv.Atoi(pageString)
-		if err != nil {
-			return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("page must be integer"))
-		}
-		if pageInt < 1 {
-			return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("page must be greater than zero"))
-		}
+	paginationRequest, err := model.ParsePaginationRequestWithCursor(c.Query("limit"), c.Query("page"), c.Query("cursor"))
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
 	}
-	req.Limit = limitInt
-	req.Page = pageInt
+	req.PaginationRequest = paginationRequest
 
 	userId, err := helper.GetUserIdFromToken(c)
 	if err != nil {
@@ -142,3 +140,96 @@ func (p *trxDelivery) GetTrxByIDHandler(c *fiber.Ctx) error {
 	}
 	return helper.ResponseSuccessJson(c, trxGetByIdResponse)
 }
+
+func (p *trxDelivery) GetTrxStatusHandler(c *fiber.Ctx) error {
+	ctx := c.Context()
+	trxIdString := c.Params("id")
+	trxIdInt, err := strconv.Atoi(trxIdString)
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("invalid id"))
+	}
+
+	userId, err := helper.GetUserIdFromToken(c)
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	isAdmin := helper.IsAdminFromToken(c)
+
+	trxStatusResponse, err := p.trxUsecase.GetTrxStatus(ctx, trxIdInt, userId, isAdmin)
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	return helper.ResponseSuccessJson(c, trxStatusResponse)
+}
+
+func (p *trxDelivery) EditTrxStatusHandler(c *fiber.Ctx) error {
+	ctx := c.Context()
+	var req model.TrxStatusUpdateRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	req.Trim()
+	if req.Status == "" {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("status must not be empty"))
+	}
+	if len(req.Catatan) > 255 {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("catatan must not exceed 255 characters"))
+	}
+	// the catatan becomes the alasan of the cancellation, like on the cancel endpoint it is required
+	if req.Status == model.TRX_STATUS_CANCELLED && req.Catatan == "" {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("catatan must not be empty when cancelling"))
+	}
+
+	trxIdString := c.Params("id")
+	trxIdInt, err := strconv.Atoi(trxIdString)
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("invalid id"))
+	}
+
+	userId, err := helper.GetUserIdFromToken(c)
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	isAdmin := helper.IsAdminFromToken(c)
+
+	trxStatusResponse, err := p.trxUsecase.EditTrxStatus(ctx, trxIdInt, userId, isAdmin, &req)
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	return helper.ResponseSuccessJson(c, trxStatusResponse)
+}
+
+func (p *trxDelivery) CancelTrxHandler(c *fiber.Ctx) error {
+	ctx := c.Context()
+	var req model.TrxCancelRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	req.Trim()
+	if req.Alasan == "" {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("alasan must not be empty"))
+	}
+	if len(req.Alasan) > 255 {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("alasan must not exceed 255 characters"))
+	}
+
+	trxIdString := c.Params("id")
+	trxIdInt, err := strconv.Atoi(trxIdString)
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("invalid id"))
+	}
+
+	userId, err := helper.GetUserIdFromToken(c)
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	isAdmin := helper.IsAdminFromToken(c)
+
+	trxStatusResponse, err := p.trxUsecase.CancelTrx(ctx, trxIdInt, userId, isAdmin, &req)
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	return helper.ResponseSuccessJson(c, trxStatusResponse)
+}
@@ -1,9 +1,11 @@
 package delivery
 
 import (
+	"errors"
 	"marketplace-api/helper"
 	"marketplace-api/model"
 	"net/http"
+	"strconv"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -23,6 +25,7 @@ func NewUserDelivery(userUsecase model.UserUsecase) UserDelivery {
 func (p *userDelivery) MountProtectedRoutes(jwtMiddleware func(*fiber.Ctx) error, group fiber.Router) {
 	group.Get("", jwtMiddleware, p.GetCurrentUserHandler)
 	group.Put("", jwtMiddleware, p.EditCurrentUserHandler)
+	group.Put("/:id/reseller", jwtMiddleware, helper.CheckAdminTokenHandler, p.EditUserResellerHandler)
 }
 
 func (p *userDelivery) GetCurrentUserHandler(c *fiber.Ctx) error {
@@ -58,3 +61,20 @@ func (p *userDelivery) EditCurrentUserHandler(c *fiber.Ctx) error {
 	}
 	return helper.ResponseSuccessJson(c, userResponse)
 }
+
+func (p *userDelivery) EditUserResellerHandler(c *fiber.Ctx) error {
+	ctx := c.Context()
+	var req model.UserResellerRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	userId, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("invalid id"))
+	}
+	userResponse, err := p.userUsecase.EditUserReseller(ctx, userId, &req)
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	return helper.ResponseSuccessJson(c, userResponse)
+}
@@ -0,0 +1,220 @@
+package delivery
+
+import (
+	"errors"
+	"marketplace-api/helper"
+	"marketplace-api/model"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type voucherDelivery struct {
+	voucherUsecase model.VoucherUsecase
+}
+
+type VoucherDelivery interface {
+	MountProtectedRoutes(
+		jwtMiddleware func(*fiber.Ctx) error,
+		idempotencyMiddleware func(*fiber.Ctx) error,
+		group fiber.Router,
+	)
+}
+
+func NewVoucherDelivery(voucherUsecase model.VoucherUsecase) VoucherDelivery {
+	return &voucherDelivery{voucherUsecase: voucherUsecase}
+}
+
+func (p *voucherDelivery) MountProtectedRoutes(
+	jwtMiddleware func(*fiber.Ctx) error,
+	idempotencyMiddleware func(*fiber.Ctx) error,
+	group fiber.Router,
+) {
+	group.Post("", jwtMiddleware, idempotencyMiddleware, p.StoreVoucherHandler)
+	group.Get("", jwtMiddleware, p.FetchVoucherHandler)
+	group.Get("/:id", jwtMiddleware, p.GetVoucherByIDHandler)
+	group.Put("/:id", jwtMiddleware, p.EditVoucherHandler)
+	group.Delete("/:id", jwtMiddleware, p.DeleteVoucherHandler)
+}
+
+func (p *voucherDelivery) StoreVoucherHandler(c *fiber.Ctx) error {
+	ctx := c.Context()
+	var req model.VoucherRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	if err := validateVoucherRequest(&req); err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+
+	userId, err := helper.GetUserIdFromToken(c)
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	isAdmin := helper.IsAdminFromToken(c)
+
+	voucherResponse, err := p.voucherUsecase.StoreVoucher(ctx, userId, isAdmin, &req)
+	if err != nil {
+		if errors.Is(err, model.ErrDuplicateKodeVoucher) {
+			return helper.ResponseErrorJson(c, fiber.StatusConflict, err)
+		}
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	return helper.ResponseSuccessJson(c, voucherResponse)
+}
+
+func (p *voucherDelivery) FetchVoucherHandler(c *fiber.Ctx) error {
+	ctx := c.Context()
+	req := new(model.VoucherFetchRequest)
+	paginationRequest, err := model.ParsePaginationRequest(c.Query("limit"), c.Query("page"))
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	req.PaginationRequest = paginationRequest
+
+	userId, err := helper.GetUserIdFromToken(c)
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	isAdmin := helper.IsAdminFromToken(c)
+
+	voucherFetchResponse, err := p.voucherUsecase.FetchVoucher(ctx, userId, isAdmin, req)
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	return helper.ResponseSuccessJson(c, voucherFetchResponse)
+}
+
+func (p *voucherDelivery) GetVoucherByIDHandler(c *fiber.Ctx) error {
+	ctx := c.Context()
+	voucherId, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("invalid id"))
+	}
+
+	userId, err := helper.GetUserIdFromToken(c)
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	isAdmin := helper.IsAdminFromToken(c)
+
+	voucherResponse, err := p.voucherUsecase.GetVoucherByID(ctx, voucherId, userId, isAdmin)
+	if err != nil {
+		if errors.Is(err, model.ErrVoucherNotFound) {
+			return helper.ResponseErrorJson(c, fiber.StatusNotFound, err)
+		}
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	return helper.ResponseSuccessJson(c, voucherResponse)
+}
+
+func (p *voucherDelivery) EditVoucherHandler(c *fiber.Ctx) error {
+	ctx := c.Context()
+	var req model.VoucherRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	if err := validateVoucherRequest(&req); err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+
+	voucherId, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("invalid id"))
+	}
+	userId, err := helper.GetUserIdFromToken(c)
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	isAdmin := helper.IsAdminFromToken(c)
+
+	voucherResponse, err := p.voucherUsecase.EditVoucher(ctx, voucherId, userId, isAdmin, &req)
+	if err != nil {
+		if errors.Is(err, model.ErrVoucherNotFound) {
+			return helper.ResponseErrorJson(c, fiber.StatusNotFound, err)
+		}
+		if errors.Is(err, model.ErrDuplicateKodeVoucher) {
+			return helper.ResponseErrorJson(c, fiber.StatusConflict, err)
+		}
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	return helper.ResponseSuccessJson(c, voucherResponse)
+}
+
+func (p *voucherDelivery) DeleteVoucherHandler(c *fiber.Ctx) error {
+	ctx := c.Context()
+	voucherId, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, errors.New("invalid id"))
+	}
+
+	userId, err := helper.GetUserIdFromToken(c)
+	if err != nil {
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	isAdmin := helper.IsAdminFromToken(c)
+
+	err = p.voucherUsecase.DestroyVoucher(ctx, voucherId, userId, isAdmin)
+	if err != nil {
+		if errors.Is(err, model.ErrVoucherNotFound) {
+			return helper.ResponseErrorJson(c, fiber.StatusNotFound, err)
+		}
+		return helper.ResponseErrorJson(c, fiber.StatusBadRequest, err)
+	}
+	return helper.ResponseSuccessJson(c, "")
+}
+
+func validateVoucherRequest(req *model.VoucherRequest) error {
+	// voucher codes are stored in upper case so buyers can type them in any case
+	req.Kode = strings.ToUpper(strings.TrimSpace(req.Kode))
+	if req.Kode == "" {
+		return errors.New("kode must not be empty")
+	}
+	if len(req.Kode) > 255 {
+		return errors.New("kode must not exceed 255 characters")
+	}
+
+	switch req.JenisDiskon {
+	case model.VOUCHER_JENIS_DISKON_PERSEN:
+		if req.NilaiDiskon <= 0 || req.NilaiDiskon > 100 {
+			return errors.New("nilai diskon must be between 1 and 100 for a persen voucher")
+		}
+	case model.VOUCHER_JENIS_DISKON_NOMINAL:
+		if req.NilaiDiskon <= 0 {
+			return errors.New("invalid nilai diskon")
+		}
+		if req.MaksDiskon != 0 {
+			return errors.New("maks diskon can only be used by a persen voucher")
+		}
+	default:
+		return errors.New("jenis diskon must be persen or nominal")
+	}
+
+	if req.MaksDiskon < 0 {
+		return errors.New("invalid maks diskon")
+	}
+	if req.MinBelanja < 0 {
+		return errors.New("invalid min belanja")
+	}
+	if req.KuotaTotal < 0 {
+		return errors.New("invalid kuota total")
+	}
+	if req.KuotaPerUser < 0 {
+		return errors.New("invalid kuota per user")
+	}
+
+	if req.BerlakuMulai.IsZero() || req.BerlakuSampai.IsZero() {
+		return errors.New("berlaku mulai and berlaku sampai must not be empty")
+	}
+	if !req.BerlakuSampai.After(req.BerlakuMulai) {
+		return errors.New("berlaku sampai must be after berlaku mulai")
+	}
+
+	for _, categoryId := range req.CategoryIds {
+		if categoryId <= 0 {
+			return errors.New("invalid category id")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"marketplace-api/config"
+	"marketplace-api/model"
+	"marketplace-api/repository"
+	"marketplace-api/usecase"
+)
+
+// RunGcUploads handles `gc-uploads [-grace 24h] [-delete]`, it lists the orphaned uploads and deletes them with -delete
+func RunGcUploads(cfg config.Config, args []string) {
+	flags := flag.NewFlagSet("gc-uploads", flag.ExitOnError)
+	gracePeriod := flags.Duration("grace", cfg.UploadGcGracePeriod(), "skip uploads younger than this")
+	deleteOrphans := flags.Bool("delete", false, "delete the orphaned uploads instead of only listing them")
+	flags.Parse(args)
+
+	orphanUploadUsecase := usecase.NewOrphanUploadUsecase(
+		repository.NewFotoProdukRepository(cfg),
+		repository.NewTokoRepository(cfg),
+		newStorage(cfg),
+	)
+	report, err := orphanUploadUsecase.CollectOrphanUploads(context.Background(), &model.OrphanUploadRequest{
+		GracePeriod: *gracePeriod,
+		Delete:      *deleteOrphans,
+	})
+	if report != nil {
+		for _, orphan := range report.Orphans {
+			fmt.Printf("%s\t%d\t%s\n", orphan.Key, orphan.Size, orphan.LastModified.Format("2006-01-02 15:04:05"))
+		}
+		for _, failure := range report.Failures {
+			fmt.Printf("failed to delete %s: %v\n", failure.Key, failure.Err)
+		}
+		fmt.Printf("scanned %d upload(s), %d orphaned, %d deleted\n", report.Scanned, len(report.Orphans), report.Deleted)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(report.Failures) > 0 {
+		log.Fatalf("%d orphaned upload(s) could not be deleted", len(report.Failures))
+	}
+}
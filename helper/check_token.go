@@ -33,3 +33,10 @@ func GetUserIdFromToken(c *fiber.Ctx) (int, error) {
 	}
 	return idInt, nil
 }
+
+func IsAdminFromToken(c *fiber.Ctx) bool {
+	token := c.Locals("user").(*jwt.Token)
+	claims := token.Claims.(jwt.MapClaims)
+	isAdmin, _ := claims["isAdmin"].(bool)
+	return isAdmin
+}
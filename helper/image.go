@@ -0,0 +1,217 @@
+package helper
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"marketplace-api/model"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+type imageFormat struct {
+	decode       func(io.Reader) (image.Image, error)
+	decodeConfig func(io.Reader) (image.Config, error)
+}
+
+var (
+	jpegMagic = []byte{0xFF, 0xD8, 0xFF}
+	pngMagic  = []byte("\x89PNG\r\n\x1a\n")
+)
+
+// ProcessImage sniffs the format from the magic bytes, checks the size and dimensions, and re-encodes
+// the photo and its renditions. Re-encoding drops the EXIF data, so the EXIF orientation is applied first
+func ProcessImage(r io.Reader, renditions []model.ImageRendition) (*model.ProcessedImage, error) {
+	body, err := io.ReadAll(io.LimitReader(r, model.IMAGE_MAX_BYTES+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > model.IMAGE_MAX_BYTES {
+		return nil, model.ErrImageTooLarge
+	}
+
+	format, ok := sniffImageFormat(body)
+	if !ok {
+		return nil, model.ErrImageUnsupportedFormat
+	}
+	config, err := format.decodeConfig(bytes.NewReader(body))
+	if err != nil {
+		return nil, model.ErrImageUnsupportedFormat
+	}
+	if config.Width > model.IMAGE_MAX_DIMENSION || config.Height > model.IMAGE_MAX_DIMENSION {
+		return nil, model.ErrImageDimensionsTooLarge
+	}
+	img, err := format.decode(bytes.NewReader(body))
+	if err != nil {
+		return nil, model.ErrImageUnsupportedFormat
+	}
+	if bytes.HasPrefix(body, jpegMagic) {
+		img = orientImage(img, jpegOrientation(body))
+	}
+
+	processedImage := new(model.ProcessedImage)
+	processedImage.Original, err = encodeImage(img)
+	if err != nil {
+		return nil, err
+	}
+	processedImage.Renditions = map[string]*model.EncodedImage{}
+	for _, rendition := range renditions {
+		processedImage.Renditions[rendition.Name], err = encodeImage(resizeImage(img, rendition.MaxDimension))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return processedImage, nil
+}
+
+func sniffImageFormat(body []byte) (imageFormat, bool) {
+	switch {
+	case bytes.HasPrefix(body, jpegMagic):
+		return imageFormat{decode: jpeg.Decode, decodeConfig: jpeg.DecodeConfig}, true
+	case bytes.HasPrefix(body, pngMagic):
+		return imageFormat{decode: png.Decode, decodeConfig: png.DecodeConfig}, true
+	case len(body) >= 12 && string(body[0:4]) == "RIFF" && string(body[8:12]) == "WEBP":
+		return imageFormat{decode: webp.Decode, decodeConfig: webp.DecodeConfig}, true
+	}
+	return imageFormat{}, false
+}
+
+// encodeImage writes opaque images as jpeg and keeps transparent ones as png
+func encodeImage(img image.Image) (*model.EncodedImage, error) {
+	var buffer bytes.Buffer
+	if opaque, ok := img.(interface{ Opaque() bool }); ok && opaque.Opaque() {
+		err := jpeg.Encode(&buffer, img, &jpeg.Options{Quality: model.IMAGE_JPEG_QUALITY})
+		if err != nil {
+			return nil, err
+		}
+		return &model.EncodedImage{Body: buffer.Bytes(), ContentType: "image/jpeg", Extension: "jpg"}, nil
+	}
+	err := png.Encode(&buffer, img)
+	if err != nil {
+		return nil, err
+	}
+	return &model.EncodedImage{Body: buffer.Bytes(), ContentType: "image/png", Extension: "png"}, nil
+}
+
+// resizeImage scales img down to fit in a maxDimension square, smaller images are never scaled up
+func resizeImage(img image.Image, maxDimension int) image.Image {
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	if width <= maxDimension && height <= maxDimension {
+		return img
+	}
+	if width >= height {
+		height = height * maxDimension / width
+		width = maxDimension
+	} else {
+		width = width * maxDimension / height
+		height = maxDimension
+	}
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+	resized := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(resized, resized.Bounds(), img, img.Bounds(), draw.Src, nil)
+	return resized
+}
+
+// jpegOrientation reads the EXIF orientation tag of a jpeg, 1 means the pixels are already upright
+func jpegOrientation(body []byte) int {
+	// skip the SOI marker and walk the segments until the image data starts
+	i := 2
+	for i+4 <= len(body) && body[i] == 0xFF {
+		marker := body[i+1]
+		if marker == 0xDA {
+			break
+		}
+		length := int(binary.BigEndian.Uint16(body[i+2 : i+4]))
+		if length < 2 || i+2+length > len(body) {
+			break
+		}
+		segment := body[i+4 : i+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+		i += 2 + length
+	}
+	return 1
+}
+
+// exifOrientation looks for the orientation tag (0x0112) in IFD0 of the TIFF structure of an EXIF segment
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var byteOrder binary.ByteOrder
+	switch string(tiff[0:2]) {
+	case "II":
+		byteOrder = binary.LittleEndian
+	case "MM":
+		byteOrder = binary.BigEndian
+	default:
+		return 1
+	}
+	ifdOffset := int(byteOrder.Uint32(tiff[4:8]))
+	if ifdOffset < 8 || ifdOffset+2 > len(tiff) {
+		return 1
+	}
+	entryCount := int(byteOrder.Uint16(tiff[ifdOffset : ifdOffset+2]))
+	for n := 0; n < entryCount; n++ {
+		entry := ifdOffset + 2 + n*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if byteOrder.Uint16(tiff[entry:entry+2]) == 0x0112 {
+			orientation := int(byteOrder.Uint16(tiff[entry+8 : entry+10]))
+			if orientation < 1 || orientation > 8 {
+				return 1
+			}
+			return orientation
+		}
+	}
+	return 1
+}
+
+// orientImage flips and rotates img as described by an EXIF orientation
+func orientImage(img image.Image, orientation int) image.Image {
+	if orientation <= 1 {
+		return img
+	}
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	// orientations 5 to 8 swap the width and the height
+	dstWidth, dstHeight := width, height
+	if orientation >= 5 {
+		dstWidth, dstHeight = height, width
+	}
+	oriented := image.NewNRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var dstX, dstY int
+			switch orientation {
+			case 2:
+				dstX, dstY = width-1-x, y
+			case 3:
+				dstX, dstY = width-1-x, height-1-y
+			case 4:
+				dstX, dstY = x, height-1-y
+			case 5:
+				dstX, dstY = y, x
+			case 6:
+				dstX, dstY = height-1-y, x
+			case 7:
+				dstX, dstY = height-1-y, width-1-x
+			case 8:
+				dstX, dstY = y, width-1-x
+			}
+			oriented.Set(dstX, dstY, img.At(bounds.Min.X+x, bounds.Min.Y+y))
+		}
+	}
+	return oriented
+}
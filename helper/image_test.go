@@ -0,0 +1,231 @@
+package helper
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"marketplace-api/model"
+	"os"
+	"testing"
+)
+
+func encodePng(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buffer bytes.Buffer
+	if err := png.Encode(&buffer, img); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes()
+}
+
+func encodeJpeg(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buffer bytes.Buffer
+	if err := jpeg.Encode(&buffer, img, &jpeg.Options{Quality: 100}); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes()
+}
+
+// filledImage is an opaque image, its left half red and its right half blue
+func filledImage(width int, height int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := color.NRGBA{R: 255, A: 255}
+			if x >= width/2 {
+				c = color.NRGBA{B: 255, A: 255}
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// exifSegment builds an APP1 segment with only the orientation tag in IFD0
+func exifSegment(byteOrder binary.ByteOrder, orientation uint16) []byte {
+	tiff := make([]byte, 8+2+12+4)
+	if byteOrder == binary.LittleEndian {
+		copy(tiff, "II")
+	} else {
+		copy(tiff, "MM")
+	}
+	byteOrder.PutUint16(tiff[2:4], 42)
+	byteOrder.PutUint32(tiff[4:8], 8)
+	byteOrder.PutUint16(tiff[8:10], 1)
+	byteOrder.PutUint16(tiff[10:12], 0x0112)
+	byteOrder.PutUint16(tiff[12:14], 3)
+	byteOrder.PutUint32(tiff[14:18], 1)
+	byteOrder.PutUint16(tiff[18:20], orientation)
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:4], uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+// withExif inserts segment right after the SOI marker of a jpeg
+func withExif(body []byte, segment []byte) []byte {
+	result := append([]byte{}, body[:2]...)
+	result = append(result, segment...)
+	return append(result, body[2:]...)
+}
+
+func TestProcessImageFormats(t *testing.T) {
+	webpBody, err := os.ReadFile("testdata/gopher.webp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	transparent := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	jpegBody := encodeJpeg(t, filledImage(4, 4))
+
+	tests := []struct {
+		name            string
+		body            []byte
+		wantErr         error
+		wantContentType string
+	}{
+		{name: "jpeg", body: jpegBody, wantContentType: "image/jpeg"},
+		{name: "opaque png", body: encodePng(t, filledImage(4, 4)), wantContentType: "image/jpeg"},
+		{name: "transparent png", body: encodePng(t, transparent), wantContentType: "image/png"},
+		{name: "webp", body: webpBody, wantContentType: "image/jpeg"},
+		{name: "gif", body: []byte("GIF89a\x01\x00\x01\x00\x00\x00\x00;"), wantErr: model.ErrImageUnsupportedFormat},
+		{name: "text", body: []byte("<?php echo 1; ?>"), wantErr: model.ErrImageUnsupportedFormat},
+		{name: "empty", body: []byte{}, wantErr: model.ErrImageUnsupportedFormat},
+		{name: "jpeg magic with garbage", body: append([]byte{0xFF, 0xD8, 0xFF}, "garbage"...), wantErr: model.ErrImageUnsupportedFormat},
+		{name: "truncated png", body: encodePng(t, filledImage(4, 4))[:40], wantErr: model.ErrImageUnsupportedFormat},
+		{name: "too many bytes", body: append(jpegBody, make([]byte, model.IMAGE_MAX_BYTES)...), wantErr: model.ErrImageTooLarge},
+		{name: "too wide", body: encodePng(t, image.NewGray(image.Rect(0, 0, model.IMAGE_MAX_DIMENSION+1, 1))), wantErr: model.ErrImageDimensionsTooLarge},
+		{name: "too tall", body: encodePng(t, image.NewGray(image.Rect(0, 0, 1, model.IMAGE_MAX_DIMENSION+1))), wantErr: model.ErrImageDimensionsTooLarge},
+		{name: "largest accepted", body: encodePng(t, image.NewGray(image.Rect(0, 0, model.IMAGE_MAX_DIMENSION, 1))), wantContentType: "image/jpeg"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processedImage, err := ProcessImage(bytes.NewReader(tt.body), nil)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if processedImage.Original.ContentType != tt.wantContentType {
+				t.Errorf("got content type %s, want %s", processedImage.Original.ContentType, tt.wantContentType)
+			}
+		})
+	}
+}
+
+func TestProcessImageOrientation(t *testing.T) {
+	body := encodeJpeg(t, filledImage(40, 20))
+
+	tests := []struct {
+		name        string
+		body        []byte
+		wantWidth   int
+		wantHeight  int
+		wantTopLeft string
+	}{
+		{name: "without exif", body: body, wantWidth: 40, wantHeight: 20, wantTopLeft: "red"},
+		{name: "upright", body: withExif(body, exifSegment(binary.BigEndian, 1)), wantWidth: 40, wantHeight: 20, wantTopLeft: "red"},
+		{name: "mirrored", body: withExif(body, exifSegment(binary.LittleEndian, 2)), wantWidth: 40, wantHeight: 20, wantTopLeft: "blue"},
+		{name: "rotated 180", body: withExif(body, exifSegment(binary.BigEndian, 3)), wantWidth: 40, wantHeight: 20, wantTopLeft: "blue"},
+		{name: "rotated 90 clockwise", body: withExif(body, exifSegment(binary.LittleEndian, 6)), wantWidth: 20, wantHeight: 40, wantTopLeft: "red"},
+		{name: "rotated 90 counterclockwise", body: withExif(body, exifSegment(binary.BigEndian, 8)), wantWidth: 20, wantHeight: 40, wantTopLeft: "blue"},
+		{name: "invalid orientation", body: withExif(body, exifSegment(binary.BigEndian, 9)), wantWidth: 40, wantHeight: 20, wantTopLeft: "red"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processedImage, err := ProcessImage(bytes.NewReader(tt.body), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if bytes.Contains(processedImage.Original.Body, []byte("Exif")) {
+				t.Error("the EXIF data was kept")
+			}
+			img, err := jpeg.Decode(bytes.NewReader(processedImage.Original.Body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if img.Bounds().Dx() != tt.wantWidth || img.Bounds().Dy() != tt.wantHeight {
+				t.Errorf("got %dx%d, want %dx%d", img.Bounds().Dx(), img.Bounds().Dy(), tt.wantWidth, tt.wantHeight)
+			}
+			r, _, b, _ := img.At(1, 1).RGBA()
+			topLeft := "red"
+			if b > r {
+				topLeft = "blue"
+			}
+			if topLeft != tt.wantTopLeft {
+				t.Errorf("top left is %s, want %s", topLeft, tt.wantTopLeft)
+			}
+		})
+	}
+}
+
+func TestJpegOrientation(t *testing.T) {
+	body := encodeJpeg(t, filledImage(4, 4))
+	truncated := exifSegment(binary.BigEndian, 6)
+	binary.BigEndian.PutUint16(truncated[2:4], 0xFFFF)
+
+	tests := []struct {
+		name string
+		body []byte
+		want int
+	}{
+		{name: "no exif", body: body, want: 1},
+		{name: "big endian", body: withExif(body, exifSegment(binary.BigEndian, 6)), want: 6},
+		{name: "little endian", body: withExif(body, exifSegment(binary.LittleEndian, 8)), want: 8},
+		{name: "out of range", body: withExif(body, exifSegment(binary.LittleEndian, 0)), want: 1},
+		{name: "segment longer than the file", body: withExif(body, truncated), want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := jpegOrientation(tt.body); got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessImageRenditions(t *testing.T) {
+	tests := []struct {
+		name   string
+		width  int
+		height int
+		// want is the size of the thumbnail, medium and large rendition
+		want [3][2]int
+	}{
+		{name: "landscape", width: 1000, height: 500, want: [3][2]int{{200, 100}, {800, 400}, {1000, 500}}},
+		{name: "portrait", width: 300, height: 900, want: [3][2]int{{66, 200}, {266, 800}, {300, 900}}},
+		{name: "smaller than every rendition", width: 150, height: 100, want: [3][2]int{{150, 100}, {150, 100}, {150, 100}}},
+		{name: "thin", width: 1800, height: 1, want: [3][2]int{{200, 1}, {800, 1}, {1600, 1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := encodePng(t, filledImage(tt.width, tt.height))
+			processedImage, err := ProcessImage(bytes.NewReader(body), model.FOTO_PRODUK_RENDITIONS)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(processedImage.Renditions) != len(model.FOTO_PRODUK_RENDITIONS) {
+				t.Fatalf("got %d renditions", len(processedImage.Renditions))
+			}
+			for i, rendition := range model.FOTO_PRODUK_RENDITIONS {
+				encodedImage, ok := processedImage.Renditions[rendition.Name]
+				if !ok {
+					t.Fatalf("rendition %s is missing", rendition.Name)
+				}
+				config, err := jpeg.DecodeConfig(bytes.NewReader(encodedImage.Body))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := [2]int{config.Width, config.Height}; got != tt.want[i] {
+					t.Errorf("%s is %dx%d, want %dx%d", rendition.Name, got[0], got[1], tt.want[i][0], tt.want[i][1])
+				}
+			}
+		})
+	}
+}
@@ -34,3 +34,15 @@ func ResponseErrorJson(c *fiber.Ctx, code int, err error) error {
 	}
 	return c.Status(code).JSON(res)
 }
+
+// ResponsePartialJson returns what was done before the error with 207 Multi-Status
+func ResponsePartialJson(c *fiber.Ctx, data interface{}, err error) error {
+	message := "Partially succeed to " + string(c.Request().Header.Method()) + " data"
+	res := response{
+		Status:  false,
+		Message: message,
+		Errors:  []string{err.Error()},
+		Data:    data,
+	}
+	return c.Status(fiber.StatusMultiStatus).JSON(res)
+}
@@ -1,9 +1,13 @@
 package main
 
 import (
+	"context"
 	"log"
 	"marketplace-api/config"
+	"os"
+	"os/signal"
 	"sync"
+	"syscall"
 
 	"github.com/joho/godotenv"
 )
@@ -15,13 +19,31 @@ func main() {
 	}
 
 	config := config.NewConfig()
+
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			RunMigrate(config, os.Args[2:])
+			return
+		case "gc-uploads":
+			RunGcUploads(config, os.Args[2:])
+			return
+		default:
+			log.Fatalf("unknown command %q", os.Args[1])
+		}
+	}
+
+	// Ctrl + C or SIGTERM stops the server and the background workers, wg waits until they are done
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	server := InitServer(config)
 	wg := sync.WaitGroup{}
 
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		server.Run()
+		server.Run(ctx, &wg)
 	}()
 
 	wg.Wait()
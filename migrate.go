@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"marketplace-api/config"
+	"marketplace-api/migration"
+	"strconv"
+)
+
+// RunMigrate handles `migrate up`, `migrate down [steps]` and `migrate status`
+func RunMigrate(cfg config.Config, args []string) {
+	db := cfg.Database()
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	switch command {
+	case "up":
+		migrations, err := migration.Up(db)
+		for _, m := range migrations {
+			fmt.Println("applied", m)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(migrations) == 0 {
+			fmt.Println("no pending migration")
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			var err error
+			steps, err = strconv.Atoi(args[1])
+			if err != nil {
+				log.Fatal("steps must be integer")
+			}
+		}
+		migrations, err := migration.Down(db, steps)
+		for _, m := range migrations {
+			fmt.Println("reverted", m)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	case "status":
+		statuses, err := migration.Status(db)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%06d_%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+	default:
+		log.Fatalf("unknown migrate command %q, must be up, down, or status", command)
+	}
+}
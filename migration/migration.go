@@ -0,0 +1,257 @@
+package migration
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// migration files are named <version>_<name>.up.sql and <version>_<name>.down.sql
+//
+//go:embed sql/*.sql
+var sqlFiles embed.FS
+
+type (
+	SchemaMigration struct {
+		Version   int64     `gorm:"column:version;primaryKey;autoIncrement:false"`
+		Name      string    `gorm:"column:name;size:255;not null"`
+		AppliedAt time.Time `gorm:"column:applied_at"`
+	}
+
+	Migration struct {
+		Version int64
+		Name    string
+		Up      string
+		Down    string
+	}
+
+	MigrationStatus struct {
+		Version   int64
+		Name      string
+		AppliedAt *time.Time
+	}
+)
+
+// override gorm table name
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+func (m *Migration) String() string {
+	return fmt.Sprintf("%06d_%s", m.Version, m.Name)
+}
+
+// Load reads every embedded migration, sorted by version ascending
+func Load() ([]*Migration, error) {
+	return load(sqlFiles)
+}
+
+func load(fsys fs.FS) ([]*Migration, error) {
+	fileNames, err := fs.Glob(fsys, "sql/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	migrationByVersion := map[int64]*Migration{}
+	for _, fileName := range fileNames {
+		baseName := path.Base(fileName)
+		var direction string
+		switch {
+		case strings.HasSuffix(baseName, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(baseName, ".down.sql"):
+			direction = "down"
+		default:
+			return nil, fmt.Errorf("invalid migration file name: %s", baseName)
+		}
+		nameWithVersion := strings.TrimSuffix(baseName, "."+direction+".sql")
+		versionString, name, found := strings.Cut(nameWithVersion, "_")
+		if !found {
+			return nil, fmt.Errorf("invalid migration file name: %s", baseName)
+		}
+		version, err := strconv.ParseInt(versionString, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version: %s", baseName)
+		}
+
+		content, err := fs.ReadFile(fsys, fileName)
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := migrationByVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: name}
+			migrationByVersion[version] = migration
+		}
+		if migration.Name != name {
+			return nil, fmt.Errorf("migration version %d has different names: %s and %s", version, migration.Name, name)
+		}
+		if direction == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := []*Migration{}
+	for _, migration := range migrationByVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %s has no up file", migration)
+		}
+		migrations = append(migrations, migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Up applies every migration that has not been applied yet and returns the applied migrations
+func Up(db *gorm.DB) ([]*Migration, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedVersions(db)
+	if err != nil {
+		return nil, err
+	}
+
+	appliedNow := []*Migration{}
+	for _, migration := range migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		if err := execStatements(db, migration.Up); err != nil {
+			return appliedNow, fmt.Errorf("migration %s failed: %w", migration, err)
+		}
+		schemaMigration := &SchemaMigration{
+			Version:   migration.Version,
+			Name:      migration.Name,
+			AppliedAt: time.Now(),
+		}
+		if err := db.Create(schemaMigration).Error; err != nil {
+			return appliedNow, err
+		}
+		appliedNow = append(appliedNow, migration)
+	}
+	return appliedNow, nil
+}
+
+// Down reverts the last steps applied migrations, newest first
+func Down(db *gorm.DB, steps int) ([]*Migration, error) {
+	if steps < 1 {
+		return nil, errors.New("steps must be greater than zero")
+	}
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedVersions(db)
+	if err != nil {
+		return nil, err
+	}
+
+	revertedNow := []*Migration{}
+	for i := len(migrations) - 1; i >= 0 && len(revertedNow) < steps; i-- {
+		migration := migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		if migration.Down == "" {
+			return revertedNow, fmt.Errorf("migration %s has no down file", migration)
+		}
+		if err := execStatements(db, migration.Down); err != nil {
+			return revertedNow, fmt.Errorf("migration %s failed: %w", migration, err)
+		}
+		if err := db.Delete(&SchemaMigration{}, migration.Version).Error; err != nil {
+			return revertedNow, err
+		}
+		revertedNow = append(revertedNow, migration)
+	}
+	return revertedNow, nil
+}
+
+// Status lists every known migration along with the time it was applied, nil when still pending
+func Status(db *gorm.DB) ([]*MigrationStatus, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedVersions(db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := []*MigrationStatus{}
+	for _, migration := range migrations {
+		status := &MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if schemaMigration, ok := applied[migration.Version]; ok {
+			appliedAt := schemaMigration.AppliedAt
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+func appliedVersions(db *gorm.DB) (map[int64]*SchemaMigration, error) {
+	if err := db.Exec("CREATE TABLE IF NOT EXISTS `schema_migrations` (" +
+		"`version` BIGINT NOT NULL, " +
+		"`name` VARCHAR(255) NOT NULL, " +
+		"`applied_at` DATETIME(3) NULL, " +
+		"PRIMARY KEY (`version`))").Error; err != nil {
+		return nil, err
+	}
+
+	var schemaMigrations []*SchemaMigration
+	if err := db.Find(&schemaMigrations).Error; err != nil {
+		return nil, err
+	}
+	applied := map[int64]*SchemaMigration{}
+	for _, schemaMigration := range schemaMigrations {
+		applied[schemaMigration.Version] = schemaMigration
+	}
+	return applied, nil
+}
+
+func execStatements(db *gorm.DB, content string) error {
+	for _, statement := range splitStatements(content) {
+		if err := db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// the mysql driver does not allow multiple statements in one Exec call unless multiStatements=true,
+// so the content is split after every semicolon at the end of a line
+func splitStatements(content string) []string {
+	statements := []string{}
+	statement := strings.Builder{}
+	for _, line := range strings.Split(content, "\n") {
+		trimmedLine := strings.TrimSpace(line)
+		if trimmedLine == "" || strings.HasPrefix(trimmedLine, "--") {
+			continue
+		}
+		statement.WriteString(line)
+		statement.WriteString("\n")
+		if strings.HasSuffix(trimmedLine, ";") {
+			statements = append(statements, statement.String())
+			statement.Reset()
+		}
+	}
+	if strings.TrimSpace(statement.String()) != "" {
+		statements = append(statements, statement.String())
+	}
+	return statements
+}
@@ -0,0 +1,117 @@
+package migration
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadEmbeddedMigrations(t *testing.T) {
+	migrations, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("no migrations loaded")
+	}
+	for i, migration := range migrations {
+		if migration.Version != int64(i+1) {
+			t.Errorf("migration %s: want version %d", migration, i+1)
+		}
+		if strings.TrimSpace(migration.Up) == "" || strings.TrimSpace(migration.Down) == "" {
+			t.Errorf("migration %s: up and down must not be empty", migration)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"sql/000002_add_b.up.sql":      {Data: []byte("B up;")},
+		"sql/000001_create_a.up.sql":   {Data: []byte("A up;")},
+		"sql/000001_create_a.down.sql": {Data: []byte("A down;")},
+	}
+	migrations, err := load(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*Migration{
+		{Version: 1, Name: "create_a", Up: "A up;", Down: "A down;"},
+		{Version: 2, Name: "add_b", Up: "B up;"},
+	}
+	if !reflect.DeepEqual(migrations, want) {
+		t.Errorf("got %+v %+v, want %+v %+v", migrations[0], migrations[1], want[0], want[1])
+	}
+}
+
+func TestLoadInvalidFiles(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+	}{
+		{"no direction", []string{"sql/000001_create_a.sql"}},
+		{"no name", []string{"sql/000001.up.sql"}},
+		{"version is not a number", []string{"sql/first_create_a.up.sql"}},
+		{"names differ", []string{"sql/000001_create_a.up.sql", "sql/000001_create_b.down.sql"}},
+		{"no up file", []string{"sql/000001_create_a.down.sql"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := fstest.MapFS{}
+			for _, file := range tt.files {
+				fsys[file] = &fstest.MapFile{Data: []byte("SELECT 1;")}
+			}
+			if _, err := load(fsys); err == nil {
+				t.Error("want an error")
+			}
+		})
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name:    "empty",
+			content: "\n-- only a comment\n\n",
+			want:    []string{},
+		},
+		{
+			name:    "one statement per line",
+			content: "ALTER TABLE `a` ADD COLUMN `b` INT;\nALTER TABLE `a` ADD COLUMN `c` INT;\n",
+			want:    []string{"ALTER TABLE `a` ADD COLUMN `b` INT;\n", "ALTER TABLE `a` ADD COLUMN `c` INT;\n"},
+		},
+		{
+			name: "statement across lines with comments",
+			content: "-- the table\nCREATE TABLE `a` (\n\t`id` INT,\n\t-- a comment inside\n\t`b` INT\n);\n\n" +
+				"UPDATE `a` SET `b` = 1;",
+			want: []string{"CREATE TABLE `a` (\n\t`id` INT,\n\t`b` INT\n);\n", "UPDATE `a` SET `b` = 1;\n"},
+		},
+		{
+			name:    "semicolon inside a line does not split",
+			content: "UPDATE `a` SET `b` = ';' WHERE `id` = 1;\n",
+			want:    []string{"UPDATE `a` SET `b` = ';' WHERE `id` = 1;\n"},
+		},
+		{
+			name:    "last statement without semicolon",
+			content: "UPDATE `a` SET `b` = 1;\nUPDATE `a` SET `b` = 2\n",
+			want:    []string{"UPDATE `a` SET `b` = 1;\n", "UPDATE `a` SET `b` = 2\n"},
+		},
+		{
+			name:    "crlf line endings",
+			content: "UPDATE `a` SET `b` = 1;\r\nUPDATE `a` SET `b` = 2;\r\n",
+			want:    []string{"UPDATE `a` SET `b` = 1;\r\n", "UPDATE `a` SET `b` = 2;\r\n"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitStatements(tt.content)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+CREATE TABLE IF NOT EXISTS `user` (
+	`id` BIGINT NOT NULL AUTO_INCREMENT,
+	`nama` VARCHAR(255) NOT NULL,
+	`kata_sandi` VARCHAR(255) NOT NULL,
+	`no_telp` VARCHAR(255) NOT NULL,
+	`tanggal_lahir` DATE NOT NULL,
+	`jenis_kelamin` VARCHAR(255) NOT NULL,
+	`tentang` LONGTEXT NOT NULL,
+	`pekerjaan` VARCHAR(255) NOT NULL,
+	`email` VARCHAR(255) NOT NULL,
+	`id_provinsi` VARCHAR(255) NOT NULL,
+	`id_kota` VARCHAR(255) NOT NULL,
+	`is_admin` BOOLEAN NOT NULL DEFAULT 0,
+	`created_at` DATETIME(3) NULL,
+	`updated_at` DATETIME(3) NULL,
+	PRIMARY KEY (`id`),
+	UNIQUE KEY `idx_user_no_telp` (`no_telp`),
+	UNIQUE KEY `idx_user_email` (`email`)
+);
+
+CREATE TABLE IF NOT EXISTS `category` (
+	`id` BIGINT NOT NULL AUTO_INCREMENT,
+	`nama_category` VARCHAR(255) NOT NULL,
+	`created_at` DATETIME(3) NULL,
+	`updated_at` DATETIME(3) NULL,
+	PRIMARY KEY (`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `toko` (
+	`id` BIGINT NOT NULL AUTO_INCREMENT,
+	`id_user` BIGINT NULL,
+	`nama_toko` VARCHAR(255) NOT NULL,
+	`url_foto` VARCHAR(255) NOT NULL,
+	`created_at` DATETIME(3) NULL,
+	`updated_at` DATETIME(3) NULL,
+	PRIMARY KEY (`id`),
+	CONSTRAINT `fk_toko_user` FOREIGN KEY (`id_user`) REFERENCES `user` (`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `alamat` (
+	`id` BIGINT NOT NULL AUTO_INCREMENT,
+	`id_user` BIGINT NULL,
+	`judul_alamat` VARCHAR(255) NOT NULL,
+	`nama_penerima` VARCHAR(255) NOT NULL,
+	`no_telp` VARCHAR(255) NOT NULL,
+	`detail_alamat` VARCHAR(255) NOT NULL,
+	`created_at` DATETIME(3) NULL,
+	`updated_at` DATETIME(3) NULL,
+	PRIMARY KEY (`id`),
+	CONSTRAINT `fk_alamat_user` FOREIGN KEY (`id_user`) REFERENCES `user` (`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `produk` (
+	`id` BIGINT NOT NULL AUTO_INCREMENT,
+	`nama_produk` VARCHAR(255) NOT NULL,
+	`slug` VARCHAR(255) NOT NULL,
+	`harga_reseller` VARCHAR(255) NOT NULL,
+	`harga_konsumen` VARCHAR(255) NOT NULL,
+	`stok` BIGINT NOT NULL,
+	`deskripsi` LONGTEXT NOT NULL,
+	`id_toko` BIGINT NULL,
+	`id_category` BIGINT NULL,
+	`created_at` DATETIME(3) NULL,
+	`updated_at` DATETIME(3) NULL,
+	PRIMARY KEY (`id`),
+	CONSTRAINT `fk_produk_toko` FOREIGN KEY (`id_toko`) REFERENCES `toko` (`id`),
+	CONSTRAINT `fk_produk_category` FOREIGN KEY (`id_category`) REFERENCES `category` (`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `foto_produk` (
+	`id` BIGINT NOT NULL AUTO_INCREMENT,
+	`id_produk` BIGINT NULL,
+	`url` VARCHAR(255) NOT NULL,
+	`created_at` DATETIME(3) NULL,
+	`updated_at` DATETIME(3) NULL,
+	PRIMARY KEY (`id`),
+	CONSTRAINT `fk_foto_produk_produk` FOREIGN KEY (`id_produk`) REFERENCES `produk` (`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `log_produk` (
+	`id` BIGINT NOT NULL AUTO_INCREMENT,
+	`id_produk` BIGINT NOT NULL,
+	`nama_produk` VARCHAR(255) NOT NULL,
+	`slug` VARCHAR(255) NOT NULL,
+	`harga_reseller` VARCHAR(255) NOT NULL,
+	`harga_konsumen` VARCHAR(255) NOT NULL,
+	`deskripsi` LONGTEXT NOT NULL,
+	`created_at` DATETIME(3) NULL,
+	`updated_at` DATETIME(3) NULL,
+	`id_toko` BIGINT NOT NULL,
+	`id_category` BIGINT NOT NULL,
+	PRIMARY KEY (`id`),
+	CONSTRAINT `fk_log_produk_produk` FOREIGN KEY (`id_produk`) REFERENCES `produk` (`id`),
+	CONSTRAINT `fk_log_produk_toko` FOREIGN KEY (`id_toko`) REFERENCES `toko` (`id`),
+	CONSTRAINT `fk_log_produk_category` FOREIGN KEY (`id_category`) REFERENCES `category` (`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `trx` (
+	`id` BIGINT NOT NULL AUTO_INCREMENT,
+	`id_user` BIGINT NOT NULL,
+	`alamat_pengiriman` BIGINT NOT NULL,
+	`harga_total` BIGINT NOT NULL,
+	`kode_invoice` VARCHAR(255) NOT NULL,
+	`method_bayar` VARCHAR(255) NOT NULL,
+	`created_at` DATETIME(3) NULL,
+	`updated_at` DATETIME(3) NULL,
+	PRIMARY KEY (`id`),
+	CONSTRAINT `fk_trx_user` FOREIGN KEY (`id_user`) REFERENCES `user` (`id`),
+	CONSTRAINT `fk_trx_alamat` FOREIGN KEY (`alamat_pengiriman`) REFERENCES `alamat` (`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `detail_trx` (
+	`id` BIGINT NOT NULL AUTO_INCREMENT,
+	`id_trx` BIGINT NOT NULL,
+	`id_log_produk` BIGINT NOT NULL,
+	`id_toko` BIGINT NOT NULL,
+	`kuantitas` BIGINT NOT NULL,
+	`harga_total` BIGINT NOT NULL,
+	`created_at` DATETIME(3) NULL,
+	`updated_at` DATETIME(3) NULL,
+	PRIMARY KEY (`id`),
+	CONSTRAINT `fk_detail_trx_trx` FOREIGN KEY (`id_trx`) REFERENCES `trx` (`id`),
+	CONSTRAINT `fk_detail_trx_log_produk` FOREIGN KEY (`id_log_produk`) REFERENCES `log_produk` (`id`),
+	CONSTRAINT `fk_detail_trx_toko` FOREIGN KEY (`id_toko`) REFERENCES `toko` (`id`)
+);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS `trx_status_history`;
+DROP INDEX `idx_trx_status` ON `trx`;
+ALTER TABLE `trx` DROP COLUMN `status`;
@@ -0,0 +1,17 @@
+ALTER TABLE `trx` ADD COLUMN `status` VARCHAR(255) NOT NULL DEFAULT 'pending_payment' AFTER `method_bayar`;
+CREATE INDEX `idx_trx_status` ON `trx` (`status`);
+
+CREATE TABLE IF NOT EXISTS `trx_status_history` (
+	`id` BIGINT NOT NULL AUTO_INCREMENT,
+	`id_trx` BIGINT NOT NULL,
+	`status_sebelum` VARCHAR(255) NOT NULL,
+	`status_sesudah` VARCHAR(255) NOT NULL,
+	`id_user` BIGINT NULL,
+	`peran` VARCHAR(255) NOT NULL,
+	`catatan` VARCHAR(255) NOT NULL,
+	`created_at` DATETIME(3) NULL,
+	PRIMARY KEY (`id`),
+	KEY `idx_trx_status_history_id_trx` (`id_trx`),
+	CONSTRAINT `fk_trx_status_history_trx` FOREIGN KEY (`id_trx`) REFERENCES `trx` (`id`),
+	CONSTRAINT `fk_trx_status_history_user` FOREIGN KEY (`id_user`) REFERENCES `user` (`id`)
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS `cart_item`;
+DROP TABLE IF EXISTS `cart`;
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS `cart` (
+	`id` BIGINT NOT NULL AUTO_INCREMENT,
+	`id_user` BIGINT NOT NULL,
+	`created_at` DATETIME(3) NULL,
+	`updated_at` DATETIME(3) NULL,
+	PRIMARY KEY (`id`),
+	UNIQUE KEY `idx_cart_id_user` (`id_user`),
+	CONSTRAINT `fk_cart_user` FOREIGN KEY (`id_user`) REFERENCES `user` (`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `cart_item` (
+	`id` BIGINT NOT NULL AUTO_INCREMENT,
+	`id_cart` BIGINT NOT NULL,
+	`id_produk` BIGINT NOT NULL,
+	`kuantitas` BIGINT NOT NULL,
+	`harga` BIGINT NOT NULL,
+	`created_at` DATETIME(3) NULL,
+	`updated_at` DATETIME(3) NULL,
+	PRIMARY KEY (`id`),
+	UNIQUE KEY `idx_cart_item_id_cart_id_produk` (`id_cart`, `id_produk`),
+	CONSTRAINT `fk_cart_item_cart` FOREIGN KEY (`id_cart`) REFERENCES `cart` (`id`) ON DELETE CASCADE,
+	CONSTRAINT `fk_cart_item_produk` FOREIGN KEY (`id_produk`) REFERENCES `produk` (`id`) ON DELETE CASCADE
+);
@@ -0,0 +1,6 @@
+DELETE FROM `log_produk` WHERE `jenis` = 'pembatalan';
+ALTER TABLE `log_produk` DROP COLUMN `keterangan`;
+ALTER TABLE `log_produk` DROP COLUMN `perubahan_stok`;
+ALTER TABLE `log_produk` DROP COLUMN `jenis`;
+
+ALTER TABLE `trx` DROP COLUMN `alasan_batal`;
@@ -0,0 +1,11 @@
+ALTER TABLE `trx` ADD COLUMN `alasan_batal` VARCHAR(255) NOT NULL DEFAULT '' AFTER `status`;
+
+ALTER TABLE `log_produk` ADD COLUMN `jenis` VARCHAR(255) NOT NULL DEFAULT 'trx';
+ALTER TABLE `log_produk` ADD COLUMN `perubahan_stok` BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE `log_produk` ADD COLUMN `keterangan` VARCHAR(255) NOT NULL DEFAULT '';
+
+-- every existing log produk was written by a transaction, so its stock change is the ordered quantity
+UPDATE `log_produk`
+JOIN `detail_trx` ON `detail_trx`.`id_log_produk` = `log_produk`.`id`
+JOIN `trx` ON `trx`.`id` = `detail_trx`.`id_trx`
+SET `log_produk`.`perubahan_stok` = -`detail_trx`.`kuantitas`, `log_produk`.`keterangan` = `trx`.`kode_invoice`;
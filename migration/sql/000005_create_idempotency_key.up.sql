@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS `idempotency_key` (
+	`id` BIGINT NOT NULL AUTO_INCREMENT,
+	`id_user` BIGINT NOT NULL,
+	`idempotency_key` VARCHAR(255) NOT NULL,
+	`method` VARCHAR(255) NOT NULL,
+	`path` VARCHAR(255) NOT NULL,
+	`request_hash` VARCHAR(255) NOT NULL,
+	`status_code` BIGINT NOT NULL DEFAULT 0,
+	`response_body` LONGBLOB NULL,
+	`created_at` DATETIME(3) NULL,
+	`updated_at` DATETIME(3) NULL,
+	PRIMARY KEY (`id`),
+	UNIQUE KEY `idx_idempotency_key_id_user_idempotency_key` (`id_user`, `idempotency_key`),
+	CONSTRAINT `fk_idempotency_key_user` FOREIGN KEY (`id_user`) REFERENCES `user` (`id`)
+);
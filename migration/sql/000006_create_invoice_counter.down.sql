@@ -0,0 +1,2 @@
+ALTER TABLE `trx` DROP INDEX `idx_trx_kode_invoice`;
+DROP TABLE IF EXISTS `invoice_counter`;
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS `invoice_counter` (
+	`tanggal` VARCHAR(8) NOT NULL,
+	`nomor` BIGINT NOT NULL,
+	`updated_at` DATETIME(3) NULL,
+	PRIMARY KEY (`tanggal`)
+);
+-- codes generated randomly before the counter may collide, every duplicate but the oldest gets its id appended
+UPDATE `trx` t
+JOIN (
+	SELECT `kode_invoice`, MIN(`id`) AS `min_id`
+	FROM `trx`
+	GROUP BY `kode_invoice`
+	HAVING COUNT(*) > 1
+) d ON t.`kode_invoice` = d.`kode_invoice` AND t.`id` <> d.`min_id`
+SET t.`kode_invoice` = CONCAT(t.`kode_invoice`, '-', t.`id`);
+ALTER TABLE `trx` ADD UNIQUE INDEX `idx_trx_kode_invoice` (`kode_invoice`);
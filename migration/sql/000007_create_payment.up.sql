@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS `payment` (
+	`id` BIGINT NOT NULL AUTO_INCREMENT,
+	`id_trx` BIGINT NOT NULL,
+	`method_bayar` VARCHAR(255) NOT NULL,
+	`jumlah` BIGINT NOT NULL,
+	`status` VARCHAR(255) NOT NULL DEFAULT 'pending',
+	`referensi` VARCHAR(255) NOT NULL,
+	`instruksi` TEXT NULL,
+	`settled_at` DATETIME(3) NULL,
+	`created_at` DATETIME(3) NULL,
+	`updated_at` DATETIME(3) NULL,
+	PRIMARY KEY (`id`),
+	UNIQUE KEY `idx_payment_id_trx` (`id_trx`),
+	UNIQUE KEY `idx_payment_referensi` (`referensi`),
+	INDEX `idx_payment_status` (`status`),
+	CONSTRAINT `fk_payment_trx` FOREIGN KEY (`id_trx`) REFERENCES `trx` (`id`)
+);
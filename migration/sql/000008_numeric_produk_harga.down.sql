@@ -0,0 +1,6 @@
+ALTER TABLE `log_produk`
+	MODIFY `harga_reseller` VARCHAR(255) NOT NULL,
+	MODIFY `harga_konsumen` VARCHAR(255) NOT NULL;
+ALTER TABLE `produk`
+	MODIFY `harga_reseller` VARCHAR(255) NOT NULL,
+	MODIFY `harga_konsumen` VARCHAR(255) NOT NULL;
@@ -0,0 +1,15 @@
+-- prices were only ever saved after strconv.Atoi accepted them, so they are integers already. The cast
+-- keeps this working on MySQL 5.7, and in strict mode a value that is not an integer fails the migration
+-- instead of being guessed
+UPDATE `produk` SET
+	`harga_reseller` = CAST(`harga_reseller` AS UNSIGNED),
+	`harga_konsumen` = CAST(`harga_konsumen` AS UNSIGNED);
+UPDATE `log_produk` SET
+	`harga_reseller` = CAST(`harga_reseller` AS UNSIGNED),
+	`harga_konsumen` = CAST(`harga_konsumen` AS UNSIGNED);
+ALTER TABLE `produk`
+	MODIFY `harga_reseller` BIGINT NOT NULL,
+	MODIFY `harga_konsumen` BIGINT NOT NULL;
+ALTER TABLE `log_produk`
+	MODIFY `harga_reseller` BIGINT NOT NULL,
+	MODIFY `harga_konsumen` BIGINT NOT NULL;
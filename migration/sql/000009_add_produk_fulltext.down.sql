@@ -0,0 +1,4 @@
+ALTER TABLE `toko` DROP INDEX `ft_toko_nama_toko`;
+ALTER TABLE `category` DROP INDEX `ft_category_nama_category`;
+ALTER TABLE `produk` DROP INDEX `ft_produk_deskripsi`;
+ALTER TABLE `produk` DROP INDEX `ft_produk_nama_produk`;
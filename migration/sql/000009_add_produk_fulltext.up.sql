@@ -0,0 +1,5 @@
+-- the ngram parser indexes bigrams, so a misspelled query still shares most of its bigrams with the product
+ALTER TABLE `produk` ADD FULLTEXT INDEX `ft_produk_nama_produk` (`nama_produk`) WITH PARSER ngram;
+ALTER TABLE `produk` ADD FULLTEXT INDEX `ft_produk_deskripsi` (`deskripsi`) WITH PARSER ngram;
+ALTER TABLE `category` ADD FULLTEXT INDEX `ft_category_nama_category` (`nama_category`) WITH PARSER ngram;
+ALTER TABLE `toko` ADD FULLTEXT INDEX `ft_toko_nama_toko` (`nama_toko`) WITH PARSER ngram;
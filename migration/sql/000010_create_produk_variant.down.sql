@@ -0,0 +1,11 @@
+DELETE FROM `cart_item` WHERE `id_produk_variant` IS NOT NULL;
+ALTER TABLE `cart_item` ADD UNIQUE KEY `idx_cart_item_id_cart_id_produk` (`id_cart`, `id_produk`);
+ALTER TABLE `cart_item` DROP INDEX `idx_cart_item_id_cart_id_produk_variant`;
+ALTER TABLE `cart_item` DROP FOREIGN KEY `fk_cart_item_produk_variant`;
+ALTER TABLE `cart_item` DROP COLUMN `id_produk_variant`;
+
+ALTER TABLE `log_produk` DROP COLUMN `atribut_variant`;
+ALTER TABLE `log_produk` DROP COLUMN `sku`;
+ALTER TABLE `log_produk` DROP COLUMN `id_produk_variant`;
+
+DROP TABLE IF EXISTS `produk_variant`;
@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS `produk_variant` (
+	`id` BIGINT NOT NULL AUTO_INCREMENT,
+	`id_produk` BIGINT NOT NULL,
+	`sku` VARCHAR(255) NOT NULL,
+	`atribut` JSON NOT NULL,
+	`harga_reseller` BIGINT NOT NULL,
+	`harga_konsumen` BIGINT NOT NULL,
+	`stok` BIGINT NOT NULL,
+	`created_at` DATETIME(3) NULL,
+	`updated_at` DATETIME(3) NULL,
+	PRIMARY KEY (`id`),
+	UNIQUE KEY `idx_produk_variant_sku` (`sku`),
+	KEY `idx_produk_variant_id_produk` (`id_produk`),
+	CONSTRAINT `fk_produk_variant_produk` FOREIGN KEY (`id_produk`) REFERENCES `produk` (`id`)
+);
+
+-- the variant is a snapshot in log produk, so it has no foreign key and survives deleting the variant
+ALTER TABLE `log_produk` ADD COLUMN `id_produk_variant` BIGINT NULL;
+ALTER TABLE `log_produk` ADD COLUMN `sku` VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE `log_produk` ADD COLUMN `atribut_variant` JSON NULL;
+
+ALTER TABLE `cart_item` ADD COLUMN `id_produk_variant` BIGINT NULL AFTER `id_produk`;
+ALTER TABLE `cart_item` ADD CONSTRAINT `fk_cart_item_produk_variant` FOREIGN KEY (`id_produk_variant`) REFERENCES `produk_variant` (`id`) ON DELETE CASCADE;
+-- the new key is added first because fk_cart_item_cart needs an index starting with id_cart
+ALTER TABLE `cart_item` ADD UNIQUE KEY `idx_cart_item_id_cart_id_produk_variant` (`id_cart`, `id_produk`, `id_produk_variant`);
+ALTER TABLE `cart_item` DROP INDEX `idx_cart_item_id_cart_id_produk`;
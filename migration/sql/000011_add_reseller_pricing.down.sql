@@ -0,0 +1,3 @@
+ALTER TABLE `produk` DROP COLUMN `min_kuantitas_reseller`;
+
+ALTER TABLE `user` DROP COLUMN `is_reseller`;
@@ -0,0 +1,3 @@
+ALTER TABLE `user` ADD COLUMN `is_reseller` BOOLEAN NOT NULL DEFAULT 0 AFTER `is_admin`;
+
+ALTER TABLE `produk` ADD COLUMN `min_kuantitas_reseller` INT NOT NULL DEFAULT 0 AFTER `stok`;
@@ -0,0 +1,53 @@
+CREATE TABLE IF NOT EXISTS `voucher` (
+	`id` BIGINT NOT NULL AUTO_INCREMENT,
+	`kode` VARCHAR(255) NOT NULL,
+	`id_toko` BIGINT NULL,
+	`jenis_diskon` VARCHAR(255) NOT NULL,
+	`nilai_diskon` BIGINT NOT NULL,
+	`maks_diskon` BIGINT NOT NULL DEFAULT 0,
+	`min_belanja` BIGINT NOT NULL DEFAULT 0,
+	`kuota_total` BIGINT NOT NULL DEFAULT 0,
+	`kuota_per_user` BIGINT NOT NULL DEFAULT 0,
+	`terpakai` BIGINT NOT NULL DEFAULT 0,
+	`berlaku_mulai` DATETIME(3) NOT NULL,
+	`berlaku_sampai` DATETIME(3) NOT NULL,
+	`created_at` DATETIME(3) NULL,
+	`updated_at` DATETIME(3) NULL,
+	PRIMARY KEY (`id`),
+	UNIQUE KEY `idx_voucher_kode` (`kode`),
+	KEY `idx_voucher_id_toko` (`id_toko`),
+	CONSTRAINT `fk_voucher_toko` FOREIGN KEY (`id_toko`) REFERENCES `toko` (`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `voucher_category` (
+	`id` BIGINT NOT NULL AUTO_INCREMENT,
+	`id_voucher` BIGINT NOT NULL,
+	`id_category` BIGINT NOT NULL,
+	PRIMARY KEY (`id`),
+	UNIQUE KEY `idx_voucher_category_id_voucher_id_category` (`id_voucher`, `id_category`),
+	CONSTRAINT `fk_voucher_category_voucher` FOREIGN KEY (`id_voucher`) REFERENCES `voucher` (`id`),
+	CONSTRAINT `fk_voucher_category_category` FOREIGN KEY (`id_category`) REFERENCES `category` (`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `voucher_pemakaian` (
+	`id` BIGINT NOT NULL AUTO_INCREMENT,
+	`id_voucher` BIGINT NOT NULL,
+	`id_user` BIGINT NOT NULL,
+	`id_trx` BIGINT NOT NULL,
+	`diskon` BIGINT NOT NULL,
+	`created_at` DATETIME(3) NULL,
+	PRIMARY KEY (`id`),
+	UNIQUE KEY `idx_voucher_pemakaian_id_trx` (`id_trx`),
+	KEY `idx_voucher_pemakaian_id_voucher_id_user` (`id_voucher`, `id_user`),
+	CONSTRAINT `fk_voucher_pemakaian_voucher` FOREIGN KEY (`id_voucher`) REFERENCES `voucher` (`id`),
+	CONSTRAINT `fk_voucher_pemakaian_user` FOREIGN KEY (`id_user`) REFERENCES `user` (`id`),
+	CONSTRAINT `fk_voucher_pemakaian_trx` FOREIGN KEY (`id_trx`) REFERENCES `trx` (`id`)
+);
+
+-- kode voucher is a snapshot, the trx keeps it after the voucher is deleted
+ALTER TABLE `trx` ADD COLUMN `id_voucher` BIGINT NULL AFTER `harga_total`;
+ALTER TABLE `trx` ADD COLUMN `kode_voucher` VARCHAR(255) NOT NULL DEFAULT '' AFTER `id_voucher`;
+ALTER TABLE `trx` ADD COLUMN `diskon` BIGINT NOT NULL DEFAULT 0 AFTER `kode_voucher`;
+ALTER TABLE `trx` ADD CONSTRAINT `fk_trx_voucher` FOREIGN KEY (`id_voucher`) REFERENCES `voucher` (`id`) ON DELETE SET NULL;
+
+ALTER TABLE `detail_trx` ADD COLUMN `diskon` BIGINT NOT NULL DEFAULT 0 AFTER `harga_total`;
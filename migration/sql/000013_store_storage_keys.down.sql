@@ -0,0 +1,3 @@
+UPDATE `toko` SET `url_foto` = CONCAT('/uploads/', `url_foto`) WHERE `url_foto` <> '';
+
+UPDATE `foto_produk` SET `url` = CONCAT('/uploads/', `url`) WHERE `url` <> '';
@@ -0,0 +1,3 @@
+UPDATE `foto_produk` SET `url` = SUBSTRING_INDEX(`url`, '/uploads/', -1) WHERE `url` LIKE '%/uploads/%';
+
+UPDATE `toko` SET `url_foto` = SUBSTRING_INDEX(`url_foto`, '/uploads/', -1) WHERE `url_foto` LIKE '%/uploads/%';
@@ -0,0 +1,3 @@
+ALTER TABLE `foto_produk` DROP COLUMN `is_primary`;
+
+ALTER TABLE `foto_produk` DROP COLUMN `position`;
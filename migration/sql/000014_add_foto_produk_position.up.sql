@@ -0,0 +1,13 @@
+ALTER TABLE `foto_produk` ADD COLUMN `position` INT NOT NULL DEFAULT 0 AFTER `url`;
+
+ALTER TABLE `foto_produk` ADD COLUMN `is_primary` BOOLEAN NOT NULL DEFAULT 0 AFTER `position`;
+
+-- existing photos keep their upload order, the first photo of each product becomes its primary photo
+UPDATE `foto_produk` AS `f`
+JOIN (
+	SELECT `a`.`id`, COUNT(`b`.`id`) AS `position`
+	FROM `foto_produk` AS `a`
+	LEFT JOIN `foto_produk` AS `b` ON `b`.`id_produk` = `a`.`id_produk` AND `b`.`id` < `a`.`id`
+	GROUP BY `a`.`id`
+) AS `ranked` ON `ranked`.`id` = `f`.`id`
+SET `f`.`position` = `ranked`.`position`, `f`.`is_primary` = `ranked`.`position` = 0;
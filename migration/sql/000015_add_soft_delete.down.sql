@@ -0,0 +1,8 @@
+ALTER TABLE `alamat` DROP INDEX `idx_alamat_deleted_at`;
+ALTER TABLE `alamat` DROP COLUMN `deleted_at`;
+
+ALTER TABLE `category` DROP INDEX `idx_category_deleted_at`;
+ALTER TABLE `category` DROP COLUMN `deleted_at`;
+
+ALTER TABLE `produk` DROP INDEX `idx_produk_deleted_at`;
+ALTER TABLE `produk` DROP COLUMN `deleted_at`;
@@ -0,0 +1,9 @@
+-- deleted rows stay so that orders keep resolving the product, category and address they refer to
+ALTER TABLE `produk` ADD COLUMN `deleted_at` DATETIME(3) NULL AFTER `updated_at`;
+ALTER TABLE `produk` ADD INDEX `idx_produk_deleted_at` (`deleted_at`);
+
+ALTER TABLE `category` ADD COLUMN `deleted_at` DATETIME(3) NULL AFTER `updated_at`;
+ALTER TABLE `category` ADD INDEX `idx_category_deleted_at` (`deleted_at`);
+
+ALTER TABLE `alamat` ADD COLUMN `deleted_at` DATETIME(3) NULL AFTER `updated_at`;
+ALTER TABLE `alamat` ADD INDEX `idx_alamat_deleted_at` (`deleted_at`);
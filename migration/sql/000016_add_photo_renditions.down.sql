@@ -0,0 +1,3 @@
+ALTER TABLE `toko` DROP COLUMN `foto_has_renditions`;
+
+ALTER TABLE `foto_produk` DROP COLUMN `has_renditions`;
@@ -0,0 +1,4 @@
+-- photos uploaded before the renditions were generated keep 0 and are served from the original file
+ALTER TABLE `foto_produk` ADD COLUMN `has_renditions` BOOLEAN NOT NULL DEFAULT 0 AFTER `is_primary`;
+
+ALTER TABLE `toko` ADD COLUMN `foto_has_renditions` BOOLEAN NOT NULL DEFAULT 0 AFTER `url_foto`;
@@ -0,0 +1,3 @@
+ALTER TABLE `cart_item` ADD UNIQUE KEY `idx_cart_item_id_cart_id_produk_variant` (`id_cart`, `id_produk`, `id_produk_variant`);
+ALTER TABLE `cart_item` DROP INDEX `idx_cart_item_id_cart_id_produk_variant_key`;
+ALTER TABLE `cart_item` DROP COLUMN `id_produk_variant_key`;
@@ -0,0 +1,20 @@
+-- NULLs are never equal in a unique key, so idx_cart_item_id_cart_id_produk_variant let a product
+-- without variants be added twice to a cart. The key now uses 0 for a missing variant
+ALTER TABLE `cart_item` ADD COLUMN `id_produk_variant_key` BIGINT AS (COALESCE(`id_produk_variant`, 0)) STORED NOT NULL AFTER `id_produk_variant`;
+-- the lines added twice are merged into the oldest one
+UPDATE `cart_item` JOIN (
+	SELECT MIN(`id`) AS `id`, SUM(`kuantitas`) AS `kuantitas`
+	FROM `cart_item`
+	GROUP BY `id_cart`, `id_produk`, `id_produk_variant_key`
+	HAVING COUNT(*) > 1
+) AS `merged` ON `merged`.`id` = `cart_item`.`id`
+SET `cart_item`.`kuantitas` = `merged`.`kuantitas`;
+DELETE `duplicate` FROM `cart_item` AS `duplicate`
+JOIN `cart_item` AS `oldest`
+	ON `oldest`.`id_cart` = `duplicate`.`id_cart`
+	AND `oldest`.`id_produk` = `duplicate`.`id_produk`
+	AND `oldest`.`id_produk_variant_key` = `duplicate`.`id_produk_variant_key`
+	AND `oldest`.`id` < `duplicate`.`id`;
+-- the new key is added first because fk_cart_item_cart needs an index starting with id_cart
+ALTER TABLE `cart_item` ADD UNIQUE KEY `idx_cart_item_id_cart_id_produk_variant_key` (`id_cart`, `id_produk`, `id_produk_variant_key`);
+ALTER TABLE `cart_item` DROP INDEX `idx_cart_item_id_cart_id_produk_variant`;
@@ -7,6 +7,7 @@ import (
 
 	validation "github.com/go-ozzo/ozzo-validation"
 	"github.com/go-ozzo/ozzo-validation/is"
+	"gorm.io/gorm"
 )
 
 type (
@@ -20,22 +21,29 @@ type (
 		DetailAlamat string    `gorm:"column:detail_alamat;size:255;not null"`
 		CreatedAt    time.Time `gorm:"column:created_at"`
 		UpdatedAt    time.Time `gorm:"column:updated_at"`
+		// DeletedAt hides the alamat from its user, orders keep resolving it
+		DeletedAt gorm.DeletedAt `gorm:"column:deleted_at;index"`
 	}
 
 	AlamatRepository interface {
 		Create(ctx context.Context, alamat *Alamat) (*Alamat, error)
-		FetchAndFilter(ctx context.Context, userId int, judulAlamat string) ([]*Alamat, error)
+		FetchAndFilter(ctx context.Context, req *AlamatFetchRequest, userId int) ([]*Alamat, int64, error)
 		FindByID(ctx context.Context, alamatId int) (*Alamat, error)
+		FindByIDs(ctx context.Context, alamatIds []int) ([]*Alamat, error)
+		// FindByIDsWithDeleted also finds deleted alamat, for records that outlive them such as orders
+		FindByIDsWithDeleted(ctx context.Context, alamatIds []int) ([]*Alamat, error)
 		UpdateByID(ctx context.Context, alamatId int, alamat *Alamat) (*Alamat, error)
 		Delete(ctx context.Context, alamatId int) error
+		Restore(ctx context.Context, alamatId int) (*Alamat, error)
 	}
 
 	AlamatUsecase interface {
 		StoreAlamat(ctx context.Context, req *AlamatRequest) (*AlamatResponse, error)
-		FetchAndFilterAlamat(ctx context.Context, userId int, judulAlamat string) ([]*AlamatResponse, error)
+		FetchAndFilterAlamat(ctx context.Context, req *AlamatFetchRequest, userId int) (*AlamatFetchResponse, error)
 		GetAlamatByID(ctx context.Context, alamatId int, userId int) (*AlamatResponse, error)
 		EditAlamatByID(ctx context.Context, alamatId int, req *AlamatRequest) (*AlamatResponse, error)
 		DestroyAlamat(ctx context.Context, alamatId int, userId int) error
+		RestoreAlamat(ctx context.Context, alamatId int) (*AlamatResponse, error)
 	}
 
 	AlamatRequest struct {
@@ -46,6 +54,11 @@ type (
 		DetailAlamat string `json:"detail_alamat"`
 	}
 
+	AlamatFetchRequest struct {
+		PaginationRequest
+		JudulAlamat string
+	}
+
 	AlamatResponse struct {
 		ID           int    `json:"id"`
 		JudulAlamat  string `json:"judul_alamat"`
@@ -53,6 +66,11 @@ type (
 		NoTelp       string `json:"no_telp"`
 		DetailAlamat string `json:"detail_alamat"`
 	}
+
+	AlamatFetchResponse struct {
+		Pagination
+		Data []*AlamatResponse `json:"data"`
+	}
 )
 
 // override gorm table name
@@ -0,0 +1,111 @@
+package model
+
+import (
+	"context"
+	"time"
+)
+
+type (
+	Cart struct {
+		ID        int       `gorm:"column:id"`
+		IdUser    int       `gorm:"column:id_user;not null;unique"`
+		User      *User     `gorm:"foreignKey:IdUser"`
+		CreatedAt time.Time `gorm:"column:created_at"`
+		UpdatedAt time.Time `gorm:"column:updated_at"`
+	}
+
+	CartItem struct {
+		ID       int     `gorm:"column:id"`
+		IdCart   int     `gorm:"column:id_cart;not null"`
+		Cart     *Cart   `gorm:"foreignKey:IdCart"`
+		IdProduk int     `gorm:"column:id_produk;not null"`
+		Produk   *Produk `gorm:"foreignKey:IdProduk"`
+		// IdProdukVariant is nil when the product has no variants
+		IdProdukVariant *int `gorm:"column:id_produk_variant"`
+		Kuantitas       int  `gorm:"column:kuantitas;not null"`
+		// Harga is the product price when the item was last added or re-validated
+		Harga     Money     `gorm:"column:harga;not null"`
+		CreatedAt time.Time `gorm:"column:created_at"`
+		UpdatedAt time.Time `gorm:"column:updated_at"`
+	}
+
+	CartRepository interface {
+		FindOrCreateByUserID(ctx context.Context, userId int) (*Cart, error)
+		FetchItems(ctx context.Context, cartId int) ([]*CartItem, error)
+		FindItemByID(ctx context.Context, cartItemId int) (*CartItem, error)
+		CreateItem(ctx context.Context, cartItem *CartItem) (*CartItem, error)
+		UpdateItemByID(ctx context.Context, cartItemId int, cartItem *CartItem) (*CartItem, error)
+		DeleteItems(ctx context.Context, cartItemIds []int) error
+		DeleteAllItems(ctx context.Context, cartId int) error
+	}
+
+	CartUsecase interface {
+		GetCart(ctx context.Context, userId int) (*CartResponse, error)
+		StoreCartItem(ctx context.Context, req *CartItemRequest, userId int) (*CartResponse, error)
+		EditCartItem(ctx context.Context, cartItemId int, req *CartItemUpdateRequest, userId int) (*CartResponse, error)
+		DestroyCartItem(ctx context.Context, cartItemId int, userId int) (*CartResponse, error)
+		ClearCart(ctx context.Context, userId int) error
+		// Checkout returns the transactions it created along with the error when it stops halfway
+		Checkout(ctx context.Context, req *CartCheckoutRequest, userId int) ([]*TrxGetByIDResponse, error)
+	}
+
+	CartItemRequest struct {
+		ProductId int `json:"product_id"`
+		// VariantId is required when the product has variants
+		VariantId int `json:"variant_id"`
+		Kuantitas int `json:"kuantitas"`
+	}
+
+	CartItemUpdateRequest struct {
+		Kuantitas int `json:"kuantitas"`
+	}
+
+	CartCheckoutRequest struct {
+		MethodBayar      string `json:"method_bayar"`
+		AlamatPengiriman int    `json:"alamat_kirim"`
+	}
+
+	CartItemResponse struct {
+		ID                   int               `json:"id"`
+		IdProduk             int               `json:"product_id"`
+		NamaProduk           string            `json:"nama_produk"`
+		Slug                 string            `json:"slug"`
+		IdProdukVariant      *int              `json:"variant_id"`
+		Sku                  string            `json:"sku"`
+		AtributVariant       map[string]string `json:"atribut_variant"`
+		Kuantitas            int               `json:"kuantitas"`
+		Harga                Money             `json:"harga"`
+		HargaSaatDitambahkan Money             `json:"harga_saat_ditambahkan"`
+		HargaBerubah         bool              `json:"harga_berubah"`
+		Stok                 int               `json:"stok"`
+		StokCukup            bool              `json:"stok_cukup"`
+		HargaTotal           Money             `json:"harga_total"`
+		// Photo is the first photo of the product, empty when the product has no photo
+		Photo string `json:"photo"`
+	}
+
+	CartTokoResponse struct {
+		Toko          *TokoGetByIDResponse `json:"toko"`
+		Items         []*CartItemResponse  `json:"items"`
+		SubtotalHarga Money                `json:"subtotal_harga"`
+	}
+
+	CartResponse struct {
+		ID             int                 `json:"id"`
+		Toko           []*CartTokoResponse `json:"toko"`
+		TotalKuantitas int                 `json:"total_kuantitas"`
+		TotalHarga     Money               `json:"total_harga"`
+		// CanCheckout is false when the cart is empty or an item has a changed price or insufficient stock
+		CanCheckout bool `json:"can_checkout"`
+	}
+)
+
+// override gorm table name
+func (Cart) TableName() string {
+	return "cart"
+}
+
+// override gorm table name
+func (CartItem) TableName() string {
+	return "cart_item"
+}
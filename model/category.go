@@ -2,23 +2,35 @@ package model
 
 import (
 	"context"
+	"errors"
 	"time"
+
+	"gorm.io/gorm"
 )
 
+var ErrCategoryInUse = errors.New("category is still used by products")
+
 type (
 	Category struct {
 		ID           int       `gorm:"column:id"`
 		NamaCategory string    `gorm:"column:nama_category;size:255;not null"`
 		CreatedAt    time.Time `gorm:"column:created_at"`
 		UpdatedAt    time.Time `gorm:"column:updated_at"`
+		// DeletedAt hides the category, orders keep resolving it
+		DeletedAt gorm.DeletedAt `gorm:"column:deleted_at;index"`
 	}
 
 	CategoryRepository interface {
 		Create(ctx context.Context, category *Category) (*Category, error)
 		FetchAll(ctx context.Context) ([]*Category, error)
 		FindByID(ctx context.Context, id int) (*Category, error)
+		FindByIDs(ctx context.Context, ids []int) ([]*Category, error)
+		// FindByIDsWithDeleted also finds deleted categories, for records that outlive them such as orders
+		FindByIDsWithDeleted(ctx context.Context, ids []int) ([]*Category, error)
 		UpdateByID(ctx context.Context, id int, category *Category) (*Category, error)
+		// Delete refuses with ErrCategoryInUse while products that are not archived use the category
 		Delete(ctx context.Context, id int) error
+		Restore(ctx context.Context, id int) (*Category, error)
 	}
 
 	CategoryUsecase interface {
@@ -27,6 +39,7 @@ type (
 		GetCategoryByID(ctx context.Context, id int) (*CategoryResponse, error)
 		EditCategory(ctx context.Context, id int, req *CategoryRequest) (*CategoryResponse, error)
 		DestroyCategory(ctx context.Context, id int) error
+		RestoreCategory(ctx context.Context, id int) (*CategoryResponse, error)
 	}
 
 	CategoryRequest struct {
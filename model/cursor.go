@@ -0,0 +1,44 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+const CURSOR_SORT_ID = "id"
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+type (
+	// Cursor points right after the last row of a page, it is sent to clients as an opaque string.
+	// Key is the sort value of that row and ID breaks ties between rows with the same sort value.
+	Cursor struct {
+		Sort string `json:"s"`
+		Key  string `json:"k,omitempty"`
+		ID   int    `json:"i"`
+	}
+
+	// PageResult is returned by repositories that support cursors along with the fetched rows
+	PageResult struct {
+		Total      int64
+		NextCursor string
+	}
+)
+
+func (c Cursor) Encode() string {
+	cursorJson, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(cursorJson)
+}
+
+func DecodeCursor(cursorString string) (*Cursor, error) {
+	cursorJson, err := base64.RawURLEncoding.DecodeString(cursorString)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	cursor := new(Cursor)
+	if err := json.Unmarshal(cursorJson, cursor); err != nil || cursor.Sort == "" {
+		return nil, ErrInvalidCursor
+	}
+	return cursor, nil
+}
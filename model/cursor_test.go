@@ -0,0 +1,80 @@
+package model
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func TestCursorEncodeDecode(t *testing.T) {
+	for _, cursor := range []Cursor{
+		{Sort: CURSOR_SORT_ID, ID: 42},
+		{Sort: PRODUK_SORT_HARGA_ASC, Key: "15000", ID: 7},
+		{Sort: PRODUK_SORT_TERBARU, Key: "2023-02-01T10:00:00.5Z", ID: 1},
+	} {
+		decoded, err := DecodeCursor(cursor.Encode())
+		if err != nil {
+			t.Fatalf("%+v: %v", cursor, err)
+		}
+		if *decoded != cursor {
+			t.Errorf("got %+v, want %+v", *decoded, cursor)
+		}
+	}
+}
+
+func TestDecodeCursorRejectsTamperedCursors(t *testing.T) {
+	encode := func(s string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(s))
+	}
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"not base64", "!!!"},
+		{"padded base64", base64.URLEncoding.EncodeToString([]byte(`{"s":"id","i":1}`))},
+		{"not json", encode("id:1")},
+		{"no sort", encode(`{"i":1}`)},
+		{"id is not a number", encode(`{"s":"id","i":"1 OR 1=1"}`)},
+	}
+	for _, tt := range tests {
+		if _, err := DecodeCursor(tt.cursor); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%s: got error %v, want %v", tt.name, err, ErrInvalidCursor)
+		}
+	}
+}
+
+func TestParsePaginationRequestWithCursor(t *testing.T) {
+	cursor := Cursor{Sort: CURSOR_SORT_ID, ID: 10}.Encode()
+	tests := []struct {
+		name    string
+		limit   string
+		page    string
+		cursor  string
+		wantErr bool
+	}{
+		{"no cursor", "10", "2", "", false},
+		{"cursor", "10", "", cursor, false},
+		{"cursor without limit", "", "", cursor, true},
+		{"cursor with page", "10", "2", cursor, true},
+		{"invalid cursor", "10", "", "abc", true},
+	}
+	for _, tt := range tests {
+		req, err := ParsePaginationRequestWithCursor(tt.limit, tt.page, tt.cursor)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: got error %v, want error %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if tt.cursor == "" {
+			if req.Cursor != nil || req.Page != 2 || req.Offset() != 10 {
+				t.Errorf("%s: got %+v, want page 2 without cursor", tt.name, req)
+			}
+			continue
+		}
+		if req.Cursor == nil || req.Cursor.ID != 10 || req.Page != 0 || req.Offset() != 0 || req.FetchLimit() != 11 {
+			t.Errorf("%s: got %+v, want the cursor, no offset and one extra row", tt.name, req)
+		}
+	}
+}
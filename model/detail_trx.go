@@ -5,6 +5,8 @@ import (
 	"time"
 )
 
+const TOKO_ORDER_DATE_FORMAT = "02/01/2006"
+
 type (
 	// Trx means Transaction
 	DetailTrx struct {
@@ -16,13 +18,18 @@ type (
 		IdToko      int        `gorm:"column:id_toko;not null"`
 		Toko        *Toko      `gorm:"foreignKey:IdToko"`
 		Kuantitas   int        `gorm:"column:kuantitas;not null"`
-		HargaTotal  int        `gorm:"column:harga_total;not null"`
-		CreatedAt   time.Time  `gorm:"column:created_at"`
-		UpdatedAt   time.Time  `gorm:"column:updated_at"`
+		// HargaTotal is the price of the line after its share of the voucher discount, Diskon
+		HargaTotal Money     `gorm:"column:harga_total;not null"`
+		Diskon     Money     `gorm:"column:diskon;not null;default:0"`
+		CreatedAt  time.Time `gorm:"column:created_at"`
+		UpdatedAt  time.Time `gorm:"column:updated_at"`
 	}
 
 	DetailTrxRepository interface {
 		FindByTrxID(ctx context.Context, trxId int) ([]*DetailTrx, error)
+		FetchByTrxIDs(ctx context.Context, trxIds []int) ([]*DetailTrx, error)
+		FetchByTokoID(ctx context.Context, tokoId int, req *TokoOrderFetchRequest) ([]*DetailTrx, error)
+		SummarizeByTokoID(ctx context.Context, tokoId int, req *TokoOrderFetchRequest) (*TokoOrderSummary, error)
 	}
 
 	DetailTrxWithLogProduk struct {
@@ -32,14 +39,54 @@ type (
 
 	DetailTrxRequest struct {
 		ProductId int `json:"product_id"`
+		// VariantId is required when the product has variants
+		VariantId int `json:"variant_id"`
 		Kuantitas int `json:"kuantitas"`
 	}
 
+	// TokoOrderFetchRequest filters the detail trx of a toko, zero StartDate/EndDate and -1 ProductId mean no filter
+	TokoOrderFetchRequest struct {
+		StartDate   time.Time
+		EndDate     time.Time
+		Status      TrxStatus
+		ProductId   int
+		KodeInvoice string
+		PaginationRequest
+	}
+
+	TokoOrderSummary struct {
+		Total          int64
+		TotalKuantitas int
+		TotalHarga     Money
+	}
+
 	DetailTrxResponse struct {
 		LogProduk  *LogProdukResponse   `json:"product"`
 		Toko       *TokoGetByIDResponse `json:"toko"`
 		Kuantitas  int                  `json:"kuantitas"`
-		HargaTotal int                  `json:"harga_total"`
+		HargaTotal Money                `json:"harga_total"`
+		Diskon     Money                `json:"diskon"`
+	}
+
+	TokoOrderResponse struct {
+		ID               int                `json:"id"`
+		IdTrx            int                `json:"trx_id"`
+		KodeInvoice      string             `json:"kode_invoice"`
+		MethodBayar      string             `json:"method_bayar"`
+		Status           TrxStatus          `json:"status"`
+		AlamatPengiriman *AlamatResponse    `json:"alamat_kirim"`
+		LogProduk        *LogProdukResponse `json:"product"`
+		Kuantitas        int                `json:"kuantitas"`
+		HargaTotal       Money              `json:"harga_total"`
+		Diskon           Money              `json:"diskon"`
+		CreatedAt        time.Time          `json:"created_at"`
+	}
+
+	TokoOrderFetchResponse struct {
+		Pagination
+		TotalKuantitas int                  `json:"total_kuantitas"`
+		TotalHarga     Money                `json:"total_harga"`
+		Data           []*TokoOrderResponse `json:"data"`
 	}
 )
 
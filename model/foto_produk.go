@@ -2,27 +2,68 @@ package model
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
+var (
+	ErrFotoProdukNotFound     = errors.New("foto produk not found")
+	ErrFotoProdukMismatch     = errors.New("photo does not belong to the product")
+	ErrFotoProdukRequired     = errors.New("a product must keep at least one photo")
+	ErrInvalidFotoProdukOrder = errors.New("photo_ids must list every photo of the product once")
+)
+
 type (
+	// FotoProduk is shown in ascending Position, the IsPrimary photo represents the product in listings and carts
 	FotoProduk struct {
-		ID        int       `gorm:"column:id"`
-		IdProduk  int       `gorm:"column:id_produk"`
-		Produk    *Produk   `gorm:"foreignKey:IdProduk"`
-		Url       string    `gorm:"column:url;size:255;not null"`
-		CreatedAt time.Time `gorm:"column:created_at"`
-		UpdatedAt time.Time `gorm:"column:updated_at"`
+		ID        int     `gorm:"column:id"`
+		IdProduk  int     `gorm:"column:id_produk"`
+		Produk    *Produk `gorm:"foreignKey:IdProduk"`
+		Url       string  `gorm:"column:url;size:255;not null"`
+		Position  int     `gorm:"column:position;not null;default:0"`
+		IsPrimary bool    `gorm:"column:is_primary;not null;default:0"`
+		// HasRenditions is false for photos uploaded before the renditions were generated
+		HasRenditions bool      `gorm:"column:has_renditions;not null;default:0"`
+		CreatedAt     time.Time `gorm:"column:created_at"`
+		UpdatedAt     time.Time `gorm:"column:updated_at"`
 	}
 
 	FotoProdukRepository interface {
+		// Create appends the photos after the existing ones, the first photo of a product becomes its primary photo
+		Create(ctx context.Context, produkId int, photoKeys []string) ([]*FotoProduk, error)
+		FindByID(ctx context.Context, fotoProdukId int) (*FotoProduk, error)
 		FetchByProdukId(ctx context.Context, produkId int) ([]*FotoProduk, error)
+		FetchByProdukIds(ctx context.Context, produkIds []int) ([]*FotoProduk, error)
+		// FetchUrls returns the object key of every product photo
+		FetchUrls(ctx context.Context) ([]string, error)
+		// Reorder expects every photo id of the product once, in the new order
+		Reorder(ctx context.Context, produkId int, fotoProdukIds []int) error
+		SetPrimary(ctx context.Context, produkId int, fotoProdukId int) error
+		// Delete refuses to delete the last photo of a product with ErrFotoProdukRequired,
+		// the primary photo moves to the next photo when the primary photo is deleted
+		Delete(ctx context.Context, fotoProdukId int) error
+	}
+
+	FotoProdukUsecase interface {
+		StoreFotoProduk(ctx context.Context, produkId int, userId int, photoKeys []string) ([]*FotoProdukResponse, error)
+		ReorderFotoProduk(ctx context.Context, produkId int, userId int, req *FotoProdukOrderRequest) ([]*FotoProdukResponse, error)
+		SetPrimaryFotoProduk(ctx context.Context, produkId int, fotoProdukId int, userId int) ([]*FotoProdukResponse, error)
+		DestroyFotoProduk(ctx context.Context, produkId int, fotoProdukId int, userId int) error
+	}
+
+	FotoProdukOrderRequest struct {
+		FotoProdukIds []int `json:"photo_ids"`
 	}
 
 	FotoProdukResponse struct {
-		ID       int    `json:"id"`
-		IdProduk int    `json:"product_id"`
-		Url      string `json:"url"`
+		ID           int    `json:"id"`
+		IdProduk     int    `json:"product_id"`
+		Url          string `json:"url"`
+		UrlThumbnail string `json:"url_thumbnail"`
+		UrlMedium    string `json:"url_medium"`
+		UrlLarge     string `json:"url_large"`
+		Position     int    `json:"position"`
+		IsPrimary    bool   `json:"is_primary"`
 	}
 )
 
@@ -30,3 +71,16 @@ type (
 func (FotoProduk) TableName() string {
 	return "foto_produk"
 }
+
+// PrimaryFotoProduk is the primary photo of a product, the first one when none is marked, nil without photos
+func PrimaryFotoProduk(fotoProdukList []*FotoProduk) *FotoProduk {
+	for _, fotoProduk := range fotoProdukList {
+		if fotoProduk.IsPrimary {
+			return fotoProduk
+		}
+	}
+	if len(fotoProdukList) > 0 {
+		return fotoProdukList[0]
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const IDEMPOTENCY_KEY_HEADER = "Idempotency-Key"
+
+const (
+	// IDEMPOTENCY_KEY_LOCK_TIMEOUT frees a key whose first request never completed, e.g. because the process crashed
+	IDEMPOTENCY_KEY_LOCK_TIMEOUT = 5 * time.Minute
+	// IDEMPOTENCY_KEY_TTL is how long a completed request is replayed, the key can be used again afterwards
+	IDEMPOTENCY_KEY_TTL              = 24 * time.Hour
+	IDEMPOTENCY_KEY_CLEANUP_INTERVAL = time.Hour
+)
+
+var (
+	ErrIdempotencyKeyMismatch   = errors.New("idempotency key has already been used for a different request")
+	ErrIdempotencyKeyInProgress = errors.New("request with the same idempotency key is still being processed")
+)
+
+type (
+	IdempotencyKey struct {
+		ID          int    `gorm:"column:id"`
+		IdUser      int    `gorm:"column:id_user;not null"`
+		User        *User  `gorm:"foreignKey:IdUser"`
+		Key         string `gorm:"column:idempotency_key;size:255;not null"`
+		Method      string `gorm:"column:method;size:255;not null"`
+		Path        string `gorm:"column:path;size:255;not null"`
+		RequestHash string `gorm:"column:request_hash;size:255;not null"`
+		// StatusCode stays zero while the first request is still being processed
+		StatusCode   int       `gorm:"column:status_code;not null;default:0"`
+		ResponseBody []byte    `gorm:"column:response_body"`
+		CreatedAt    time.Time `gorm:"column:created_at"`
+		UpdatedAt    time.Time `gorm:"column:updated_at"`
+	}
+
+	IdempotencyKeyRepository interface {
+		Create(ctx context.Context, idempotencyKey *IdempotencyKey) (*IdempotencyKey, error)
+		FindByUserIDAndKey(ctx context.Context, userId int, key string) (*IdempotencyKey, error)
+		// Reclaim reserves an expired key for a new request,
+		// it returns ErrIdempotencyKeyInProgress when another request has reclaimed the key first
+		Reclaim(ctx context.Context, idempotencyKeyId int, idempotencyKey *IdempotencyKey, now time.Time) (*IdempotencyKey, error)
+		UpdateResponseByID(ctx context.Context, idempotencyKeyId int, statusCode int, responseBody []byte) error
+		Delete(ctx context.Context, idempotencyKeyId int) error
+		// DeleteExpired removes every key that IsExpired at now and returns how many were removed
+		DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+	}
+
+	IdempotencyKeyUsecase interface {
+		// BeginRequest returns replay true along with the stored response when the request has been completed before
+		BeginRequest(ctx context.Context, req *IdempotencyKeyRequest) (*IdempotencyKey, bool, error)
+		CompleteRequest(ctx context.Context, idempotencyKeyId int, statusCode int, responseBody []byte) error
+		ReleaseRequest(ctx context.Context, idempotencyKeyId int) error
+		DeleteExpiredKeys(ctx context.Context) (int64, error)
+	}
+
+	IdempotencyKeyRequest struct {
+		IdUser      int
+		Key         string
+		Method      string
+		Path        string
+		RequestHash string
+	}
+)
+
+// override gorm table name
+func (IdempotencyKey) TableName() string {
+	return "idempotency_key"
+}
+
+// IsExpired tells whether the key is free for a new request, either because its request stopped
+// before completing or because the stored response is not replayed anymore
+func (i *IdempotencyKey) IsExpired(now time.Time) bool {
+	if i.StatusCode == 0 {
+		return i.UpdatedAt.Before(now.Add(-IDEMPOTENCY_KEY_LOCK_TIMEOUT))
+	}
+	return i.UpdatedAt.Before(now.Add(-IDEMPOTENCY_KEY_TTL))
+}
@@ -0,0 +1,89 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+const (
+	// IMAGE_MAX_BYTES is the largest photo accepted for upload
+	IMAGE_MAX_BYTES = 8 << 20
+	// IMAGE_MAX_DIMENSION is checked before decoding, so a small file cannot expand into a huge bitmap
+	IMAGE_MAX_DIMENSION = 6000
+	// UPLOAD_BODY_LIMIT leaves room for several photos in one multipart request
+	UPLOAD_BODY_LIMIT = 64 << 20
+
+	IMAGE_JPEG_QUALITY = 85
+)
+
+const (
+	IMAGE_RENDITION_THUMBNAIL = "thumbnail"
+	IMAGE_RENDITION_MEDIUM    = "medium"
+	IMAGE_RENDITION_LARGE     = "large"
+)
+
+var (
+	ErrImageUnsupportedFormat  = errors.New("photo must be a jpeg, png or webp image")
+	ErrImageTooLarge           = fmt.Errorf("photo must not exceed %d MB", IMAGE_MAX_BYTES>>20)
+	ErrImageDimensionsTooLarge = fmt.Errorf("photo must not exceed %dx%d pixels", IMAGE_MAX_DIMENSION, IMAGE_MAX_DIMENSION)
+)
+
+type (
+	// ImageRendition is a resized copy of a photo that fits in a MaxDimension square
+	ImageRendition struct {
+		Name         string
+		MaxDimension int
+	}
+
+	EncodedImage struct {
+		Body        []byte
+		ContentType string
+		// Extension has no leading dot
+		Extension string
+	}
+
+	// ProcessedImage is a checked photo re-encoded without its metadata
+	ProcessedImage struct {
+		Original   *EncodedImage
+		Renditions map[string]*EncodedImage
+	}
+)
+
+// FOTO_PRODUK_RENDITIONS are generated for every uploaded product photo
+var FOTO_PRODUK_RENDITIONS = []ImageRendition{
+	{Name: IMAGE_RENDITION_THUMBNAIL, MaxDimension: 200},
+	{Name: IMAGE_RENDITION_MEDIUM, MaxDimension: 800},
+	{Name: IMAGE_RENDITION_LARGE, MaxDimension: 1600},
+}
+
+// TOKO_FOTO_RENDITIONS are generated for every uploaded toko photo
+var TOKO_FOTO_RENDITIONS = []ImageRendition{
+	{Name: IMAGE_RENDITION_THUMBNAIL, MaxDimension: 200},
+	{Name: IMAGE_RENDITION_MEDIUM, MaxDimension: 800},
+}
+
+// RenditionKey is the object key of a rendition next to the photo, e.g. "produk/<uuid>_thumbnail.jpg"
+func RenditionKey(key string, rendition string) string {
+	extension := path.Ext(key)
+	return strings.TrimSuffix(key, extension) + "_" + rendition + extension
+}
+
+// FotoProdukObjectKeys are the keys of a product photo and of all its renditions
+func FotoProdukObjectKeys(key string) []string {
+	return photoObjectKeys(key, FOTO_PRODUK_RENDITIONS)
+}
+
+// TokoFotoObjectKeys are the keys of a toko photo and of all its renditions
+func TokoFotoObjectKeys(key string) []string {
+	return photoObjectKeys(key, TOKO_FOTO_RENDITIONS)
+}
+
+func photoObjectKeys(key string, renditions []ImageRendition) []string {
+	keys := []string{key}
+	for _, rendition := range renditions {
+		keys = append(keys, RenditionKey(key, rendition.Name))
+	}
+	return keys
+}
@@ -0,0 +1,37 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	KODE_INVOICE_DATE_FORMAT = "20060102"
+	KODE_INVOICE_MAX_RETRY   = 3
+)
+
+var ErrDuplicateKodeInvoice = errors.New("kode invoice already exists")
+
+type (
+	// InvoiceCounter keeps the last invoice number given out on a date
+	InvoiceCounter struct {
+		Tanggal   string    `gorm:"column:tanggal;size:8;primaryKey"`
+		Nomor     int       `gorm:"column:nomor;not null"`
+		UpdatedAt time.Time `gorm:"column:updated_at"`
+	}
+
+	InvoiceCounterRepository interface {
+		Next(ctx context.Context, tanggal string) (int, error)
+	}
+
+	KodeInvoiceGenerator interface {
+		// Generate returns codes like INV-20261018-000123, numbered sequentially per day
+		Generate(ctx context.Context) (string, error)
+	}
+)
+
+// override gorm table name
+func (InvoiceCounter) TableName() string {
+	return "invoice_counter"
+}
@@ -5,6 +5,11 @@ import (
 	"time"
 )
 
+const (
+	LOG_PRODUK_JENIS_TRX        = "trx"
+	LOG_PRODUK_JENIS_PEMBATALAN = "pembatalan"
+)
+
 type (
 	// Trx means Transaction
 	LogProduk struct {
@@ -13,8 +18,8 @@ type (
 		Produk        *Produk   `gorm:"foreignKey:IdProduk"`
 		NamaProduk    string    `gorm:"column:nama_produk;size:255;not null"`
 		Slug          string    `gorm:"column:slug;size:255;not null"`
-		HargaReseller string    `gorm:"column:harga_reseller;size:255;not null"`
-		HargaKonsumen string    `gorm:"column:harga_konsumen;size:255;not null"`
+		HargaReseller Money     `gorm:"column:harga_reseller;not null"`
+		HargaKonsumen Money     `gorm:"column:harga_konsumen;not null"`
 		Deskripsi     string    `gorm:"column:deskripsi;not null"`
 		CreatedAt     time.Time `gorm:"column:created_at"`
 		UpdatedAt     time.Time `gorm:"column:updated_at"`
@@ -22,33 +27,46 @@ type (
 		Toko          *Toko     `gorm:"foreignKey:IdToko"`
 		IdCategory    int       `gorm:"column:id_category;not null"`
 		Category      *Category `gorm:"foreignKey:IdCategory"`
+		// Jenis tells why the log is written, PerubahanStok is the stock change caused by it
+		Jenis         string `gorm:"column:jenis;size:255;not null;default:trx"`
+		PerubahanStok int    `gorm:"column:perubahan_stok;not null;default:0"`
+		Keterangan    string `gorm:"column:keterangan;size:255;not null;default:''"`
+		// the variant snapshot is empty when the product was bought without a variant
+		IdProdukVariant *int              `gorm:"column:id_produk_variant"`
+		Sku             string            `gorm:"column:sku;size:255;not null;default:''"`
+		AtributVariant  map[string]string `gorm:"column:atribut_variant;serializer:json"`
 	}
 
 	LogProdukRepository interface {
 		FindByID(ctx context.Context, logProdukId int) (*LogProduk, error)
+		FindByIDs(ctx context.Context, logProdukIds []int) ([]*LogProduk, error)
 	}
 
 	LogProdukRequest struct {
 		IdProduk      int
 		NamaProduk    string
 		Slug          string
-		HargaReseller string
-		HargaKonsumen string
+		HargaReseller Money
+		HargaKonsumen Money
 		Deskripsi     string
 		IdToko        int
 		IdCategory    int
 	}
 
 	LogProdukResponse struct {
-		IdProduk      int                    `json:"id"`
-		NamaProduk    string                 `json:"nama_produk"`
-		Slug          string                 `json:"slug"`
-		HargaReseller string                 `json:"harga_reseller"`
-		HargaKonsumen string                 `json:"harga_konsumen"`
-		Deskripsi     string                 `json:"deskripsi"`
-		Toko          *TokoLogProdukResponse `json:"toko"`
-		Category      *CategoryResponse      `json:"category"`
-		Photos        []*FotoProdukResponse  `json:"photos"`
+		IdProduk      int    `json:"id"`
+		NamaProduk    string `json:"nama_produk"`
+		Slug          string `json:"slug"`
+		HargaReseller Money  `json:"harga_reseller"`
+		HargaKonsumen Money  `json:"harga_konsumen"`
+		Deskripsi     string `json:"deskripsi"`
+		// IdProdukVariant, Sku and AtributVariant describe the bought variant, they are empty without a variant
+		IdProdukVariant *int                   `json:"variant_id"`
+		Sku             string                 `json:"sku"`
+		AtributVariant  map[string]string      `json:"atribut_variant"`
+		Toko            *TokoLogProdukResponse `json:"toko"`
+		Category        *CategoryResponse      `json:"category"`
+		Photos          []*FotoProdukResponse  `json:"photos"`
 	}
 )
 
@@ -0,0 +1,25 @@
+package model
+
+import (
+	"errors"
+	"strconv"
+)
+
+var ErrInvalidMoney = errors.New("money must be a whole number of rupiah")
+
+// Money is an amount in whole rupiah, stored as BIGINT and rendered as a JSON number
+type Money int64
+
+// ParseMoney parses a plain base 10 rupiah amount, e.g. "15000"
+func ParseMoney(s string) (Money, error) {
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidMoney
+	}
+	return Money(v), nil
+}
+
+// Times returns the amount for the given quantity
+func (m Money) Times(kuantitas int) Money {
+	return m * Money(kuantitas)
+}
@@ -0,0 +1,36 @@
+package model
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    Money
+		wantErr error
+	}{
+		{"15000", 15000, nil},
+		{"0", 0, nil},
+		{"9223372036854775807", 9223372036854775807, nil},
+		{"", 0, ErrInvalidMoney},
+		{"15.000", 0, ErrInvalidMoney},
+		{"15000.00", 0, ErrInvalidMoney},
+		{"Rp 15000", 0, ErrInvalidMoney},
+		{"1e5", 0, ErrInvalidMoney},
+		{"9223372036854775808", 0, ErrInvalidMoney},
+	}
+	for _, tt := range tests {
+		got, err := ParseMoney(tt.s)
+		if !errors.Is(err, tt.wantErr) || got != tt.want {
+			t.Errorf("ParseMoney(%q): got %d, %v, want %d, %v", tt.s, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestMoneyTimes(t *testing.T) {
+	if got := Money(15000).Times(3); got != 45000 {
+		t.Errorf("got %d, want 45000", got)
+	}
+}
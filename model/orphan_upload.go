@@ -0,0 +1,37 @@
+package model
+
+import (
+	"context"
+	"time"
+)
+
+// ORPHAN_UPLOAD_FOLDERS are the storage folders checked for photos no row refers to anymore
+var ORPHAN_UPLOAD_FOLDERS = []string{STORAGE_FOLDER_PRODUK, STORAGE_FOLDER_TOKO}
+
+type (
+	OrphanUploadUsecase interface {
+		// CollectOrphanUploads finds stored photos that no foto_produk or toko row refers to and that are older than
+		// the grace period, so uploads whose row is still being written are left alone. Orphans are only deleted
+		// when req.Delete is set, an orphan that cannot be deleted is reported in Failures and the rest are still deleted
+		CollectOrphanUploads(ctx context.Context, req *OrphanUploadRequest) (*OrphanUploadReport, error)
+	}
+
+	OrphanUploadRequest struct {
+		GracePeriod time.Duration
+		Delete      bool
+	}
+
+	OrphanUploadReport struct {
+		// Scanned is the number of objects in ORPHAN_UPLOAD_FOLDERS
+		Scanned int
+		Orphans []*StorageObject
+		Deleted int
+		// Failures are the orphans whose delete failed
+		Failures []*OrphanUploadFailure
+	}
+
+	OrphanUploadFailure struct {
+		Key string
+		Err error
+	}
+)
@@ -0,0 +1,133 @@
+package model
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+type (
+	// PaginationRequest holds the limit and page query params, a Limit of -1 means no limit.
+	// When Cursor is set the rows after the cursor are fetched and Page is not used.
+	PaginationRequest struct {
+		Limit  int
+		Page   int
+		Cursor *Cursor
+	}
+
+	// Pagination is embedded in every paginated response
+	Pagination struct {
+		Limit      int   `json:"limit"`
+		Page       int   `json:"page"`
+		Total      int64 `json:"total"`
+		TotalPages int   `json:"total_pages"`
+		HasNext    bool  `json:"has_next"`
+		// NextCursor is only filled by endpoints that accept a cursor, empty on the last page
+		NextCursor string `json:"next_cursor,omitempty"`
+	}
+)
+
+// ParsePaginationRequest parses the limit and page query params, page can only be used along with limit
+func ParsePaginationRequest(limitString string, pageString string) (PaginationRequest, error) {
+	limitString = strings.TrimSpace(limitString)
+	pageString = strings.TrimSpace(pageString)
+	req := PaginationRequest{Limit: -1, Page: 1}
+
+	var err error
+	if limitString != "" {
+		req.Limit, err = strconv.Atoi(limitString)
+		if err != nil {
+			return req, errors.New("limit must be integer")
+		}
+		if req.Limit < 1 {
+			return req, errors.New("limit must be greater than zero")
+		}
+	}
+	if pageString != "" {
+		if limitString == "" {
+			return req, errors.New("must use limit query param when using page query param")
+		}
+		req.Page, err = strconv.Atoi(pageString)
+		if err != nil {
+			return req, errors.New("page must be integer")
+		}
+		if req.Page < 1 {
+			return req, errors.New("page must be greater than zero")
+		}
+	}
+	return req, nil
+}
+
+// ParsePaginationRequestWithCursor also parses the cursor query param, which needs limit and cannot be used with page
+func ParsePaginationRequestWithCursor(limitString string, pageString string, cursorString string) (PaginationRequest, error) {
+	req, err := ParsePaginationRequest(limitString, pageString)
+	if err != nil {
+		return req, err
+	}
+
+	cursorString = strings.TrimSpace(cursorString)
+	if cursorString == "" {
+		return req, nil
+	}
+	if req.Limit < 1 {
+		return req, errors.New("must use limit query param when using cursor query param")
+	}
+	if strings.TrimSpace(pageString) != "" {
+		return req, errors.New("cannot use page query param along with cursor query param")
+	}
+	req.Cursor, err = DecodeCursor(cursorString)
+	if err != nil {
+		return req, err
+	}
+	req.Page = 0
+	return req, nil
+}
+
+func (req PaginationRequest) Offset() int {
+	if req.Limit < 1 || req.Cursor != nil {
+		return 0
+	}
+	return (req.Page - 1) * req.Limit
+}
+
+func NewPagination(req PaginationRequest, total int64) Pagination {
+	pagination := Pagination{Limit: req.Limit, Page: req.Page, Total: total}
+	switch {
+	case total == 0:
+		pagination.TotalPages = 0
+	case req.Limit < 1:
+		pagination.TotalPages = 1
+	default:
+		pagination.TotalPages = int((total + int64(req.Limit) - 1) / int64(req.Limit))
+	}
+	pagination.HasNext = req.Page < pagination.TotalPages
+	return pagination
+}
+
+func NewCursorPagination(req PaginationRequest, pageResult *PageResult) Pagination {
+	pagination := NewPagination(req, pageResult.Total)
+	pagination.NextCursor = pageResult.NextCursor
+	if req.Cursor != nil {
+		pagination.HasNext = pageResult.NextCursor != ""
+	}
+	return pagination
+}
+
+// HasMoreRows tells whether rows follow the fetched ones, in cursor mode one extra row is fetched to find out
+func (req PaginationRequest) HasMoreRows(fetched int, total int64) bool {
+	if req.Limit < 1 {
+		return false
+	}
+	if req.Cursor != nil {
+		return fetched > req.Limit
+	}
+	return int64(req.Offset()+fetched) < total
+}
+
+// FetchLimit is the number of rows to fetch, one more than the limit in cursor mode
+func (req PaginationRequest) FetchLimit() int {
+	if req.Cursor != nil {
+		return req.Limit + 1
+	}
+	return req.Limit
+}
@@ -0,0 +1,110 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const PAYMENT_SIGNATURE_HEADER = "X-Payment-Signature"
+
+const (
+	PAYMENT_METHOD_BANK_TRANSFER PaymentMethod = "bank_transfer"
+	PAYMENT_METHOD_COD           PaymentMethod = "cod"
+	PAYMENT_METHOD_E_WALLET      PaymentMethod = "e_wallet"
+)
+
+const (
+	PAYMENT_STATUS_PENDING PaymentStatus = "pending"
+	PAYMENT_STATUS_SETTLED PaymentStatus = "settled"
+	PAYMENT_STATUS_FAILED  PaymentStatus = "failed"
+	PAYMENT_STATUS_EXPIRED PaymentStatus = "expired"
+)
+
+var (
+	ErrInvalidPaymentMethod    = errors.New("invalid method bayar")
+	ErrInvalidPaymentSignature = errors.New("invalid payment signature")
+)
+
+type (
+	PaymentMethod string
+
+	PaymentStatus string
+
+	Payment struct {
+		ID          int           `gorm:"column:id"`
+		IdTrx       int           `gorm:"column:id_trx;not null;unique"`
+		Trx         *Trx          `gorm:"foreignKey:IdTrx"`
+		MethodBayar PaymentMethod `gorm:"column:method_bayar;size:255;not null"`
+		Jumlah      Money         `gorm:"column:jumlah;not null"`
+		Status      PaymentStatus `gorm:"column:status;size:255;not null;default:pending"`
+		// Referensi is the id of the payment on the provider side
+		Referensi string     `gorm:"column:referensi;size:255;not null;unique"`
+		Instruksi string     `gorm:"column:instruksi;type:text"`
+		SettledAt *time.Time `gorm:"column:settled_at"`
+		CreatedAt time.Time  `gorm:"column:created_at"`
+		UpdatedAt time.Time  `gorm:"column:updated_at"`
+	}
+
+	// PaymentCharge is what a provider returns when a payment is created
+	PaymentCharge struct {
+		Referensi string
+		Instruksi string
+	}
+
+	// PaymentNotification is a verified webhook call from a provider
+	PaymentNotification struct {
+		Referensi string        `json:"referensi"`
+		Status    PaymentStatus `json:"status"`
+		Jumlah    Money         `json:"jumlah"`
+	}
+
+	// PaymentProvider is implemented once per payment gateway
+	PaymentProvider interface {
+		Method() PaymentMethod
+		// PayOnDelivery is true when the buyer pays the courier, the order can be processed before it is settled
+		PayOnDelivery() bool
+		CreateCharge(ctx context.Context, trx *Trx) (*PaymentCharge, error)
+		// ParseNotification verifies the signature of a webhook call and parses its body
+		ParseNotification(signature string, body []byte) (*PaymentNotification, error)
+	}
+
+	PaymentProviderRegistry interface {
+		Get(method PaymentMethod) (PaymentProvider, error)
+		Methods() []PaymentMethod
+	}
+
+	PaymentRepository interface {
+		Create(ctx context.Context, payment *Payment) (*Payment, error)
+		// FindByTrxID returns nil without error for trx created before payments were recorded
+		FindByTrxID(ctx context.Context, trxId int) (*Payment, error)
+		FetchByTrxIDs(ctx context.Context, trxIds []int) ([]*Payment, error)
+		FindByReferensi(ctx context.Context, referensi string) (*Payment, error)
+		// Settle marks the payment settled and moves the trx to paid when it is still pending payment
+		Settle(ctx context.Context, paymentId int, history *TrxStatusHistory) error
+		UpdateStatus(ctx context.Context, paymentId int, currentStatus PaymentStatus, status PaymentStatus) error
+	}
+
+	PaymentUsecase interface {
+		FetchMethods(ctx context.Context) []*PaymentMethodResponse
+		HandleNotification(ctx context.Context, method PaymentMethod, signature string, body []byte) error
+	}
+
+	PaymentMethodResponse struct {
+		Method        PaymentMethod `json:"method"`
+		PayOnDelivery bool          `json:"pay_on_delivery"`
+	}
+
+	PaymentResponse struct {
+		MethodBayar PaymentMethod `json:"method_bayar"`
+		Jumlah      Money         `json:"jumlah"`
+		Status      PaymentStatus `json:"status"`
+		Instruksi   string        `json:"instruksi"`
+		SettledAt   *time.Time    `json:"settled_at"`
+	}
+)
+
+// override gorm table name
+func (Payment) TableName() string {
+	return "payment"
+}
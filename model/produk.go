@@ -3,83 +3,135 @@ package model
 import (
 	"context"
 	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	PRODUK_SORT_DEFAULT    = CURSOR_SORT_ID
+	PRODUK_SORT_HARGA_ASC  = "harga_asc"
+	PRODUK_SORT_HARGA_DESC = "harga_desc"
+	PRODUK_SORT_TERBARU    = "terbaru"
+	PRODUK_SORT_TERLARIS   = "terlaris"
+	PRODUK_SORT_STOK       = "stok"
 )
 
+// PRODUK_SORTS is the whitelist of the sort query param of the product listing
+var PRODUK_SORTS = []string{
+	PRODUK_SORT_DEFAULT,
+	PRODUK_SORT_HARGA_ASC,
+	PRODUK_SORT_HARGA_DESC,
+	PRODUK_SORT_TERBARU,
+	PRODUK_SORT_TERLARIS,
+	PRODUK_SORT_STOK,
+}
+
 type (
 	Produk struct {
-		ID            int       `gorm:"column:id"`
-		NamaProduk    string    `gorm:"column:nama_produk;size:255;not null"`
-		Slug          string    `gorm:"column:slug;size:255;not null"`
-		HargaReseller string    `gorm:"column:harga_reseller;size:255;not null"`
-		HargaKonsumen string    `gorm:"column:harga_konsumen;size:255;not null"`
-		Stok          int       `gorm:"column:stok;not null"`
-		Deskripsi     string    `gorm:"column:deskripsi;not null"`
-		IdToko        int       `gorm:"column:id_toko"`
-		Toko          *Toko     `gorm:"foreignKey:IdToko"`
-		IdCategory    int       `gorm:"column:id_category"`
-		Category      *Category `gorm:"foreignKey:IdCategory"`
-		CreatedAt     time.Time `gorm:"column:created_at"`
-		UpdatedAt     time.Time `gorm:"column:updated_at"`
+		ID            int    `gorm:"column:id"`
+		NamaProduk    string `gorm:"column:nama_produk;size:255;not null"`
+		Slug          string `gorm:"column:slug;size:255;not null"`
+		HargaReseller Money  `gorm:"column:harga_reseller;not null"`
+		HargaKonsumen Money  `gorm:"column:harga_konsumen;not null"`
+		Stok          int    `gorm:"column:stok;not null"`
+		// MinKuantitasReseller is the quantity a reseller has to buy to get HargaReseller, 0 means any quantity
+		MinKuantitasReseller int       `gorm:"column:min_kuantitas_reseller;not null;default:0"`
+		Deskripsi            string    `gorm:"column:deskripsi;not null"`
+		IdToko               int       `gorm:"column:id_toko"`
+		Toko                 *Toko     `gorm:"foreignKey:IdToko"`
+		IdCategory           int       `gorm:"column:id_category"`
+		Category             *Category `gorm:"foreignKey:IdCategory"`
+		CreatedAt            time.Time `gorm:"column:created_at"`
+		UpdatedAt            time.Time `gorm:"column:updated_at"`
+		// DeletedAt archives the product, orders keep referring to it through log_produk
+		DeletedAt gorm.DeletedAt `gorm:"column:deleted_at;index"`
 	}
 
 	ProdukRepository interface {
 		CreateProdukAndFotoProduk(
 			ctx context.Context,
 			produk *Produk,
-			photoUrls []string,
+			photoKeys []string,
 		) (*Produk, []*FotoProduk, error)
-		Fetch(ctx context.Context, req *ProdukFetchRequest) ([]*Produk, error)
+		Fetch(ctx context.Context, req *ProdukFetchRequest) ([]*Produk, *PageResult, error)
+		FetchFacets(ctx context.Context, req *ProdukFetchRequest) (*ProdukFacets, error)
 		FindByID(ctx context.Context, produkId int) (*Produk, error)
+		// FindByIDWithDeleted also finds archived products
+		FindByIDWithDeleted(ctx context.Context, produkId int) (*Produk, error)
+		FindByIDs(ctx context.Context, produkIds []int) ([]*Produk, error)
+		FetchSearchDocuments(ctx context.Context) ([]*ProdukSearchDocument, error)
+		FetchSearchDocumentsByTokoID(ctx context.Context, tokoId int) ([]*ProdukSearchDocument, error)
+		FetchSearchDocumentsByCategoryID(ctx context.Context, categoryId int) ([]*ProdukSearchDocument, error)
+		// UpdateProdukAndFotoProduk replaces the photos only when photoKeys is not empty, it returns the photos either way
 		UpdateProdukAndFotoProduk(
 			ctx context.Context,
 			produkId int,
 			produk *Produk,
-			fotoProdukList []*FotoProduk,
+			photoKeys []string,
 		) (*Produk, []*FotoProduk, error)
-		DeleteProdukAndFotoProduk(ctx context.Context, produkId int) error
+		// Delete archives the product and takes it out of every cart, its photos and variants are kept for Restore
+		Delete(ctx context.Context, produkId int) error
+		Restore(ctx context.Context, produkId int) error
 	}
 
 	ProdukUsecase interface {
 		StoreProduk(ctx context.Context, req *ProdukRequest, userId int) (*ProdukResponse, error)
-		FetchProduk(ctx context.Context, req *ProdukFetchRequest) ([]*ProdukResponse, error)
+		FetchProduk(ctx context.Context, req *ProdukFetchRequest) (*ProdukFetchResponse, error)
+		SearchProduk(ctx context.Context, req *ProdukSearchRequest) (*ProdukFetchResponse, error)
+		// RebuildSearchIndex indexes every product again, needed by search indexes that do not persist
+		RebuildSearchIndex(ctx context.Context) error
 		GetProdukByID(ctx context.Context, produkId int) (*ProdukResponse, error)
 		EditProdukByID(ctx context.Context, produkId int, userId int, req *ProdukRequest) (*ProdukResponse, error)
 		DestroyProduk(ctx context.Context, produkId int, userId int) error
+		RestoreProduk(ctx context.Context, produkId int) (*ProdukResponse, error)
 	}
 
 	ProdukFetchRequest struct {
+		PaginationRequest
 		NamaProduk string
-		Limit      int
-		Page       int
 		CategoryId int
 		TokoId     int
-		MaxHarga   int
-		MinHarga   int
+		MaxHarga   Money
+		MinHarga   Money
+		// Sort is one of PRODUK_SORTS
+		Sort string
+		// HargaBuckets are the ascending upper bounds of the price facet buckets
+		HargaBuckets []Money
 	}
 
 	ProdukRequest struct {
-		NamaProduk    string `json:"nama_produk"`
-		Slug          string
-		HargaReseller string `json:"harga_reseller"`
-		HargaKonsumen string `json:"harga_konsumen"`
-		Stok          int    `json:"stok"`
-		Deskripsi     string `json:"deskripsi"`
-		IdToko        int
-		IdCategory    int `json:"id_category"`
-		PhotoUrls     []string
+		NamaProduk           string `json:"nama_produk"`
+		Slug                 string
+		HargaReseller        Money  `json:"harga_reseller"`
+		HargaKonsumen        Money  `json:"harga_konsumen"`
+		Stok                 int    `json:"stok"`
+		MinKuantitasReseller int    `json:"min_kuantitas_reseller"`
+		Deskripsi            string `json:"deskripsi"`
+		IdToko               int
+		IdCategory           int `json:"id_category"`
+		PhotoKeys            []string
 	}
 
 	ProdukResponse struct {
-		ID            int                   `json:"id"`
-		NamaProduk    string                `json:"nama_produk"`
-		Slug          string                `json:"slug"`
-		HargaReseller string                `json:"harga_reseller"`
-		HargaKonsumen string                `json:"harga_konsumen"`
-		Stok          int                   `json:"stok"`
-		Deskripsi     string                `json:"deskripsi"`
-		Toko          *TokoGetByIDResponse  `json:"toko"`
-		Category      *CategoryResponse     `json:"category"`
-		Photos        []*FotoProdukResponse `json:"photos"`
+		ID                   int                      `json:"id"`
+		NamaProduk           string                   `json:"nama_produk"`
+		Slug                 string                   `json:"slug"`
+		HargaReseller        Money                    `json:"harga_reseller"`
+		HargaKonsumen        Money                    `json:"harga_konsumen"`
+		Stok                 int                      `json:"stok"`
+		MinKuantitasReseller int                      `json:"min_kuantitas_reseller"`
+		Deskripsi            string                   `json:"deskripsi"`
+		Toko                 *TokoGetByIDResponse     `json:"toko"`
+		Category             *CategoryResponse        `json:"category"`
+		Photos               []*FotoProdukResponse    `json:"photos"`
+		Variants             []*ProdukVariantResponse `json:"variants"`
+	}
+
+	ProdukFetchResponse struct {
+		Pagination
+		Data []*ProdukResponse `json:"data"`
+		// Facets is only filled by the product listing
+		Facets *ProdukFacets `json:"facets,omitempty"`
 	}
 )
 
@@ -87,3 +139,12 @@ type (
 func (Produk) TableName() string {
 	return "produk"
 }
+
+// HargaSatuan returns the unit price of the given consumer and reseller prices,
+// resellers pay the reseller price once they buy at least MinKuantitasReseller
+func (produk *Produk) HargaSatuan(hargaKonsumen Money, hargaReseller Money, kuantitas int, isReseller bool) Money {
+	if isReseller && kuantitas >= produk.MinKuantitasReseller {
+		return hargaReseller
+	}
+	return hargaKonsumen
+}
@@ -0,0 +1,51 @@
+package model
+
+const PRODUK_FACET_MAX_HARGA_BUCKETS = 20
+
+// PRODUK_FACET_HARGA_BUCKETS are the default upper bounds of the price facet buckets
+var PRODUK_FACET_HARGA_BUCKETS = []Money{50000, 100000, 250000, 500000, 1000000}
+
+type (
+	// ProdukFacets counts the products matching the listing filters, grouped in several ways
+	ProdukFacets struct {
+		Category []*ProdukCategoryFacet `json:"category"`
+		Toko     []*ProdukTokoFacet     `json:"toko"`
+		Harga    []*ProdukHargaFacet    `json:"harga"`
+		Stok     *ProdukStokFacet       `json:"stok"`
+	}
+
+	ProdukCategoryFacet struct {
+		IdCategory   int    `gorm:"column:id_category" json:"id_category"`
+		NamaCategory string `gorm:"column:nama_category" json:"nama_category"`
+		Jumlah       int64  `gorm:"column:jumlah" json:"jumlah"`
+	}
+
+	ProdukTokoFacet struct {
+		IdToko   int    `gorm:"column:id_toko" json:"id_toko"`
+		NamaToko string `gorm:"column:nama_toko" json:"nama_toko"`
+		Jumlah   int64  `gorm:"column:jumlah" json:"jumlah"`
+	}
+
+	// ProdukHargaFacet counts the prices from MinHarga up to but excluding MaxHarga, MaxHarga is nil for the last bucket
+	ProdukHargaFacet struct {
+		MinHarga Money  `json:"min_harga"`
+		MaxHarga *Money `json:"max_harga"`
+		Jumlah   int64  `json:"jumlah"`
+	}
+
+	ProdukStokFacet struct {
+		Tersedia int64 `gorm:"column:tersedia" json:"tersedia"`
+		Habis    int64 `gorm:"column:habis" json:"habis"`
+	}
+)
+
+// NewProdukHargaFacets returns the empty buckets split at the ascending bounds
+func NewProdukHargaFacets(bounds []Money) []*ProdukHargaFacet {
+	facets := []*ProdukHargaFacet{}
+	minHarga := Money(0)
+	for i := range bounds {
+		facets = append(facets, &ProdukHargaFacet{MinHarga: minHarga, MaxHarga: &bounds[i]})
+		minHarga = bounds[i]
+	}
+	return append(facets, &ProdukHargaFacet{MinHarga: minHarga})
+}
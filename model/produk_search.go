@@ -0,0 +1,54 @@
+package model
+
+import (
+	"context"
+	"errors"
+)
+
+const (
+	PRODUK_SEARCH_BACKEND_MYSQL  = "mysql"
+	PRODUK_SEARCH_BACKEND_MEMORY = "memory"
+)
+
+// relevance weight of every indexed field, a hit in the product name counts most
+const (
+	PRODUK_SEARCH_WEIGHT_NAMA_PRODUK   = 3
+	PRODUK_SEARCH_WEIGHT_DESKRIPSI     = 1
+	PRODUK_SEARCH_WEIGHT_NAMA_CATEGORY = 2
+	PRODUK_SEARCH_WEIGHT_NAMA_TOKO     = 2
+)
+
+// PRODUK_SEARCH_MIN_SCORE_RATIO drops the MySQL hits scoring below this share of the best hit, the ngram
+// parser matches a product on any shared bigram, so without it a common bigram matches most of the catalog
+const PRODUK_SEARCH_MIN_SCORE_RATIO = 0.25
+
+var ErrEmptySearchQuery = errors.New("q must not be empty")
+
+type (
+	// ProdukSearchDocument is the indexed text of a product
+	ProdukSearchDocument struct {
+		ID           int    `gorm:"column:id"`
+		NamaProduk   string `gorm:"column:nama_produk"`
+		Deskripsi    string `gorm:"column:deskripsi"`
+		NamaCategory string `gorm:"column:nama_category"`
+		NamaToko     string `gorm:"column:nama_toko"`
+	}
+
+	ProdukSearchHit struct {
+		ID    int     `gorm:"column:id"`
+		Score float64 `gorm:"column:score"`
+	}
+
+	// ProdukSearchIndex finds products by relevance, hits are ordered by score descending then id
+	ProdukSearchIndex interface {
+		// Index adds or replaces the document of a product
+		Index(ctx context.Context, document *ProdukSearchDocument) error
+		Remove(ctx context.Context, produkId int) error
+		Search(ctx context.Context, req *ProdukSearchRequest) ([]*ProdukSearchHit, int64, error)
+	}
+
+	ProdukSearchRequest struct {
+		PaginationRequest
+		Query string
+	}
+)
@@ -0,0 +1,77 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const PRODUK_VARIANT_MAX_ATRIBUT = 5
+
+var (
+	ErrDuplicateSku          = errors.New("sku has already been used")
+	ErrProdukVariantRequired = errors.New("variant_id is required, the product has variants")
+	ErrProdukVariantMismatch = errors.New("variant does not belong to the product")
+)
+
+type (
+	// ProdukVariant is a purchasable option of a product, e.g. size XL in red, with its own price and stock.
+	// The stock of a product with variants is kept as the sum of the stock of its variants
+	ProdukVariant struct {
+		ID       int     `gorm:"column:id"`
+		IdProduk int     `gorm:"column:id_produk;not null"`
+		Produk   *Produk `gorm:"foreignKey:IdProduk"`
+		Sku      string  `gorm:"column:sku;size:255;not null;unique"`
+		// Atribut maps an option name to its value, e.g. {"ukuran": "XL", "warna": "merah"}
+		Atribut       map[string]string `gorm:"column:atribut;serializer:json;not null"`
+		HargaReseller Money             `gorm:"column:harga_reseller;not null"`
+		HargaKonsumen Money             `gorm:"column:harga_konsumen;not null"`
+		Stok          int               `gorm:"column:stok;not null"`
+		CreatedAt     time.Time         `gorm:"column:created_at"`
+		UpdatedAt     time.Time         `gorm:"column:updated_at"`
+	}
+
+	ProdukVariantRepository interface {
+		Create(ctx context.Context, produkVariant *ProdukVariant) (*ProdukVariant, error)
+		FindByID(ctx context.Context, produkVariantId int) (*ProdukVariant, error)
+		FetchByProdukId(ctx context.Context, produkId int) ([]*ProdukVariant, error)
+		FetchByProdukIds(ctx context.Context, produkIds []int) ([]*ProdukVariant, error)
+		Update(ctx context.Context, produkVariantId int, produkVariant *ProdukVariant) (*ProdukVariant, error)
+		Delete(ctx context.Context, produkVariantId int) error
+	}
+
+	ProdukVariantUsecase interface {
+		FetchProdukVariants(ctx context.Context, produkId int) ([]*ProdukVariantResponse, error)
+		StoreProdukVariant(ctx context.Context, produkId int, userId int, req *ProdukVariantRequest) (*ProdukVariantResponse, error)
+		EditProdukVariant(
+			ctx context.Context,
+			produkId int,
+			produkVariantId int,
+			userId int,
+			req *ProdukVariantRequest,
+		) (*ProdukVariantResponse, error)
+		DestroyProdukVariant(ctx context.Context, produkId int, produkVariantId int, userId int) error
+	}
+
+	ProdukVariantRequest struct {
+		Sku           string            `json:"sku"`
+		Atribut       map[string]string `json:"atribut"`
+		HargaReseller Money             `json:"harga_reseller"`
+		HargaKonsumen Money             `json:"harga_konsumen"`
+		Stok          int               `json:"stok"`
+	}
+
+	ProdukVariantResponse struct {
+		ID            int               `json:"id"`
+		Sku           string            `json:"sku"`
+		Atribut       map[string]string `json:"atribut"`
+		HargaReseller Money             `json:"harga_reseller"`
+		HargaKonsumen Money             `json:"harga_konsumen"`
+		Stok          int               `json:"stok"`
+	}
+)
+
+// override gorm table name
+func (ProdukVariant) TableName() string {
+	return "produk_variant"
+}
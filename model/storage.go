@@ -0,0 +1,61 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+const (
+	STORAGE_BACKEND_LOCAL = "local"
+	STORAGE_BACKEND_S3    = "s3"
+)
+
+// LOCAL_STORAGE_ROUTE is where the files of the local storage are served
+const LOCAL_STORAGE_ROUTE = "/uploads"
+
+// the folders of the object keys, e.g. "produk/<uuid>.jpg"
+const (
+	STORAGE_FOLDER_PRODUK = "produk"
+	STORAGE_FOLDER_TOKO   = "toko"
+)
+
+var ErrInvalidStorageKey = errors.New("invalid storage key")
+
+type (
+	// Storage keeps the uploaded files as objects, the database stores the object key instead of an url
+	Storage interface {
+		Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+		// Delete does not fail when the object does not exist
+		Delete(ctx context.Context, key string) error
+		// URL is the public url of the object, an empty key gives an empty url
+		URL(key string) string
+		// List returns every object whose key starts with prefix
+		List(ctx context.Context, prefix string) ([]*StorageObject, error)
+	}
+
+	StorageObject struct {
+		Key          string
+		Size         int64
+		LastModified time.Time
+	}
+
+	LocalStorageConfig struct {
+		// Dir is the folder the objects are written to
+		Dir string
+		// PublicUrl is prepended to the keys, the files are served on LOCAL_STORAGE_ROUTE
+		PublicUrl string
+	}
+
+	// S3StorageConfig addresses the bucket path-style, so S3-compatible servers such as MinIO work too
+	S3StorageConfig struct {
+		Endpoint  string
+		Region    string
+		Bucket    string
+		AccessKey string
+		SecretKey string
+		// PublicUrl is prepended to the keys, defaults to the bucket url
+		PublicUrl string
+	}
+)
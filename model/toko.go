@@ -7,21 +7,26 @@ import (
 
 type (
 	Toko struct {
-		ID        int       `gorm:"column:id"`
-		IdUser    int       `gorm:"column:id_user"`
-		User      *User     `gorm:"foreignKey:IdUser"`
-		NamaToko  string    `gorm:"column:nama_toko;size:255;not null"`
-		UrlFoto   string    `gorm:"column:url_foto;size:255;not null"`
-		CreatedAt time.Time `gorm:"column:created_at"`
-		UpdatedAt time.Time `gorm:"column:updated_at"`
+		ID       int    `gorm:"column:id"`
+		IdUser   int    `gorm:"column:id_user"`
+		User     *User  `gorm:"foreignKey:IdUser"`
+		NamaToko string `gorm:"column:nama_toko;size:255;not null"`
+		UrlFoto  string `gorm:"column:url_foto;size:255;not null"`
+		// FotoHasRenditions is false for photos uploaded before the renditions were generated
+		FotoHasRenditions bool      `gorm:"column:foto_has_renditions;not null;default:0"`
+		CreatedAt         time.Time `gorm:"column:created_at"`
+		UpdatedAt         time.Time `gorm:"column:updated_at"`
 	}
 
 	TokoRepository interface {
 		Create(ctx context.Context, toko *Toko) (*Toko, error)
-		FetchAndPaginate(ctx context.Context, req *TokoFetchPaginateRequest) ([]*Toko, error)
+		FetchAndPaginate(ctx context.Context, req *TokoFetchPaginateRequest) ([]*Toko, int64, error)
 		FindByTokoID(ctx context.Context, tokoId int) (*Toko, error)
+		FindByTokoIDs(ctx context.Context, tokoIds []int) ([]*Toko, error)
 		FindByUserID(ctx context.Context, userId int) (*Toko, error)
 		UpdateByTokoID(ctx context.Context, tokoId int, toko *Toko) (*Toko, error)
+		// FetchUrlFotos returns the object keys of every toko photo
+		FetchUrlFotos(ctx context.Context) ([]string, error)
 	}
 
 	TokoUsecase interface {
@@ -38,9 +43,8 @@ type (
 	}
 
 	TokoFetchPaginateRequest struct {
-		Limit int
-		Page  int
-		Nama  string
+		PaginationRequest
+		Nama string
 	}
 
 	TokoUpdateRequest struct {
@@ -58,34 +62,41 @@ type (
 	}
 
 	TokoFetchPaginateResponse struct {
-		Limit int                    `json:"limit"`
-		Page  int                    `json:"page"`
-		Data  []*TokoGetByIDResponse `json:"data"`
+		Pagination
+		Data []*TokoGetByIDResponse `json:"data"`
 	}
 
 	GetMyTokoResponse struct {
-		ID       int    `json:"id"`
-		NamaToko string `json:"nama_toko"`
-		UrlFoto  string `json:"url_foto"`
-		IdUser   int    `json:"user_id"`
+		ID               int    `json:"id"`
+		NamaToko         string `json:"nama_toko"`
+		UrlFoto          string `json:"url_foto"`
+		UrlFotoThumbnail string `json:"url_foto_thumbnail"`
+		UrlFotoMedium    string `json:"url_foto_medium"`
+		IdUser           int    `json:"user_id"`
 	}
 
 	TokoGetByIDResponse struct {
-		ID       int    `json:"id"`
-		NamaToko string `json:"nama_toko"`
-		UrlFoto  string `json:"url_foto"`
+		ID               int    `json:"id"`
+		NamaToko         string `json:"nama_toko"`
+		UrlFoto          string `json:"url_foto"`
+		UrlFotoThumbnail string `json:"url_foto_thumbnail"`
+		UrlFotoMedium    string `json:"url_foto_medium"`
 	}
 
 	TokoLogProdukResponse struct {
-		NamaToko string `json:"nama_toko"`
-		UrlFoto  string `json:"url_foto"`
+		NamaToko         string `json:"nama_toko"`
+		UrlFoto          string `json:"url_foto"`
+		UrlFotoThumbnail string `json:"url_foto_thumbnail"`
+		UrlFotoMedium    string `json:"url_foto_medium"`
 	}
 
 	TokoUpdateResponse struct {
-		ID       int    `json:"id"`
-		NamaToko string `json:"nama_toko"`
-		UrlFoto  string `json:"url_foto"`
-		IdUser   int    `json:"user_id"`
+		ID               int    `json:"id"`
+		NamaToko         string `json:"nama_toko"`
+		UrlFoto          string `json:"url_foto"`
+		UrlFotoThumbnail string `json:"url_foto_thumbnail"`
+		UrlFotoMedium    string `json:"url_foto_medium"`
+		IdUser           int    `json:"user_id"`
 	}
 )
 
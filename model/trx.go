@@ -7,19 +7,29 @@ import (
 
 const KODE_INVOICE_PREFIX = "INV-"
 
+const EXPIRE_UNPAID_TRX_BATCH_SIZE = 100
+
 type (
 	// Trx means Transaction
 	Trx struct {
-		ID               int       `gorm:"column:id"`
-		IdUser           int       `gorm:"column:id_user;not null"`
-		User             *User     `gorm:"foreignKey:IdUser"`
-		AlamatPengiriman int       `gorm:"column:alamat_pengiriman;not null"`
-		Alamat           *Alamat   `gorm:"foreignKey:AlamatPengiriman"`
-		HargaTotal       int       `gorm:"column:harga_total;not null"`
-		KodeInvoice      string    `gorm:"column:kode_invoice;size:255;not null"`
-		MethodBayar      string    `gorm:"column:method_bayar;size:255;not null"`
-		CreatedAt        time.Time `gorm:"column:created_at"`
-		UpdatedAt        time.Time `gorm:"column:updated_at"`
+		ID               int     `gorm:"column:id"`
+		IdUser           int     `gorm:"column:id_user;not null"`
+		User             *User   `gorm:"foreignKey:IdUser"`
+		AlamatPengiriman int     `gorm:"column:alamat_pengiriman;not null"`
+		Alamat           *Alamat `gorm:"foreignKey:AlamatPengiriman"`
+		// HargaTotal is the amount to pay, the sum of the detail trx after Diskon
+		HargaTotal Money `gorm:"column:harga_total;not null"`
+		// IdVoucher is nil when no voucher is used, KodeVoucher keeps the code after the voucher is deleted
+		IdVoucher   *int      `gorm:"column:id_voucher"`
+		Voucher     *Voucher  `gorm:"foreignKey:IdVoucher"`
+		KodeVoucher string    `gorm:"column:kode_voucher;size:255;not null;default:''"`
+		Diskon      Money     `gorm:"column:diskon;not null;default:0"`
+		KodeInvoice string    `gorm:"column:kode_invoice;size:255;not null;unique"`
+		MethodBayar string    `gorm:"column:method_bayar;size:255;not null"`
+		Status      TrxStatus `gorm:"column:status;size:255;not null;default:pending_payment"`
+		AlasanBatal string    `gorm:"column:alasan_batal;size:255;not null;default:''"`
+		CreatedAt   time.Time `gorm:"column:created_at"`
+		UpdatedAt   time.Time `gorm:"column:updated_at"`
 	}
 
 	TrxRepository interface {
@@ -28,40 +38,63 @@ type (
 			trx *Trx,
 			detailTrxWithLogProdukList []*DetailTrxWithLogProduk,
 		) (*Trx, error)
-		Fetch(ctx context.Context, req *TrxFetchRequest, userId int) ([]*Trx, error)
+		Fetch(ctx context.Context, req *TrxFetchRequest, userId int) ([]*Trx, *PageResult, error)
 		FindByID(ctx context.Context, trxId int) (*Trx, error)
+		FindByIDs(ctx context.Context, trxIds []int) ([]*Trx, error)
+		UpdateStatus(ctx context.Context, trxId int, currentStatus TrxStatus, history *TrxStatusHistory) error
+		CancelTrx(ctx context.Context, trxId int, currentStatus TrxStatus, history *TrxStatusHistory) error
+		FetchUnpaidCreatedBefore(ctx context.Context, createdBefore time.Time, afterId int, limit int) ([]*Trx, error)
+		ExpireTrx(ctx context.Context, trxId int, history *TrxStatusHistory) error
 	}
 
 	TrxUsecase interface {
 		StoreTrx(ctx context.Context, req *TrxStoreRequest, userId int) (*TrxGetByIDResponse, error)
 		FetchTrx(ctx context.Context, req *TrxFetchRequest, userId int) (*TrxFetchResponse, error)
 		GetTrxByID(ctx context.Context, trxId int, userId int) (*TrxGetByIDResponse, error)
+		FetchTokoOrders(ctx context.Context, req *TokoOrderFetchRequest, userId int) (*TokoOrderFetchResponse, error)
+		GetTrxStatus(ctx context.Context, trxId int, userId int, isAdmin bool) (*TrxStatusResponse, error)
+		EditTrxStatus(
+			ctx context.Context,
+			trxId int,
+			userId int,
+			isAdmin bool,
+			req *TrxStatusUpdateRequest,
+		) (*TrxStatusResponse, error)
+		CancelTrx(ctx context.Context, trxId int, userId int, isAdmin bool, req *TrxCancelRequest) (*TrxStatusResponse, error)
+		// ExpireUnpaidTrx cancels every trx still waiting for payment that was created before the deadline,
+		// a trx that cannot be cancelled does not stop the others and its error is returned with the count
+		ExpireUnpaidTrx(ctx context.Context, deadline time.Time) (int, error)
 	}
 
 	TrxStoreRequest struct {
 		MethodBayar       string              `json:"method_bayar"`
 		AlamatPengiriman  int                 `json:"alamat_kirim"`
 		DetailTrxRequests []*DetailTrxRequest `json:"detail_trx"`
+		// KodeVoucher is optional
+		KodeVoucher string `json:"kode_voucher"`
 	}
 
 	TrxFetchRequest struct {
+		PaginationRequest
 		Search string
-		Limit  int
-		Page   int
 	}
 
 	TrxFetchResponse struct {
-		Limit int                   `json:"limit"`
-		Page  int                   `json:"page"`
-		Data  []*TrxGetByIDResponse `json:"data"`
+		Pagination
+		Data []*TrxGetByIDResponse `json:"data"`
 	}
 
 	TrxGetByIDResponse struct {
 		ID                 int                  `json:"id"`
-		HargaTotal         int                  `json:"harga_total"`
+		HargaTotal         Money                `json:"harga_total"`
+		KodeVoucher        string               `json:"kode_voucher"`
+		Diskon             Money                `json:"diskon"`
 		KodeInvoice        string               `json:"kode_invoice"`
 		MethodBayar        string               `json:"method_bayar"`
+		Status             TrxStatus            `json:"status"`
+		AlasanBatal        string               `json:"alasan_batal"`
 		AlamatPengiriman   *AlamatResponse      `json:"alamat_kirim"`
+		Payment            *PaymentResponse     `json:"payment"`
 		DetailTrxResponses []*DetailTrxResponse `json:"detail_trx"`
 	}
 )
@@ -0,0 +1,141 @@
+package model
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+type (
+	TrxStatus string
+
+	// TrxActor is the role a user acts as when changing the status of a transaction
+	TrxActor string
+)
+
+const (
+	TRX_STATUS_PENDING_PAYMENT TrxStatus = "pending_payment"
+	TRX_STATUS_PAID            TrxStatus = "paid"
+	TRX_STATUS_PROCESSING      TrxStatus = "processing"
+	TRX_STATUS_SHIPPED         TrxStatus = "shipped"
+	TRX_STATUS_DELIVERED       TrxStatus = "delivered"
+	TRX_STATUS_COMPLETED       TrxStatus = "completed"
+	TRX_STATUS_CANCELLED       TrxStatus = "cancelled"
+	TRX_STATUS_REFUNDED        TrxStatus = "refunded"
+)
+
+const (
+	TRX_ACTOR_BUYER  TrxActor = "buyer"
+	TRX_ACTOR_SELLER TrxActor = "seller"
+	TRX_ACTOR_ADMIN  TrxActor = "admin"
+	TRX_ACTOR_SYSTEM TrxActor = "system"
+)
+
+// trxStatusTransitions lists, for every status, the next statuses allowed and which actors may move the transaction there
+var trxStatusTransitions = map[TrxStatus]map[TrxStatus][]TrxActor{
+	TRX_STATUS_PENDING_PAYMENT: {
+		TRX_STATUS_PAID:      {TRX_ACTOR_ADMIN, TRX_ACTOR_SYSTEM},
+		TRX_STATUS_CANCELLED: {TRX_ACTOR_BUYER, TRX_ACTOR_SELLER, TRX_ACTOR_ADMIN, TRX_ACTOR_SYSTEM},
+	},
+	TRX_STATUS_PAID: {
+		TRX_STATUS_PROCESSING: {TRX_ACTOR_SELLER, TRX_ACTOR_ADMIN},
+		TRX_STATUS_CANCELLED:  {TRX_ACTOR_BUYER, TRX_ACTOR_SELLER, TRX_ACTOR_ADMIN},
+		TRX_STATUS_REFUNDED:   {TRX_ACTOR_ADMIN},
+	},
+	TRX_STATUS_PROCESSING: {
+		TRX_STATUS_SHIPPED:   {TRX_ACTOR_SELLER, TRX_ACTOR_ADMIN},
+		TRX_STATUS_CANCELLED: {TRX_ACTOR_BUYER, TRX_ACTOR_SELLER, TRX_ACTOR_ADMIN},
+	},
+	// once shipped, only an admin can still cancel the transaction
+	TRX_STATUS_SHIPPED: {
+		TRX_STATUS_DELIVERED: {TRX_ACTOR_BUYER, TRX_ACTOR_SELLER, TRX_ACTOR_ADMIN},
+		TRX_STATUS_CANCELLED: {TRX_ACTOR_ADMIN},
+	},
+	TRX_STATUS_DELIVERED: {
+		TRX_STATUS_COMPLETED: {TRX_ACTOR_BUYER, TRX_ACTOR_ADMIN, TRX_ACTOR_SYSTEM},
+		TRX_STATUS_CANCELLED: {TRX_ACTOR_ADMIN},
+		TRX_STATUS_REFUNDED:  {TRX_ACTOR_ADMIN},
+	},
+	TRX_STATUS_COMPLETED: {
+		TRX_STATUS_CANCELLED: {TRX_ACTOR_ADMIN},
+		TRX_STATUS_REFUNDED:  {TRX_ACTOR_ADMIN},
+	},
+	TRX_STATUS_CANCELLED: {
+		TRX_STATUS_REFUNDED: {TRX_ACTOR_ADMIN},
+	},
+	TRX_STATUS_REFUNDED: {},
+}
+
+type (
+	TrxStatusHistory struct {
+		ID            int       `gorm:"column:id"`
+		IdTrx         int       `gorm:"column:id_trx;not null"`
+		Trx           *Trx      `gorm:"foreignKey:IdTrx"`
+		StatusSebelum TrxStatus `gorm:"column:status_sebelum;size:255;not null"`
+		StatusSesudah TrxStatus `gorm:"column:status_sesudah;size:255;not null"`
+		// IdUser is nil when the status is changed by the system
+		IdUser    *int      `gorm:"column:id_user"`
+		User      *User     `gorm:"foreignKey:IdUser"`
+		Peran     TrxActor  `gorm:"column:peran;size:255;not null"`
+		Catatan   string    `gorm:"column:catatan;size:255;not null"`
+		CreatedAt time.Time `gorm:"column:created_at"`
+	}
+
+	TrxStatusHistoryRepository interface {
+		FindByTrxID(ctx context.Context, trxId int) ([]*TrxStatusHistory, error)
+	}
+
+	TrxStatusUpdateRequest struct {
+		Status  TrxStatus `json:"status"`
+		Catatan string    `json:"catatan"`
+	}
+
+	TrxCancelRequest struct {
+		Alasan string `json:"alasan"`
+	}
+
+	TrxStatusHistoryResponse struct {
+		StatusSebelum TrxStatus `json:"status_sebelum"`
+		StatusSesudah TrxStatus `json:"status_sesudah"`
+		IdUser        *int      `json:"user_id"`
+		Peran         TrxActor  `json:"peran"`
+		Catatan       string    `json:"catatan"`
+		CreatedAt     time.Time `json:"created_at"`
+	}
+
+	TrxStatusResponse struct {
+		ID          int                         `json:"id"`
+		KodeInvoice string                      `json:"kode_invoice"`
+		Status      TrxStatus                   `json:"status"`
+		History     []*TrxStatusHistoryResponse `json:"history"`
+	}
+)
+
+// override gorm table name
+func (TrxStatusHistory) TableName() string {
+	return "trx_status_history"
+}
+
+func (s TrxStatus) IsValid() bool {
+	_, ok := trxStatusTransitions[s]
+	return ok
+}
+
+// CanTransitionTo reports whether actor is allowed to move a transaction from s to next
+func (s TrxStatus) CanTransitionTo(next TrxStatus, actor TrxActor) bool {
+	for _, allowedActor := range trxStatusTransitions[s][next] {
+		if allowedActor == actor {
+			return true
+		}
+	}
+	return false
+}
+
+func (req *TrxCancelRequest) Trim() {
+	req.Alasan = strings.TrimSpace(req.Alasan)
+}
+
+func (req *TrxStatusUpdateRequest) Trim() {
+	req.Status = TrxStatus(strings.TrimSpace(string(req.Status)))
+	req.Catatan = strings.TrimSpace(req.Catatan)
+}
@@ -0,0 +1,56 @@
+package model
+
+import "testing"
+
+func TestTrxStatusCanTransitionTo(t *testing.T) {
+	tests := []struct {
+		from  TrxStatus
+		to    TrxStatus
+		actor TrxActor
+		want  bool
+	}{
+		{TRX_STATUS_PENDING_PAYMENT, TRX_STATUS_PAID, TRX_ACTOR_SYSTEM, true},
+		{TRX_STATUS_PENDING_PAYMENT, TRX_STATUS_PAID, TRX_ACTOR_BUYER, false},
+		{TRX_STATUS_PENDING_PAYMENT, TRX_STATUS_CANCELLED, TRX_ACTOR_BUYER, true},
+		{TRX_STATUS_PENDING_PAYMENT, TRX_STATUS_SHIPPED, TRX_ACTOR_ADMIN, false},
+		{TRX_STATUS_PAID, TRX_STATUS_PROCESSING, TRX_ACTOR_SELLER, true},
+		{TRX_STATUS_PAID, TRX_STATUS_PROCESSING, TRX_ACTOR_BUYER, false},
+		{TRX_STATUS_PAID, TRX_STATUS_CANCELLED, TRX_ACTOR_SYSTEM, false},
+		{TRX_STATUS_PAID, TRX_STATUS_REFUNDED, TRX_ACTOR_SELLER, false},
+		{TRX_STATUS_PROCESSING, TRX_STATUS_SHIPPED, TRX_ACTOR_SELLER, true},
+		{TRX_STATUS_PROCESSING, TRX_STATUS_CANCELLED, TRX_ACTOR_BUYER, true},
+		{TRX_STATUS_SHIPPED, TRX_STATUS_DELIVERED, TRX_ACTOR_BUYER, true},
+		{TRX_STATUS_SHIPPED, TRX_STATUS_CANCELLED, TRX_ACTOR_BUYER, false},
+		{TRX_STATUS_SHIPPED, TRX_STATUS_CANCELLED, TRX_ACTOR_SELLER, false},
+		{TRX_STATUS_SHIPPED, TRX_STATUS_CANCELLED, TRX_ACTOR_ADMIN, true},
+		{TRX_STATUS_DELIVERED, TRX_STATUS_COMPLETED, TRX_ACTOR_SYSTEM, true},
+		{TRX_STATUS_DELIVERED, TRX_STATUS_COMPLETED, TRX_ACTOR_SELLER, false},
+		{TRX_STATUS_COMPLETED, TRX_STATUS_REFUNDED, TRX_ACTOR_ADMIN, true},
+		{TRX_STATUS_CANCELLED, TRX_STATUS_PAID, TRX_ACTOR_ADMIN, false},
+		{TRX_STATUS_CANCELLED, TRX_STATUS_REFUNDED, TRX_ACTOR_ADMIN, true},
+		{TRX_STATUS_REFUNDED, TRX_STATUS_CANCELLED, TRX_ACTOR_ADMIN, false},
+		{TRX_STATUS_PAID, TRX_STATUS_PAID, TRX_ACTOR_ADMIN, false},
+		{"unknown", TRX_STATUS_PAID, TRX_ACTOR_ADMIN, false},
+	}
+	for _, tt := range tests {
+		if got := tt.from.CanTransitionTo(tt.to, tt.actor); got != tt.want {
+			t.Errorf("%s -> %s by %s: got %v, want %v", tt.from, tt.to, tt.actor, got, tt.want)
+		}
+	}
+}
+
+func TestTrxStatusIsValid(t *testing.T) {
+	for _, status := range []TrxStatus{
+		TRX_STATUS_PENDING_PAYMENT, TRX_STATUS_PAID, TRX_STATUS_PROCESSING, TRX_STATUS_SHIPPED,
+		TRX_STATUS_DELIVERED, TRX_STATUS_COMPLETED, TRX_STATUS_CANCELLED, TRX_STATUS_REFUNDED,
+	} {
+		if !status.IsValid() {
+			t.Errorf("%s is not valid", status)
+		}
+	}
+	for _, status := range []TrxStatus{"", "Paid", "unknown"} {
+		if status.IsValid() {
+			t.Errorf("%q is valid", status)
+		}
+	}
+}
@@ -25,8 +25,10 @@ type (
 		IdProvinsi   string    `gorm:"column:id_provinsi;size:255;not null"`
 		IdKota       string    `gorm:"column:id_kota;size:255;not null"`
 		IsAdmin      bool      `gorm:"column:is_admin;not null;default:0"`
-		CreatedAt    time.Time `gorm:"column:created_at"`
-		UpdatedAt    time.Time `gorm:"column:updated_at"`
+		// IsReseller is granted by an admin, resellers are charged the reseller price at checkout
+		IsReseller bool      `gorm:"column:is_reseller;not null;default:0"`
+		CreatedAt  time.Time `gorm:"column:created_at"`
+		UpdatedAt  time.Time `gorm:"column:updated_at"`
 	}
 
 	UserRepository interface {
@@ -34,6 +36,7 @@ type (
 		FindByNoTelp(ctx context.Context, noTelp string) (*User, error)
 		FindByID(ctx context.Context, id int) (*User, error)
 		UpdateByID(ctx context.Context, id int, user *User) (*User, error)
+		UpdateIsResellerByID(ctx context.Context, id int, isReseller bool) (*User, error)
 	}
 
 	UserUsecase interface {
@@ -41,6 +44,7 @@ type (
 		LoginUser(ctx context.Context, req *UserLoginRequest) (*UserLoginResponse, error)
 		GetCurrentUser(ctx context.Context, userId int) (*UserResponse, error)
 		EditCurrentUser(ctx context.Context, userId int, req *UserUpdateRequest) (*UserResponse, error)
+		EditUserReseller(ctx context.Context, userId int, req *UserResellerRequest) (*UserResponse, error)
 	}
 
 	UserRegisterRequest struct {
@@ -74,6 +78,10 @@ type (
 		IdKota       string `json:"id_kota"`
 	}
 
+	UserResellerRequest struct {
+		IsReseller bool `json:"is_reseller"`
+	}
+
 	UserRegisterResponse struct {
 		Nama         string              `json:"nama"`
 		NoTelp       string              `json:"no_telp"`
@@ -97,6 +105,7 @@ type (
 		Email        string    `json:"email"`
 		IdProvinsi   *Province `json:"id_provinsi"`
 		IdKota       *City     `json:"id_kota"`
+		IsReseller   bool      `json:"is_reseller"`
 	}
 
 	UserLoginResponse struct {
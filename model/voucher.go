@@ -0,0 +1,165 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	VOUCHER_JENIS_DISKON_PERSEN  = "persen"
+	VOUCHER_JENIS_DISKON_NOMINAL = "nominal"
+)
+
+var (
+	ErrDuplicateKodeVoucher   = errors.New("kode voucher has already been used")
+	ErrVoucherNotFound        = errors.New("voucher not found")
+	ErrVoucherNotActive       = errors.New("voucher is not active")
+	ErrVoucherKuotaHabis      = errors.New("voucher usage limit has been reached")
+	ErrVoucherKuotaUserHabis  = errors.New("voucher usage limit per user has been reached")
+	ErrVoucherMinBelanja      = errors.New("total belanja does not reach the voucher minimum spend")
+	ErrVoucherNoEligibleItems = errors.New("no product in the transaction is eligible for the voucher")
+)
+
+type (
+	// Voucher discounts a transaction, an admin-wide voucher has a nil IdToko,
+	// a toko voucher only discounts the products of its toko
+	Voucher struct {
+		ID          int    `gorm:"column:id"`
+		Kode        string `gorm:"column:kode;size:255;not null;unique"`
+		IdToko      *int   `gorm:"column:id_toko"`
+		Toko        *Toko  `gorm:"foreignKey:IdToko"`
+		JenisDiskon string `gorm:"column:jenis_diskon;size:255;not null"`
+		// NilaiDiskon is a percentage for VOUCHER_JENIS_DISKON_PERSEN, an amount of money for VOUCHER_JENIS_DISKON_NOMINAL
+		NilaiDiskon int64 `gorm:"column:nilai_diskon;not null"`
+		// MaksDiskon caps a percentage discount, 0 means no cap
+		MaksDiskon Money `gorm:"column:maks_diskon;not null;default:0"`
+		MinBelanja Money `gorm:"column:min_belanja;not null;default:0"`
+		// KuotaTotal and KuotaPerUser limit the uses of the voucher, 0 means unlimited
+		KuotaTotal    int       `gorm:"column:kuota_total;not null;default:0"`
+		KuotaPerUser  int       `gorm:"column:kuota_per_user;not null;default:0"`
+		Terpakai      int       `gorm:"column:terpakai;not null;default:0"`
+		BerlakuMulai  time.Time `gorm:"column:berlaku_mulai;not null"`
+		BerlakuSampai time.Time `gorm:"column:berlaku_sampai;not null"`
+		CreatedAt     time.Time `gorm:"column:created_at"`
+		UpdatedAt     time.Time `gorm:"column:updated_at"`
+	}
+
+	// VoucherCategory restricts a voucher to a category, a voucher without categories discounts every category
+	VoucherCategory struct {
+		ID         int       `gorm:"column:id"`
+		IdVoucher  int       `gorm:"column:id_voucher;not null"`
+		Voucher    *Voucher  `gorm:"foreignKey:IdVoucher"`
+		IdCategory int       `gorm:"column:id_category;not null"`
+		Category   *Category `gorm:"foreignKey:IdCategory"`
+	}
+
+	// VoucherPemakaian is one use of a voucher, it is removed again when the transaction is cancelled
+	VoucherPemakaian struct {
+		ID        int       `gorm:"column:id"`
+		IdVoucher int       `gorm:"column:id_voucher;not null"`
+		Voucher   *Voucher  `gorm:"foreignKey:IdVoucher"`
+		IdUser    int       `gorm:"column:id_user;not null"`
+		User      *User     `gorm:"foreignKey:IdUser"`
+		IdTrx     int       `gorm:"column:id_trx;not null;unique"`
+		Trx       *Trx      `gorm:"foreignKey:IdTrx"`
+		Diskon    Money     `gorm:"column:diskon;not null"`
+		CreatedAt time.Time `gorm:"column:created_at"`
+	}
+
+	VoucherRepository interface {
+		Create(ctx context.Context, voucher *Voucher, categoryIds []int) (*Voucher, error)
+		Fetch(ctx context.Context, req *VoucherFetchRequest) ([]*Voucher, int64, error)
+		FindByID(ctx context.Context, voucherId int) (*Voucher, error)
+		FindByKode(ctx context.Context, kode string) (*Voucher, error)
+		FetchCategories(ctx context.Context, voucherIds []int) ([]*VoucherCategory, error)
+		CountPemakaianByUser(ctx context.Context, voucherId int, userId int) (int64, error)
+		Update(ctx context.Context, voucherId int, voucher *Voucher, categoryIds []int) (*Voucher, error)
+		Delete(ctx context.Context, voucherId int) error
+	}
+
+	VoucherUsecase interface {
+		StoreVoucher(ctx context.Context, userId int, isAdmin bool, req *VoucherRequest) (*VoucherResponse, error)
+		FetchVoucher(ctx context.Context, userId int, isAdmin bool, req *VoucherFetchRequest) (*VoucherFetchResponse, error)
+		GetVoucherByID(ctx context.Context, voucherId int, userId int, isAdmin bool) (*VoucherResponse, error)
+		EditVoucher(ctx context.Context, voucherId int, userId int, isAdmin bool, req *VoucherRequest) (*VoucherResponse, error)
+		DestroyVoucher(ctx context.Context, voucherId int, userId int, isAdmin bool) error
+	}
+
+	// VoucherFetchRequest lists the vouchers of IdToko, a nil IdToko lists every voucher
+	VoucherFetchRequest struct {
+		PaginationRequest
+		IdToko *int
+	}
+
+	VoucherRequest struct {
+		Kode string `json:"kode"`
+		// Global creates an admin-wide voucher instead of a voucher of the toko of the user, only admins can set it
+		Global        bool      `json:"global"`
+		JenisDiskon   string    `json:"jenis_diskon"`
+		NilaiDiskon   int64     `json:"nilai_diskon"`
+		MaksDiskon    Money     `json:"maks_diskon"`
+		MinBelanja    Money     `json:"min_belanja"`
+		KuotaTotal    int       `json:"kuota_total"`
+		KuotaPerUser  int       `json:"kuota_per_user"`
+		BerlakuMulai  time.Time `json:"berlaku_mulai"`
+		BerlakuSampai time.Time `json:"berlaku_sampai"`
+		CategoryIds   []int     `json:"category_ids"`
+	}
+
+	VoucherResponse struct {
+		ID            int                 `json:"id"`
+		Kode          string              `json:"kode"`
+		IdToko        *int                `json:"toko_id"`
+		JenisDiskon   string              `json:"jenis_diskon"`
+		NilaiDiskon   int64               `json:"nilai_diskon"`
+		MaksDiskon    Money               `json:"maks_diskon"`
+		MinBelanja    Money               `json:"min_belanja"`
+		KuotaTotal    int                 `json:"kuota_total"`
+		KuotaPerUser  int                 `json:"kuota_per_user"`
+		Terpakai      int                 `json:"terpakai"`
+		BerlakuMulai  time.Time           `json:"berlaku_mulai"`
+		BerlakuSampai time.Time           `json:"berlaku_sampai"`
+		Categories    []*CategoryResponse `json:"categories"`
+	}
+
+	VoucherFetchResponse struct {
+		Pagination
+		Data []*VoucherResponse `json:"data"`
+	}
+)
+
+// override gorm table name
+func (Voucher) TableName() string {
+	return "voucher"
+}
+
+// override gorm table name
+func (VoucherCategory) TableName() string {
+	return "voucher_category"
+}
+
+// override gorm table name
+func (VoucherPemakaian) TableName() string {
+	return "voucher_pemakaian"
+}
+
+// IsActive tells whether the voucher can be used at the given time
+func (voucher *Voucher) IsActive(now time.Time) bool {
+	return !now.Before(voucher.BerlakuMulai) && now.Before(voucher.BerlakuSampai)
+}
+
+// Diskon returns the discount on the eligible subtotal, never more than the subtotal
+func (voucher *Voucher) Diskon(subtotal Money) Money {
+	diskon := Money(voucher.NilaiDiskon)
+	if voucher.JenisDiskon == VOUCHER_JENIS_DISKON_PERSEN {
+		diskon = subtotal * Money(voucher.NilaiDiskon) / 100
+		if voucher.MaksDiskon > 0 && diskon > voucher.MaksDiskon {
+			diskon = voucher.MaksDiskon
+		}
+	}
+	if diskon > subtotal {
+		diskon = subtotal
+	}
+	return diskon
+}
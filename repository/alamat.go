@@ -24,16 +24,24 @@ func (a *alamatRepository) Create(ctx context.Context, alamat *model.Alamat) (*m
 	return alamat, nil
 }
 
-func (a *alamatRepository) FetchAndFilter(ctx context.Context, userId int, judulAlamat string) ([]*model.Alamat, error) {
+func (a *alamatRepository) FetchAndFilter(ctx context.Context, req *model.AlamatFetchRequest, userId int) ([]*model.Alamat, int64, error) {
 	var data []*model.Alamat
+	var total int64
 
-	if err := a.Cfg.Database().WithContext(ctx).
-		Where("id_user = ? AND judul_alamat LIKE ?", userId, "%"+judulAlamat+"%").
+	query := a.Cfg.Database().WithContext(ctx).
+		Model(&model.Alamat{}).
+		Where("id_user = ? AND judul_alamat LIKE ?", userId, "%"+req.JudulAlamat+"%").
+		Session(&gorm.Session{})
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := query.
+		Limit(req.Limit).Offset(req.Offset()).
 		Find(&data).Error; err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	return data, nil
+	return data, total, nil
 }
 
 func (a *alamatRepository) FindByID(ctx context.Context, alamatId int) (*model.Alamat, error) {
@@ -76,3 +84,59 @@ func (a *alamatRepository) Delete(ctx context.Context, alamatId int) error {
 	}
 	return nil
 }
+
+func (a *alamatRepository) FindByIDs(ctx context.Context, alamatIds []int) ([]*model.Alamat, error) {
+	data := []*model.Alamat{}
+	if len(alamatIds) == 0 {
+		return data, nil
+	}
+
+	if err := a.Cfg.Database().WithContext(ctx).
+		Where("id IN ?", alamatIds).
+		Order("id").
+		Find(&data).Error; err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (a *alamatRepository) FindByIDsWithDeleted(ctx context.Context, alamatIds []int) ([]*model.Alamat, error) {
+	data := []*model.Alamat{}
+	if len(alamatIds) == 0 {
+		return data, nil
+	}
+
+	if err := a.Cfg.Database().WithContext(ctx).
+		Unscoped().
+		Where("id IN ?", alamatIds).
+		Order("id").
+		Find(&data).Error; err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (a *alamatRepository) Restore(ctx context.Context, alamatId int) (*model.Alamat, error) {
+	alamat := new(model.Alamat)
+
+	if err := a.Cfg.Database().
+		WithContext(ctx).
+		Unscoped().
+		First(alamat, alamatId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("alamat not found")
+		}
+		return nil, err
+	}
+	if !alamat.DeletedAt.Valid {
+		return nil, errors.New("alamat is not deleted")
+	}
+
+	if err := a.Cfg.Database().WithContext(ctx).Unscoped().
+		Model(alamat).Update("deleted_at", nil).Error; err != nil {
+		return nil, err
+	}
+	return alamat, nil
+}
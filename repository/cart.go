@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"marketplace-api/config"
+	"marketplace-api/model"
+
+	"gorm.io/gorm"
+)
+
+type cartRepository struct {
+	Cfg config.Config
+}
+
+func NewCartRepository(cfg config.Config) model.CartRepository {
+	return &cartRepository{Cfg: cfg}
+}
+
+func (c *cartRepository) FindOrCreateByUserID(ctx context.Context, userId int) (*model.Cart, error) {
+	cart := new(model.Cart)
+
+	if err := c.Cfg.Database().WithContext(ctx).
+		Where(model.Cart{IdUser: userId}).
+		FirstOrCreate(cart).Error; err != nil {
+		return nil, err
+	}
+	return cart, nil
+}
+
+func (c *cartRepository) FetchItems(ctx context.Context, cartId int) ([]*model.CartItem, error) {
+	var data []*model.CartItem
+
+	if err := c.Cfg.Database().WithContext(ctx).
+		Where("id_cart = ?", cartId).
+		Order("id").
+		Find(&data).Error; err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (c *cartRepository) FindItemByID(ctx context.Context, cartItemId int) (*model.CartItem, error) {
+	cartItem := new(model.CartItem)
+
+	if err := c.Cfg.Database().
+		WithContext(ctx).
+		First(cartItem, cartItemId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("cart item not found")
+		}
+		return nil, err
+	}
+	return cartItem, nil
+}
+
+func (c *cartRepository) CreateItem(ctx context.Context, cartItem *model.CartItem) (*model.CartItem, error) {
+	if err := c.Cfg.Database().WithContext(ctx).Create(&cartItem).Error; err != nil {
+		return nil, err
+	}
+	return cartItem, nil
+}
+
+func (c *cartRepository) UpdateItemByID(ctx context.Context, cartItemId int, cartItem *model.CartItem) (*model.CartItem, error) {
+	_, err := c.FindItemByID(ctx, cartItemId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.Cfg.Database().WithContext(ctx).
+		Model(&model.CartItem{ID: cartItemId}).Updates(cartItem).Find(cartItem).Error; err != nil {
+		return nil, err
+	}
+	return cartItem, nil
+}
+
+func (c *cartRepository) DeleteItems(ctx context.Context, cartItemIds []int) error {
+	if len(cartItemIds) == 0 {
+		return nil
+	}
+
+	res := c.Cfg.Database().WithContext(ctx).
+		Delete(&model.CartItem{}, cartItemIds)
+	if res.Error != nil {
+		return res.Error
+	}
+	return nil
+}
+
+func (c *cartRepository) DeleteAllItems(ctx context.Context, cartId int) error {
+	res := c.Cfg.Database().WithContext(ctx).
+		Delete(&model.CartItem{}, "id_cart = ?", cartId)
+	if res.Error != nil {
+		return res.Error
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+	"marketplace-api/config/configtest"
+	"marketplace-api/model"
+	"testing"
+)
+
+func TestCreateCartItemKeepsOneLinePerVariant(t *testing.T) {
+	ctx := context.Background()
+	cartRepository := NewCartRepository(configtest.NewConfig(t))
+	cart, err := cartRepository.FindOrCreateByUserID(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	variantIds := []int{1, 2}
+
+	tests := []struct {
+		name            string
+		idProdukVariant *int
+		wantErr         bool
+	}{
+		{"product without variants", nil, false},
+		{"same product without variants again", nil, true},
+		{"first variant", &variantIds[0], false},
+		{"second variant", &variantIds[1], false},
+		{"first variant again", &variantIds[0], true},
+	}
+	for _, tt := range tests {
+		_, err := cartRepository.CreateItem(ctx, &model.CartItem{
+			IdCart:          cart.ID,
+			IdProduk:        1,
+			IdProdukVariant: tt.idProdukVariant,
+			Kuantitas:       1,
+			Harga:           10000,
+		})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: got error %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
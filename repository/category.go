@@ -68,6 +68,18 @@ func (c *categoryRepository) Delete(ctx context.Context, id int) error {
 		return err
 	}
 
+	// archived products are not counted, they keep pointing at the deleted category
+	var totalProduk int64
+	if err := c.Cfg.Database().WithContext(ctx).
+		Model(&model.Produk{}).
+		Where("id_category = ?", id).
+		Count(&totalProduk).Error; err != nil {
+		return err
+	}
+	if totalProduk > 0 {
+		return model.ErrCategoryInUse
+	}
+
 	res := c.Cfg.Database().WithContext(ctx).
 		Delete(&model.Category{}, id)
 	if res.Error != nil {
@@ -75,3 +87,59 @@ func (c *categoryRepository) Delete(ctx context.Context, id int) error {
 	}
 	return nil
 }
+
+func (c *categoryRepository) FindByIDs(ctx context.Context, ids []int) ([]*model.Category, error) {
+	data := []*model.Category{}
+	if len(ids) == 0 {
+		return data, nil
+	}
+
+	if err := c.Cfg.Database().WithContext(ctx).
+		Where("id IN ?", ids).
+		Order("id").
+		Find(&data).Error; err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (c *categoryRepository) FindByIDsWithDeleted(ctx context.Context, ids []int) ([]*model.Category, error) {
+	data := []*model.Category{}
+	if len(ids) == 0 {
+		return data, nil
+	}
+
+	if err := c.Cfg.Database().WithContext(ctx).
+		Unscoped().
+		Where("id IN ?", ids).
+		Order("id").
+		Find(&data).Error; err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (c *categoryRepository) Restore(ctx context.Context, id int) (*model.Category, error) {
+	category := new(model.Category)
+
+	if err := c.Cfg.Database().
+		WithContext(ctx).
+		Unscoped().
+		First(category, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("category not found")
+		}
+		return nil, err
+	}
+	if !category.DeletedAt.Valid {
+		return nil, errors.New("category is not deleted")
+	}
+
+	if err := c.Cfg.Database().WithContext(ctx).Unscoped().
+		Model(category).Update("deleted_at", nil).Error; err != nil {
+		return nil, err
+	}
+	return category, nil
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"marketplace-api/config"
 	"marketplace-api/model"
+
+	"gorm.io/gorm"
 )
 
 type detailTrxRepository struct {
@@ -25,3 +27,72 @@ func (d *detailTrxRepository) FindByTrxID(ctx context.Context, trxId int) ([]*mo
 
 	return data, nil
 }
+
+func (d *detailTrxRepository) FetchByTokoID(ctx context.Context, tokoId int, req *model.TokoOrderFetchRequest) ([]*model.DetailTrx, error) {
+	var data []*model.DetailTrx
+
+	if err := d.tokoOrderQuery(ctx, tokoId, req).
+		Select("detail_trx.*").
+		Order("detail_trx.id DESC").
+		Limit(req.Limit).Offset(req.Offset()).
+		Find(&data).Error; err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (d *detailTrxRepository) SummarizeByTokoID(ctx context.Context, tokoId int, req *model.TokoOrderFetchRequest) (*model.TokoOrderSummary, error) {
+	summary := new(model.TokoOrderSummary)
+
+	if err := d.tokoOrderQuery(ctx, tokoId, req).
+		Select("COUNT(detail_trx.id) AS total, " +
+			"COALESCE(SUM(detail_trx.kuantitas), 0) AS total_kuantitas, " +
+			"COALESCE(SUM(detail_trx.harga_total), 0) AS total_harga").
+		Scan(summary).Error; err != nil {
+		return nil, err
+	}
+
+	return summary, nil
+}
+
+func (d *detailTrxRepository) tokoOrderQuery(ctx context.Context, tokoId int, req *model.TokoOrderFetchRequest) *gorm.DB {
+	query := d.Cfg.Database().WithContext(ctx).
+		Model(&model.DetailTrx{}).
+		Joins("JOIN trx ON trx.id = detail_trx.id_trx").
+		Joins("JOIN log_produk ON log_produk.id = detail_trx.id_log_produk").
+		Where("detail_trx.id_toko = ?", tokoId)
+	if !req.StartDate.IsZero() {
+		query = query.Where("trx.created_at >= ?", req.StartDate)
+	}
+	if !req.EndDate.IsZero() {
+		// end date is inclusive, so take every transaction before the next day
+		query = query.Where("trx.created_at < ?", req.EndDate.AddDate(0, 0, 1))
+	}
+	if req.Status != "" {
+		query = query.Where("trx.status = ?", req.Status)
+	}
+	if req.ProductId != -1 {
+		query = query.Where("log_produk.id_produk = ?", req.ProductId)
+	}
+	if req.KodeInvoice != "" {
+		query = query.Where("trx.kode_invoice LIKE ?", "%"+req.KodeInvoice+"%")
+	}
+	return query
+}
+
+func (d *detailTrxRepository) FetchByTrxIDs(ctx context.Context, trxIds []int) ([]*model.DetailTrx, error) {
+	data := []*model.DetailTrx{}
+	if len(trxIds) == 0 {
+		return data, nil
+	}
+
+	if err := d.Cfg.Database().WithContext(ctx).
+		Where("id_trx IN ?", trxIds).
+		Order("id").
+		Find(&data).Error; err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
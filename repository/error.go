@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+const MYSQL_ERROR_DUPLICATE_ENTRY = 1062
+
+func isDuplicateEntryError(err error) bool {
+	var mysqlError *mysql.MySQLError
+	return errors.As(err, &mysqlError) && mysqlError.Number == MYSQL_ERROR_DUPLICATE_ENTRY
+}
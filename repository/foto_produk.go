@@ -2,8 +2,12 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"marketplace-api/config"
 	"marketplace-api/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type fotoProdukRepository struct {
@@ -14,14 +18,231 @@ func NewFotoProdukRepository(cfg config.Config) model.FotoProdukRepository {
 	return &fotoProdukRepository{Cfg: cfg}
 }
 
+func (f *fotoProdukRepository) Create(ctx context.Context, produkId int, photoKeys []string) ([]*model.FotoProduk, error) {
+
+	transaction := f.Cfg.Database().WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			transaction.Rollback()
+		}
+	}()
+
+	if err := transaction.Error; err != nil {
+		return nil, err
+	}
+
+	existingFotoProdukList := []*model.FotoProduk{}
+	if err := transaction.
+		Where("id_produk = ?", produkId).
+		Find(&existingFotoProdukList).Error; err != nil {
+		transaction.Rollback()
+		return nil, err
+	}
+	nextPosition := 0
+	hasPrimary := false
+	for _, fotoProduk := range existingFotoProdukList {
+		if fotoProduk.Position >= nextPosition {
+			nextPosition = fotoProduk.Position + 1
+		}
+		hasPrimary = hasPrimary || fotoProduk.IsPrimary
+	}
+
+	fotoProdukList := newFotoProdukList(produkId, photoKeys, nextPosition, !hasPrimary)
+	if err := transaction.Create(&fotoProdukList).Error; err != nil {
+		transaction.Rollback()
+		return nil, err
+	}
+
+	return fotoProdukList, transaction.Commit().Error
+}
+
+func (f *fotoProdukRepository) FindByID(ctx context.Context, fotoProdukId int) (*model.FotoProduk, error) {
+	fotoProduk := new(model.FotoProduk)
+
+	if err := f.Cfg.Database().WithContext(ctx).
+		First(fotoProduk, fotoProdukId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, model.ErrFotoProdukNotFound
+		}
+		return nil, err
+	}
+	return fotoProduk, nil
+}
+
 func (f *fotoProdukRepository) FetchByProdukId(ctx context.Context, produkId int) ([]*model.FotoProduk, error) {
 	var data []*model.FotoProduk
 
 	if err := f.Cfg.Database().WithContext(ctx).
 		Where("id_produk = ?", produkId).
+		Order("position, id").
 		Find(&data).Error; err != nil {
 		return nil, err
 	}
 
 	return data, nil
 }
+
+func (f *fotoProdukRepository) FetchByProdukIds(ctx context.Context, produkIds []int) ([]*model.FotoProduk, error) {
+	data := []*model.FotoProduk{}
+	if len(produkIds) == 0 {
+		return data, nil
+	}
+
+	if err := f.Cfg.Database().WithContext(ctx).
+		Where("id_produk IN ?", produkIds).
+		Order("position, id").
+		Find(&data).Error; err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (f *fotoProdukRepository) FetchUrls(ctx context.Context) ([]string, error) {
+	urls := []string{}
+
+	if err := f.Cfg.Database().WithContext(ctx).
+		Model(&model.FotoProduk{}).
+		Pluck("url", &urls).Error; err != nil {
+		return nil, err
+	}
+
+	return urls, nil
+}
+
+func (f *fotoProdukRepository) Reorder(ctx context.Context, produkId int, fotoProdukIds []int) error {
+
+	transaction := f.Cfg.Database().WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			transaction.Rollback()
+		}
+	}()
+
+	if err := transaction.Error; err != nil {
+		return err
+	}
+
+	for position, fotoProdukId := range fotoProdukIds {
+		if err := transaction.
+			Model(&model.FotoProduk{}).
+			Where("id = ? AND id_produk = ?", fotoProdukId, produkId).
+			Update("position", position).Error; err != nil {
+			transaction.Rollback()
+			return err
+		}
+	}
+
+	return transaction.Commit().Error
+}
+
+func (f *fotoProdukRepository) SetPrimary(ctx context.Context, produkId int, fotoProdukId int) error {
+
+	transaction := f.Cfg.Database().WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			transaction.Rollback()
+		}
+	}()
+
+	if err := transaction.Error; err != nil {
+		return err
+	}
+
+	if err := transaction.
+		Model(&model.FotoProduk{}).
+		Where("id_produk = ? AND id <> ?", produkId, fotoProdukId).
+		Update("is_primary", false).Error; err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	if err := transaction.
+		Model(&model.FotoProduk{}).
+		Where("id = ? AND id_produk = ?", fotoProdukId, produkId).
+		Update("is_primary", true).Error; err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	return transaction.Commit().Error
+}
+
+func (f *fotoProdukRepository) Delete(ctx context.Context, fotoProdukId int) error {
+
+	transaction := f.Cfg.Database().WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			transaction.Rollback()
+		}
+	}()
+
+	if err := transaction.Error; err != nil {
+		return err
+	}
+
+	fotoProduk := new(model.FotoProduk)
+	if err := transaction.First(fotoProduk, fotoProdukId).Error; err != nil {
+		transaction.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.ErrFotoProdukNotFound
+		}
+		return err
+	}
+
+	// locking every photo of the product keeps a concurrent delete from removing the other last photo
+	fotoProdukList := []*model.FotoProduk{}
+	if err := transaction.
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id_produk = ?", fotoProduk.IdProduk).
+		Find(&fotoProdukList).Error; err != nil {
+		transaction.Rollback()
+		return err
+	}
+	if len(fotoProdukList) <= 1 {
+		transaction.Rollback()
+		return model.ErrFotoProdukRequired
+	}
+
+	if err := transaction.Delete(&model.FotoProduk{}, fotoProdukId).Error; err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	if fotoProduk.IsPrimary {
+		nextFotoProduk := new(model.FotoProduk)
+		err := transaction.
+			Where("id_produk = ?", fotoProduk.IdProduk).
+			Order("position, id").
+			First(nextFotoProduk).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			transaction.Rollback()
+			return err
+		}
+		if err == nil {
+			if err := transaction.
+				Model(nextFotoProduk).
+				Update("is_primary", true).Error; err != nil {
+				transaction.Rollback()
+				return err
+			}
+		}
+	}
+
+	return transaction.Commit().Error
+}
+
+// newFotoProdukList numbers the photos from firstPosition, the first one becomes primary when withPrimary is set
+func newFotoProdukList(produkId int, photoKeys []string, firstPosition int, withPrimary bool) []*model.FotoProduk {
+	fotoProdukList := []*model.FotoProduk{}
+	for i, photoKey := range photoKeys {
+		fotoProduk := new(model.FotoProduk)
+		fotoProduk.IdProduk = produkId
+		fotoProduk.Url = photoKey
+		fotoProduk.Position = firstPosition + i
+		fotoProduk.IsPrimary = withPrimary && i == 0
+		fotoProduk.HasRenditions = true
+		fotoProdukList = append(fotoProdukList, fotoProduk)
+	}
+	return fotoProdukList
+}
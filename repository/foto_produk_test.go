@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"marketplace-api/config/configtest"
+	"marketplace-api/model"
+	"sync"
+	"testing"
+)
+
+func TestDeleteFotoProduk(t *testing.T) {
+	ctx := context.Background()
+	cfg := configtest.NewConfig(t)
+	fotoProdukRepository := NewFotoProdukRepository(cfg)
+
+	fotoProdukList, err := fotoProdukRepository.Create(ctx, 1, []string{"produk/a.jpg", "produk/b.jpg", "produk/c.jpg"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fotoProdukRepository.Delete(ctx, fotoProdukList[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	next, err := fotoProdukRepository.FindByID(ctx, fotoProdukList[1].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !next.IsPrimary {
+		t.Error("the next photo did not become primary")
+	}
+
+	// both deletes see two photos when they are not serialized, leaving the product without a photo
+	var wg sync.WaitGroup
+	errs := make(chan error, 2)
+	for _, fotoProduk := range fotoProdukList[1:] {
+		wg.Add(1)
+		go func(fotoProdukId int) {
+			defer wg.Done()
+			errs <- fotoProdukRepository.Delete(ctx, fotoProdukId)
+		}(fotoProduk.ID)
+	}
+	wg.Wait()
+	close(errs)
+
+	deleted, refused := 0, 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			deleted++
+		case errors.Is(err, model.ErrFotoProdukRequired):
+			refused++
+		default:
+			t.Fatal(err)
+		}
+	}
+	if deleted != 1 || refused != 1 {
+		t.Errorf("%d deletes succeeded and %d were refused, want 1 and 1", deleted, refused)
+	}
+
+	remaining, err := fotoProdukRepository.FetchByProdukId(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(remaining) != 1 {
+		t.Fatalf("%d photos remain, want 1", len(remaining))
+	}
+	if !remaining[0].IsPrimary {
+		t.Error("the remaining photo is not primary")
+	}
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"marketplace-api/config"
+	"marketplace-api/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type idempotencyKeyRepository struct {
+	Cfg config.Config
+}
+
+func NewIdempotencyKeyRepository(cfg config.Config) model.IdempotencyKeyRepository {
+	return &idempotencyKeyRepository{Cfg: cfg}
+}
+
+// Create returns model.ErrIdempotencyKeyInProgress when another request has just claimed the same key
+func (i *idempotencyKeyRepository) Create(ctx context.Context, idempotencyKey *model.IdempotencyKey) (*model.IdempotencyKey, error) {
+	if err := i.Cfg.Database().WithContext(ctx).Create(&idempotencyKey).Error; err != nil {
+		if isDuplicateEntryError(err) {
+			return nil, model.ErrIdempotencyKeyInProgress
+		}
+		return nil, err
+	}
+	return idempotencyKey, nil
+}
+
+func (i *idempotencyKeyRepository) FindByUserIDAndKey(ctx context.Context, userId int, key string) (*model.IdempotencyKey, error) {
+	idempotencyKey := new(model.IdempotencyKey)
+
+	if err := i.Cfg.Database().
+		WithContext(ctx).
+		Where("id_user = ? AND idempotency_key = ?", userId, key).
+		First(idempotencyKey).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return idempotencyKey, nil
+}
+
+func (i *idempotencyKeyRepository) Reclaim(
+	ctx context.Context,
+	idempotencyKeyId int,
+	idempotencyKey *model.IdempotencyKey,
+	now time.Time,
+) (*model.IdempotencyKey, error) {
+	// the expiry is checked again in the update, so only one of two concurrent requests gets the key
+	res := whereIdempotencyKeyExpired(i.Cfg.Database().WithContext(ctx), now).
+		Model(&model.IdempotencyKey{}).
+		Where("id = ?", idempotencyKeyId).
+		Updates(map[string]interface{}{
+			"method":        idempotencyKey.Method,
+			"path":          idempotencyKey.Path,
+			"request_hash":  idempotencyKey.RequestHash,
+			"status_code":   0,
+			"response_body": nil,
+			"created_at":    now,
+			"updated_at":    now,
+		})
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, model.ErrIdempotencyKeyInProgress
+	}
+	idempotencyKey.ID = idempotencyKeyId
+	idempotencyKey.StatusCode = 0
+	idempotencyKey.ResponseBody = nil
+	idempotencyKey.CreatedAt = now
+	idempotencyKey.UpdatedAt = now
+	return idempotencyKey, nil
+}
+
+func (i *idempotencyKeyRepository) UpdateResponseByID(
+	ctx context.Context,
+	idempotencyKeyId int,
+	statusCode int,
+	responseBody []byte,
+) error {
+	if err := i.Cfg.Database().WithContext(ctx).
+		Model(&model.IdempotencyKey{ID: idempotencyKeyId}).
+		Updates(map[string]interface{}{
+			"status_code":   statusCode,
+			"response_body": responseBody,
+		}).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (i *idempotencyKeyRepository) Delete(ctx context.Context, idempotencyKeyId int) error {
+	res := i.Cfg.Database().WithContext(ctx).
+		Delete(&model.IdempotencyKey{}, idempotencyKeyId)
+	if res.Error != nil {
+		return res.Error
+	}
+	return nil
+}
+
+func (i *idempotencyKeyRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	res := whereIdempotencyKeyExpired(i.Cfg.Database().WithContext(ctx), now).
+		Delete(&model.IdempotencyKey{})
+	if res.Error != nil {
+		return 0, res.Error
+	}
+	return res.RowsAffected, nil
+}
+
+// whereIdempotencyKeyExpired matches the keys for which model.IdempotencyKey.IsExpired is true
+func whereIdempotencyKeyExpired(db *gorm.DB, now time.Time) *gorm.DB {
+	return db.Where(
+		"((status_code = 0 AND updated_at < ?) OR (status_code <> 0 AND updated_at < ?))",
+		now.Add(-model.IDEMPOTENCY_KEY_LOCK_TIMEOUT),
+		now.Add(-model.IDEMPOTENCY_KEY_TTL),
+	)
+}
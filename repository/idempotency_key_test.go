@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"marketplace-api/config/configtest"
+	"marketplace-api/model"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIdempotencyKeyDeleteExpired(t *testing.T) {
+	cfg := configtest.NewConfig(t)
+	db := cfg.Database()
+	now := time.Now()
+	keys := []*model.IdempotencyKey{
+		{Key: "fresh reservation", UpdatedAt: now},
+		{Key: "abandoned reservation", UpdatedAt: now.Add(-model.IDEMPOTENCY_KEY_LOCK_TIMEOUT - time.Minute)},
+		{Key: "fresh response", StatusCode: 200, UpdatedAt: now.Add(-model.IDEMPOTENCY_KEY_LOCK_TIMEOUT - time.Minute)},
+		{Key: "expired response", StatusCode: 200, UpdatedAt: now.Add(-model.IDEMPOTENCY_KEY_TTL - time.Minute)},
+	}
+	for _, key := range keys {
+		key.IdUser = 1
+		if err := db.Create(key).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deleted, err := NewIdempotencyKeyRepository(cfg).DeleteExpired(context.Background(), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 2 {
+		t.Errorf("deleted %d keys, want 2", deleted)
+	}
+	var remaining []string
+	if err := db.Model(&model.IdempotencyKey{}).Order("id").Pluck("idempotency_key", &remaining).Error; err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(remaining, ",") != "fresh reservation,fresh response" {
+		t.Errorf("remaining keys: %v", remaining)
+	}
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"marketplace-api/config"
+	"marketplace-api/model"
+	"time"
+)
+
+type invoiceCounterRepository struct {
+	Cfg config.Config
+}
+
+func NewInvoiceCounterRepository(cfg config.Config) model.InvoiceCounterRepository {
+	return &invoiceCounterRepository{Cfg: cfg}
+}
+
+// Next increments the counter of the date and returns the new number,
+// the row stays locked by the upsert until commit so concurrent callers never get the same number
+func (i *invoiceCounterRepository) Next(ctx context.Context, tanggal string) (int, error) {
+
+	transaction := i.Cfg.Database().WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			transaction.Rollback()
+		}
+	}()
+
+	if err := transaction.Error; err != nil {
+		return 0, err
+	}
+
+	if err := transaction.Exec(
+		"INSERT INTO invoice_counter (tanggal, nomor, updated_at) VALUES (?, 1, ?) "+
+			"ON DUPLICATE KEY UPDATE nomor = nomor + 1, updated_at = VALUES(updated_at)",
+		tanggal, time.Now(),
+	).Error; err != nil {
+		transaction.Rollback()
+		return 0, err
+	}
+
+	invoiceCounter := new(model.InvoiceCounter)
+	if err := transaction.First(invoiceCounter, "tanggal = ?", tanggal).Error; err != nil {
+		transaction.Rollback()
+		return 0, err
+	}
+
+	return invoiceCounter.Nomor, transaction.Commit().Error
+}
@@ -30,3 +30,19 @@ func (l *logProdukRepository) FindByID(ctx context.Context, logProdukId int) (*m
 	}
 	return logProduk, nil
 }
+
+func (l *logProdukRepository) FindByIDs(ctx context.Context, logProdukIds []int) ([]*model.LogProduk, error) {
+	data := []*model.LogProduk{}
+	if len(logProdukIds) == 0 {
+		return data, nil
+	}
+
+	if err := l.Cfg.Database().WithContext(ctx).
+		Where("id IN ?", logProdukIds).
+		Order("id").
+		Find(&data).Error; err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
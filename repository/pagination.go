@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"marketplace-api/model"
+
+	"gorm.io/gorm"
+)
+
+// paginateByID counts the rows of query and finds one page of them ordered by id into dest,
+// the page starts after req.Cursor when it is set, otherwise at the offset of req.Page
+func paginateByID(query *gorm.DB, req model.PaginationRequest, dest interface{}) (int64, error) {
+	var total int64
+
+	query = query.Session(&gorm.Session{})
+	if err := query.Count(&total).Error; err != nil {
+		return 0, err
+	}
+
+	pageQuery := query.Order("id")
+	if req.Cursor != nil {
+		if req.Cursor.Sort != model.CURSOR_SORT_ID {
+			return 0, model.ErrInvalidCursor
+		}
+		pageQuery = pageQuery.Where("id > ?", req.Cursor.ID)
+	}
+	if err := pageQuery.
+		Limit(req.FetchLimit()).Offset(req.Offset()).
+		Find(dest).Error; err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// cursorPageResult returns the number of rows that belong to the page and the cursor to the next page,
+// cursors holds the cursor pointing after every fetched row
+func cursorPageResult(req model.PaginationRequest, cursors []model.Cursor, total int64) (int, *model.PageResult) {
+	pageResult := &model.PageResult{Total: total}
+	fetched := len(cursors)
+	if !req.HasMoreRows(fetched, total) || fetched == 0 {
+		return fetched, pageResult
+	}
+	if fetched > req.Limit {
+		fetched = req.Limit
+	}
+	pageResult.NextCursor = cursors[fetched-1].Encode()
+	return fetched, pageResult
+}
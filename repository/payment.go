@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"marketplace-api/config"
+	"marketplace-api/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type paymentRepository struct {
+	Cfg config.Config
+}
+
+func NewPaymentRepository(cfg config.Config) model.PaymentRepository {
+	return &paymentRepository{Cfg: cfg}
+}
+
+func (p *paymentRepository) Create(ctx context.Context, payment *model.Payment) (*model.Payment, error) {
+	if err := p.Cfg.Database().WithContext(ctx).Create(&payment).Error; err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+func (p *paymentRepository) FindByTrxID(ctx context.Context, trxId int) (*model.Payment, error) {
+	payment := new(model.Payment)
+
+	if err := p.Cfg.Database().WithContext(ctx).
+		First(payment, "id_trx = ?", trxId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return payment, nil
+}
+
+func (p *paymentRepository) FindByReferensi(ctx context.Context, referensi string) (*model.Payment, error) {
+	payment := new(model.Payment)
+
+	if err := p.Cfg.Database().WithContext(ctx).
+		First(payment, "referensi = ?", referensi).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("payment not found")
+		}
+		return nil, err
+	}
+	return payment, nil
+}
+
+func (p *paymentRepository) Settle(ctx context.Context, paymentId int, history *model.TrxStatusHistory) error {
+
+	transaction := p.Cfg.Database().WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			transaction.Rollback()
+		}
+	}()
+
+	if err := transaction.Error; err != nil {
+		return err
+	}
+
+	payment := new(model.Payment)
+	if err := transaction.First(payment, paymentId).Error; err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	settledAt := time.Now()
+	res := transaction.Model(&model.Payment{}).
+		Where("id = ? AND status IN ?", paymentId, []model.PaymentStatus{model.PAYMENT_STATUS_PENDING, model.PAYMENT_STATUS_EXPIRED}).
+		Updates(map[string]interface{}{"status": model.PAYMENT_STATUS_SETTLED, "settled_at": settledAt})
+	if res.Error != nil {
+		transaction.Rollback()
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		transaction.Rollback()
+		return errors.New("payment status has been changed, please try again")
+	}
+
+	// a payment settled after the trx left pending payment (e.g. it was cancelled or expired) is kept for a refund
+	trx := new(model.Trx)
+	if err := transaction.First(trx, payment.IdTrx).Error; err != nil {
+		transaction.Rollback()
+		return err
+	}
+	if trx.Status == model.TRX_STATUS_PENDING_PAYMENT {
+		if err := updateTrxStatus(transaction, trx.ID, trx.Status, history); err != nil {
+			transaction.Rollback()
+			return err
+		}
+	}
+
+	return transaction.Commit().Error
+}
+
+func (p *paymentRepository) UpdateStatus(
+	ctx context.Context,
+	paymentId int,
+	currentStatus model.PaymentStatus,
+	status model.PaymentStatus,
+) error {
+	res := p.Cfg.Database().WithContext(ctx).Model(&model.Payment{}).
+		Where("id = ? AND status = ?", paymentId, currentStatus).
+		Update("status", status)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("payment status has been changed, please try again")
+	}
+	return nil
+}
+
+func (p *paymentRepository) FetchByTrxIDs(ctx context.Context, trxIds []int) ([]*model.Payment, error) {
+	data := []*model.Payment{}
+	if len(trxIds) == 0 {
+		return data, nil
+	}
+
+	if err := p.Cfg.Database().WithContext(ctx).
+		Where("id_trx IN ?", trxIds).
+		Order("id").
+		Find(&data).Error; err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"marketplace-api/model"
+	"testing"
+)
+
+func hmacHex(secret string, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestFakePaymentProviderParseNotification(t *testing.T) {
+	const body = `{"referensi":"FAKE-1","status":"settled","jumlah":10000}`
+
+	tests := []struct {
+		name      string
+		secret    string
+		signature string
+		body      string
+		wantErr   error
+	}{
+		{name: "valid signature", secret: "secret", signature: hmacHex("secret", body), body: body},
+		{name: "signed with another secret", secret: "secret", signature: hmacHex("other", body), body: body, wantErr: model.ErrInvalidPaymentSignature},
+		{name: "body changed after signing", secret: "secret", signature: hmacHex("secret", body), body: `{"referensi":"FAKE-1","status":"settled","jumlah":1}`, wantErr: model.ErrInvalidPaymentSignature},
+		{name: "missing signature", secret: "secret", signature: "", body: body, wantErr: model.ErrInvalidPaymentSignature},
+		{name: "empty secret", secret: "", signature: hmacHex("", body), body: body, wantErr: model.ErrInvalidPaymentSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := NewFakePaymentProvider(model.PAYMENT_METHOD_BANK_TRANSFER, false, tt.secret)
+			paymentNotification, err := provider.ParseNotification(tt.signature, []byte(tt.body))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			want := model.PaymentNotification{Referensi: "FAKE-1", Status: model.PAYMENT_STATUS_SETTLED, Jumlah: 10000}
+			if *paymentNotification != want {
+				t.Errorf("got %+v, want %+v", *paymentNotification, want)
+			}
+		})
+	}
+}
@@ -20,7 +20,7 @@ func NewProdukRepository(cfg config.Config) model.ProdukRepository {
 func (p *produkRepository) CreateProdukAndFotoProduk(
 	ctx context.Context,
 	produk *model.Produk,
-	photoUrls []string,
+	photoKeys []string,
 ) (*model.Produk, []*model.FotoProduk, error) {
 
 	transaction := p.Cfg.Database().WithContext(ctx).Begin()
@@ -39,14 +39,7 @@ func (p *produkRepository) CreateProdukAndFotoProduk(
 		return nil, nil, err
 	}
 
-	fotoProdukList := []*model.FotoProduk{}
-	for _, photoUrl := range photoUrls {
-		fotoProduk := new(model.FotoProduk)
-		fotoProduk.IdProduk = produk.ID
-		fotoProduk.Url = photoUrl
-		fotoProdukList = append(fotoProdukList, fotoProduk)
-	}
-
+	fotoProdukList := newFotoProdukList(produk.ID, photoKeys, 0, true)
 	if err := transaction.Create(&fotoProdukList).Error; err != nil {
 		transaction.Rollback()
 		return nil, nil, err
@@ -55,28 +48,128 @@ func (p *produkRepository) CreateProdukAndFotoProduk(
 	return produk, fotoProdukList, transaction.Commit().Error
 }
 
-func (p *produkRepository) Fetch(ctx context.Context, req *model.ProdukFetchRequest) ([]*model.Produk, error) {
-	var data []*model.Produk
+func (p *produkRepository) Fetch(ctx context.Context, req *model.ProdukFetchRequest) ([]*model.Produk, *model.PageResult, error) {
+	sort, ok := produkSorts[req.Sort]
+	if !ok {
+		return nil, nil, errors.New("invalid sort")
+	}
+
+	query := p.filteredQuery(ctx, req)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, nil, err
+	}
+
+	pageQuery := query.
+		Select("produk.*, " + sort.expression + " AS sort_key").
+		Order(sort.orderBy())
+	if req.Cursor != nil {
+		if req.Cursor.Sort != req.Sort {
+			return nil, nil, model.ErrInvalidCursor
+		}
+		condition, args, err := sort.after(req.Cursor)
+		if err != nil {
+			return nil, nil, err
+		}
+		pageQuery = pageQuery.Where(condition, args...)
+	}
 
-	offset := (req.Page - 1) * req.Limit
-	query := p.Cfg.Database().WithContext(ctx).Where("nama_produk LIKE ?", "%"+req.NamaProduk+"%")
+	var rows []*produkWithSortKey
+	if err := pageQuery.
+		Limit(req.FetchLimit()).Offset(req.Offset()).
+		Find(&rows).Error; err != nil {
+		return nil, nil, err
+	}
+
+	data := []*model.Produk{}
+	cursors := []model.Cursor{}
+	for _, row := range rows {
+		produk := row.Produk
+		data = append(data, &produk)
+		cursors = append(cursors, model.Cursor{Sort: req.Sort, Key: row.SortKey, ID: produk.ID})
+	}
+	fetched, pageResult := cursorPageResult(req.PaginationRequest, cursors, total)
+	return data[:fetched], pageResult, nil
+}
+
+// filteredQuery selects the products matching the filters of req, the listing and its facets share it
+func (p *produkRepository) filteredQuery(ctx context.Context, req *model.ProdukFetchRequest) *gorm.DB {
+	query := p.Cfg.Database().WithContext(ctx).
+		Model(&model.Produk{}).
+		Where("produk.nama_produk LIKE ?", "%"+req.NamaProduk+"%")
 	if req.CategoryId != -1 {
-		query = query.Where("id_category = ?", req.CategoryId)
+		query = query.Where("produk.id_category = ?", req.CategoryId)
 	}
 	if req.TokoId != -1 {
-		query = query.Where("id_toko = ?", req.TokoId)
+		query = query.Where("produk.id_toko = ?", req.TokoId)
 	}
 	if req.MinHarga != -1 {
-		query = query.Where("harga_konsumen >= ?", req.MinHarga)
+		query = query.Where("produk.harga_konsumen >= ?", req.MinHarga)
 	}
 	if req.MaxHarga != -1 {
-		query = query.Where("harga_konsumen <= ?", req.MaxHarga)
+		query = query.Where("produk.harga_konsumen <= ?", req.MaxHarga)
 	}
-	if err := query.Limit(req.Limit).Offset(offset).Find(&data).Error; err != nil {
+	return query.Session(&gorm.Session{})
+}
+
+func (p *produkRepository) FetchFacets(ctx context.Context, req *model.ProdukFetchRequest) (*model.ProdukFacets, error) {
+	query := p.filteredQuery(ctx, req)
+	facets := new(model.ProdukFacets)
+
+	facets.Category = []*model.ProdukCategoryFacet{}
+	if err := query.
+		Select("produk.id_category, COALESCE(category.nama_category, '') AS nama_category, COUNT(*) AS jumlah").
+		Joins("LEFT JOIN category ON category.id = produk.id_category").
+		Group("produk.id_category, category.nama_category").
+		Order("jumlah DESC, produk.id_category").
+		Find(&facets.Category).Error; err != nil {
 		return nil, err
 	}
 
-	return data, nil
+	facets.Toko = []*model.ProdukTokoFacet{}
+	if err := query.
+		Select("produk.id_toko, COALESCE(toko.nama_toko, '') AS nama_toko, COUNT(*) AS jumlah").
+		Joins("LEFT JOIN toko ON toko.id = produk.id_toko").
+		Group("produk.id_toko, toko.nama_toko").
+		Order("jumlah DESC, produk.id_toko").
+		Find(&facets.Toko).Error; err != nil {
+		return nil, err
+	}
+
+	// bucket i holds the prices below bound i, the last bucket has no upper bound
+	bucketExpression := "CASE"
+	bucketArgs := []interface{}{}
+	for i, bound := range req.HargaBuckets {
+		bucketExpression += " WHEN produk.harga_konsumen < ? THEN ?"
+		bucketArgs = append(bucketArgs, bound, i)
+	}
+	bucketExpression += " ELSE ? END"
+	bucketArgs = append(bucketArgs, len(req.HargaBuckets))
+
+	bucketCounts := []struct {
+		Bucket int   `gorm:"column:bucket"`
+		Jumlah int64 `gorm:"column:jumlah"`
+	}{}
+	if err := query.
+		Select(bucketExpression+" AS bucket, COUNT(*) AS jumlah", bucketArgs...).
+		Group("bucket").
+		Find(&bucketCounts).Error; err != nil {
+		return nil, err
+	}
+	facets.Harga = model.NewProdukHargaFacets(req.HargaBuckets)
+	for _, bucketCount := range bucketCounts {
+		facets.Harga[bucketCount.Bucket].Jumlah = bucketCount.Jumlah
+	}
+
+	facets.Stok = new(model.ProdukStokFacet)
+	if err := query.
+		Select("COALESCE(SUM(produk.stok > 0), 0) AS tersedia, COALESCE(SUM(produk.stok <= 0), 0) AS habis").
+		Find(facets.Stok).Error; err != nil {
+		return nil, err
+	}
+
+	return facets, nil
 }
 
 func (p *produkRepository) FindByID(ctx context.Context, produkId int) (*model.Produk, error) {
@@ -93,11 +186,71 @@ func (p *produkRepository) FindByID(ctx context.Context, produkId int) (*model.P
 	return produk, nil
 }
 
+func (p *produkRepository) FindByIDWithDeleted(ctx context.Context, produkId int) (*model.Produk, error) {
+	produk := new(model.Produk)
+
+	if err := p.Cfg.Database().
+		WithContext(ctx).
+		Unscoped().
+		First(produk, produkId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("produk not found")
+		}
+		return nil, err
+	}
+	return produk, nil
+}
+
+func (p *produkRepository) FindByIDs(ctx context.Context, produkIds []int) ([]*model.Produk, error) {
+	data := []*model.Produk{}
+	if len(produkIds) == 0 {
+		return data, nil
+	}
+
+	if err := p.Cfg.Database().WithContext(ctx).
+		Where("id IN ?", produkIds).
+		Order("id").
+		Find(&data).Error; err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (p *produkRepository) FetchSearchDocuments(ctx context.Context) ([]*model.ProdukSearchDocument, error) {
+	return p.fetchSearchDocuments(p.Cfg.Database().WithContext(ctx))
+}
+
+func (p *produkRepository) FetchSearchDocumentsByTokoID(ctx context.Context, tokoId int) ([]*model.ProdukSearchDocument, error) {
+	return p.fetchSearchDocuments(p.Cfg.Database().WithContext(ctx).Where("produk.id_toko = ?", tokoId))
+}
+
+func (p *produkRepository) FetchSearchDocumentsByCategoryID(ctx context.Context, categoryId int) ([]*model.ProdukSearchDocument, error) {
+	return p.fetchSearchDocuments(p.Cfg.Database().WithContext(ctx).Where("produk.id_category = ?", categoryId))
+}
+
+func (p *produkRepository) fetchSearchDocuments(db *gorm.DB) ([]*model.ProdukSearchDocument, error) {
+	data := []*model.ProdukSearchDocument{}
+
+	if err := db.
+		Model(&model.Produk{}).
+		Select("produk.id, produk.nama_produk, produk.deskripsi, " +
+			"COALESCE(category.nama_category, '') AS nama_category, COALESCE(toko.nama_toko, '') AS nama_toko").
+		Joins("LEFT JOIN category ON category.id = produk.id_category").
+		Joins("LEFT JOIN toko ON toko.id = produk.id_toko").
+		Order("produk.id").
+		Find(&data).Error; err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
 func (p *produkRepository) UpdateProdukAndFotoProduk(
 	ctx context.Context,
 	produkId int,
 	produk *model.Produk,
-	fotoProdukList []*model.FotoProduk,
+	photoKeys []string,
 ) (*model.Produk, []*model.FotoProduk, error) {
 
 	transaction := p.Cfg.Database().WithContext(ctx).Begin()
@@ -112,17 +265,43 @@ func (p *produkRepository) UpdateProdukAndFotoProduk(
 	}
 
 	if err := transaction.
-		Model(&model.Produk{ID: produkId}).Updates(produk).Find(produk).Error; err != nil {
+		Model(&model.Produk{ID: produkId}).Updates(produk).Error; err != nil {
+		transaction.Rollback()
+		return nil, nil, err
+	}
+
+	// Updates skips zero values, the threshold is written on its own so it can be cleared
+	if err := transaction.
+		Model(&model.Produk{ID: produkId}).
+		Update("min_kuantitas_reseller", produk.MinKuantitasReseller).Error; err != nil {
 		transaction.Rollback()
 		return nil, nil, err
 	}
 
+	if err := transaction.First(produk, produkId).Error; err != nil {
+		transaction.Rollback()
+		return nil, nil, err
+	}
+
+	fotoProdukList := []*model.FotoProduk{}
+	if len(photoKeys) == 0 {
+		if err := transaction.
+			Where("id_produk = ?", produkId).
+			Order("position, id").
+			Find(&fotoProdukList).Error; err != nil {
+			transaction.Rollback()
+			return nil, nil, err
+		}
+		return produk, fotoProdukList, transaction.Commit().Error
+	}
+
 	res := transaction.Delete(&model.FotoProduk{}, "id_produk = ?", produkId)
 	if res.Error != nil {
 		transaction.Rollback()
 		return nil, nil, res.Error
 	}
 
+	fotoProdukList = newFotoProdukList(produkId, photoKeys, 0, true)
 	if err := transaction.Create(&fotoProdukList).Error; err != nil {
 		transaction.Rollback()
 		return nil, nil, err
@@ -131,7 +310,8 @@ func (p *produkRepository) UpdateProdukAndFotoProduk(
 	return produk, fotoProdukList, transaction.Commit().Error
 }
 
-func (p *produkRepository) DeleteProdukAndFotoProduk(ctx context.Context, produkId int) error {
+// Delete keeps the photos and variants of the product, they are needed again when it is restored
+func (p *produkRepository) Delete(ctx context.Context, produkId int) error {
 
 	transaction := p.Cfg.Database().WithContext(ctx).Begin()
 	defer func() {
@@ -144,7 +324,8 @@ func (p *produkRepository) DeleteProdukAndFotoProduk(ctx context.Context, produk
 		return err
 	}
 
-	res := transaction.Delete(&model.FotoProduk{}, "id_produk = ?", produkId)
+	// the foreign key used to cascade to cart items, an archived product cannot be bought
+	res := transaction.Delete(&model.CartItem{}, "id_produk = ?", produkId)
 	if res.Error != nil {
 		transaction.Rollback()
 		return res.Error
@@ -158,3 +339,17 @@ func (p *produkRepository) DeleteProdukAndFotoProduk(ctx context.Context, produk
 
 	return transaction.Commit().Error
 }
+
+func (p *produkRepository) Restore(ctx context.Context, produkId int) error {
+	produk, err := p.FindByIDWithDeleted(ctx, produkId)
+	if err != nil {
+		return err
+	}
+	if !produk.DeletedAt.Valid {
+		return errors.New("produk is not deleted")
+	}
+
+	return p.Cfg.Database().WithContext(ctx).Unscoped().
+		Model(&model.Produk{ID: produkId}).
+		Update("deleted_at", nil).Error
+}
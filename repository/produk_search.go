@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"marketplace-api/config"
+	"marketplace-api/helper"
+	"marketplace-api/model"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// the FULLTEXT indexes use the ngram parser, a query is split into bigrams too, so a typo
+// only loses the bigrams around it and the product still ranks on the remaining ones
+const produkSearchScore = "? * MATCH(produk.nama_produk) AGAINST (?) + " +
+	"? * MATCH(produk.deskripsi) AGAINST (?) + " +
+	"? * COALESCE(MATCH(category.nama_category) AGAINST (?), 0) + " +
+	"? * COALESCE(MATCH(toko.nama_toko) AGAINST (?), 0)"
+
+const produkSearchMatch = "MATCH(produk.nama_produk) AGAINST (?) OR " +
+	"MATCH(produk.deskripsi) AGAINST (?) OR " +
+	"MATCH(category.nama_category) AGAINST (?) OR " +
+	"MATCH(toko.nama_toko) AGAINST (?)"
+
+type produkSearchIndex struct {
+	Cfg config.Config
+}
+
+// NewProdukSearchIndex searches the FULLTEXT indexes of produk, category and toko,
+// MySQL keeps them up to date so Index and Remove do nothing
+func NewProdukSearchIndex(cfg config.Config) model.ProdukSearchIndex {
+	return &produkSearchIndex{Cfg: cfg}
+}
+
+func (p *produkSearchIndex) Index(ctx context.Context, document *model.ProdukSearchDocument) error {
+	return nil
+}
+
+func (p *produkSearchIndex) Remove(ctx context.Context, produkId int) error {
+	return nil
+}
+
+// Search only counts and returns the products scoring at least PRODUK_SEARCH_MIN_SCORE_RATIO of the best one
+func (p *produkSearchIndex) Search(ctx context.Context, req *model.ProdukSearchRequest) ([]*model.ProdukSearchHit, int64, error) {
+	terms := helper.SearchTerms(req.Query)
+	if len(terms) == 0 {
+		return nil, 0, model.ErrEmptySearchQuery
+	}
+	q := strings.Join(terms, " ")
+
+	db := p.Cfg.Database().WithContext(ctx)
+	scored := db.
+		Model(&model.Produk{}).
+		Select("produk.id, "+produkSearchScore+" AS score",
+			model.PRODUK_SEARCH_WEIGHT_NAMA_PRODUK, q,
+			model.PRODUK_SEARCH_WEIGHT_DESKRIPSI, q,
+			model.PRODUK_SEARCH_WEIGHT_NAMA_CATEGORY, q,
+			model.PRODUK_SEARCH_WEIGHT_NAMA_TOKO, q,
+		).
+		Joins("LEFT JOIN category ON category.id = produk.id_category").
+		Joins("LEFT JOIN toko ON toko.id = produk.id_toko").
+		Where(produkSearchMatch, q, q, q, q)
+
+	var maxScore float64
+	if err := db.Table("(?) AS scored", scored).
+		Select("COALESCE(MAX(score), 0)").
+		Scan(&maxScore).Error; err != nil {
+		return nil, 0, err
+	}
+	query := db.Table("(?) AS scored", scored).
+		Where("score >= ?", maxScore*model.PRODUK_SEARCH_MIN_SCORE_RATIO).
+		Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	hits := []*model.ProdukSearchHit{}
+	if err := query.
+		Select("id, score").
+		Order("score DESC, id ASC").
+		Limit(req.Limit).Offset(req.Offset()).
+		Find(&hits).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return hits, total, nil
+}
@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"marketplace-api/helper"
+	"marketplace-api/model"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// relevance of a query term matching an indexed term only by prefix or within the typo distance
+const (
+	memorySearchPrefixFactor = 0.8
+	memorySearchTypoFactor   = 0.5
+)
+
+type memoryProdukSearchIndex struct {
+	mu sync.RWMutex
+	// postings maps every indexed term to the weight of the term in each product
+	postings map[string]map[int]float64
+	// terms keeps the terms of every product so its postings can be removed
+	terms map[int][]string
+}
+
+// NewMemoryProdukSearchIndex keeps an inverted index in memory, it only knows the documents given to Index
+func NewMemoryProdukSearchIndex() model.ProdukSearchIndex {
+	return &memoryProdukSearchIndex{
+		postings: map[string]map[int]float64{},
+		terms:    map[int][]string{},
+	}
+}
+
+func (m *memoryProdukSearchIndex) Index(ctx context.Context, document *model.ProdukSearchDocument) error {
+	weights := map[string]float64{}
+	fields := []struct {
+		text   string
+		weight float64
+	}{
+		{document.NamaProduk, model.PRODUK_SEARCH_WEIGHT_NAMA_PRODUK},
+		{document.Deskripsi, model.PRODUK_SEARCH_WEIGHT_DESKRIPSI},
+		{document.NamaCategory, model.PRODUK_SEARCH_WEIGHT_NAMA_CATEGORY},
+		{document.NamaToko, model.PRODUK_SEARCH_WEIGHT_NAMA_TOKO},
+	}
+	for _, field := range fields {
+		for _, term := range helper.SearchTerms(field.text) {
+			weights[term] += field.weight
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.remove(document.ID)
+	terms := []string{}
+	for term, weight := range weights {
+		if _, ok := m.postings[term]; !ok {
+			m.postings[term] = map[int]float64{}
+		}
+		m.postings[term][document.ID] = weight
+		terms = append(terms, term)
+	}
+	m.terms[document.ID] = terms
+	return nil
+}
+
+func (m *memoryProdukSearchIndex) Remove(ctx context.Context, produkId int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.remove(produkId)
+	return nil
+}
+
+func (m *memoryProdukSearchIndex) remove(produkId int) {
+	for _, term := range m.terms[produkId] {
+		delete(m.postings[term], produkId)
+		if len(m.postings[term]) == 0 {
+			delete(m.postings, term)
+		}
+	}
+	delete(m.terms, produkId)
+}
+
+func (m *memoryProdukSearchIndex) Search(ctx context.Context, req *model.ProdukSearchRequest) ([]*model.ProdukSearchHit, int64, error) {
+	queryTerms := helper.SearchTerms(req.Query)
+	if len(queryTerms) == 0 {
+		return nil, 0, model.ErrEmptySearchQuery
+	}
+
+	m.mu.RLock()
+	scores := map[int]float64{}
+	for _, queryTerm := range queryTerms {
+		// a product scores once per query term, with its best matching indexed term
+		best := map[int]float64{}
+		for term, postings := range m.postings {
+			factor := matchFactor(queryTerm, term)
+			if factor == 0 {
+				continue
+			}
+			for produkId, weight := range postings {
+				if score := factor * weight; score > best[produkId] {
+					best[produkId] = score
+				}
+			}
+		}
+		for produkId, score := range best {
+			scores[produkId] += score
+		}
+	}
+	m.mu.RUnlock()
+
+	hits := []*model.ProdukSearchHit{}
+	for produkId, score := range scores {
+		hits = append(hits, &model.ProdukSearchHit{ID: produkId, Score: score})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID < hits[j].ID
+	})
+
+	total := int64(len(hits))
+	if req.Limit < 1 {
+		return hits, total, nil
+	}
+	start := req.Offset()
+	if start > len(hits) {
+		start = len(hits)
+	}
+	end := start + req.Limit
+	if end > len(hits) {
+		end = len(hits)
+	}
+	return hits[start:end], total, nil
+}
+
+func matchFactor(queryTerm string, term string) float64 {
+	switch {
+	case term == queryTerm:
+		return 1
+	case len(queryTerm) >= 3 && strings.HasPrefix(term, queryTerm):
+		return memorySearchPrefixFactor
+	}
+	maxTypos := helper.MaxTypos(queryTerm)
+	if maxTypos == 0 {
+		return 0
+	}
+	diff := len(term) - len(queryTerm)
+	if diff > maxTypos || -diff > maxTypos {
+		return 0
+	}
+	if helper.Levenshtein(queryTerm, term) <= maxTypos {
+		return memorySearchTypoFactor
+	}
+	return 0
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"marketplace-api/model"
+	"testing"
+)
+
+func searchIds(t *testing.T, index model.ProdukSearchIndex, req *model.ProdukSearchRequest) ([]int, int64) {
+	t.Helper()
+	hits, total, err := index.Search(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := []int{}
+	for _, hit := range hits {
+		ids = append(ids, hit.ID)
+	}
+	return ids, total
+}
+
+func newTestMemoryProdukSearchIndex(t *testing.T, documents ...*model.ProdukSearchDocument) model.ProdukSearchIndex {
+	t.Helper()
+	index := NewMemoryProdukSearchIndex()
+	for _, document := range documents {
+		if err := index.Index(context.Background(), document); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return index
+}
+
+func TestMemoryProdukSearchIndexSearch(t *testing.T) {
+	index := newTestMemoryProdukSearchIndex(t,
+		&model.ProdukSearchDocument{ID: 1, NamaProduk: "Sepatu Lari", Deskripsi: "ringan", NamaCategory: "Olahraga", NamaToko: "Toko Budi"},
+		&model.ProdukSearchDocument{ID: 2, NamaProduk: "Kaos Polos", Deskripsi: "cocok untuk lari pagi", NamaCategory: "Pakaian", NamaToko: "Toko Ani"},
+		&model.ProdukSearchDocument{ID: 3, NamaProduk: "Botol Minum", Deskripsi: "", NamaCategory: "Olahraga", NamaToko: "Sepatu Store"},
+		&model.ProdukSearchDocument{ID: 4, NamaProduk: "Tas", Deskripsi: "", NamaCategory: "Aksesoris", NamaToko: "Toko Budi"},
+	)
+
+	tests := []struct {
+		name  string
+		query string
+		want  []int
+	}{
+		{name: "nama produk outranks nama toko", query: "sepatu", want: []int{1, 3}},
+		{name: "nama produk outranks deskripsi", query: "lari", want: []int{1, 2}},
+		{name: "category", query: "olahraga", want: []int{1, 3}},
+		{name: "toko", query: "budi", want: []int{1, 4}},
+		{name: "case and punctuation ignored", query: "SEPATU-lari!", want: []int{1, 3, 2}},
+		{name: "prefix", query: "pak", want: []int{2}},
+		{name: "typo", query: "sepatv", want: []int{1, 3}},
+		{name: "short terms must match exactly", query: "ta", want: []int{}},
+		{name: "short term", query: "tas", want: []int{4}},
+		{name: "no match", query: "laptop", want: []int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids, total := searchIds(t, index, &model.ProdukSearchRequest{Query: tt.query})
+			if fmt.Sprint(ids) != fmt.Sprint(tt.want) {
+				t.Errorf("got %v, want %v", ids, tt.want)
+			}
+			if total != int64(len(tt.want)) {
+				t.Errorf("got total %d, want %d", total, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestMemoryProdukSearchIndexEmptyQuery(t *testing.T) {
+	index := newTestMemoryProdukSearchIndex(t)
+	for _, query := range []string{"", "  ", "!?"} {
+		if _, _, err := index.Search(context.Background(), &model.ProdukSearchRequest{Query: query}); !errors.Is(err, model.ErrEmptySearchQuery) {
+			t.Errorf("query %q: got error %v", query, err)
+		}
+	}
+}
+
+func TestMemoryProdukSearchIndexPagination(t *testing.T) {
+	index := NewMemoryProdukSearchIndex()
+	for id := 1; id <= 5; id++ {
+		if err := index.Index(context.Background(), &model.ProdukSearchDocument{ID: id, NamaProduk: "kopi"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		page  int
+		limit int
+		want  []int
+	}{
+		{page: 1, limit: 2, want: []int{1, 2}},
+		{page: 3, limit: 2, want: []int{5}},
+		{page: 4, limit: 2, want: []int{}},
+		{page: 1, limit: 0, want: []int{1, 2, 3, 4, 5}},
+	}
+	for _, tt := range tests {
+		req := &model.ProdukSearchRequest{Query: "kopi", PaginationRequest: model.PaginationRequest{Page: tt.page, Limit: tt.limit}}
+		ids, total := searchIds(t, index, req)
+		if fmt.Sprint(ids) != fmt.Sprint(tt.want) {
+			t.Errorf("page %d limit %d: got %v, want %v", tt.page, tt.limit, ids, tt.want)
+		}
+		if total != 5 {
+			t.Errorf("page %d limit %d: got total %d, want 5", tt.page, tt.limit, total)
+		}
+	}
+}
+
+func TestMemoryProdukSearchIndexReplaceAndRemove(t *testing.T) {
+	index := newTestMemoryProdukSearchIndex(t,
+		&model.ProdukSearchDocument{ID: 1, NamaProduk: "Kemeja", NamaToko: "Toko Lama"},
+		&model.ProdukSearchDocument{ID: 2, NamaProduk: "Kemeja Batik"},
+	)
+
+	// indexing a product again replaces its old terms
+	if err := index.Index(context.Background(), &model.ProdukSearchDocument{ID: 1, NamaProduk: "Kemeja", NamaToko: "Toko Baru"}); err != nil {
+		t.Fatal(err)
+	}
+	if ids, _ := searchIds(t, index, &model.ProdukSearchRequest{Query: "lama"}); len(ids) != 0 {
+		t.Errorf("old toko name still matches %v", ids)
+	}
+	if ids, _ := searchIds(t, index, &model.ProdukSearchRequest{Query: "baru"}); fmt.Sprint(ids) != "[1]" {
+		t.Errorf("new toko name: got %v, want [1]", ids)
+	}
+
+	if err := index.Remove(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if ids, _ := searchIds(t, index, &model.ProdukSearchRequest{Query: "kemeja"}); fmt.Sprint(ids) != "[2]" {
+		t.Errorf("after remove: got %v, want [2]", ids)
+	}
+	if err := index.Remove(context.Background(), 1); err != nil {
+		t.Errorf("removing twice: %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"fmt"
+	"marketplace-api/config/configtest"
+	"marketplace-api/model"
+	"testing"
+	"time"
+)
+
+func TestProdukSearchIndexSearch(t *testing.T) {
+	cfg := configtest.NewMySQLConfig(t)
+	db := cfg.Database()
+
+	user := &model.User{Nama: "user", KataSandi: "-", NoTelp: "080000000001", TanggalLahir: time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC), Email: "user@example.com"}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+	toko := &model.Toko{IdUser: user.ID, NamaToko: "Toko Budi"}
+	if err := db.Create(toko).Error; err != nil {
+		t.Fatal(err)
+	}
+	category := &model.Category{NamaCategory: "Fashion"}
+	if err := db.Create(category).Error; err != nil {
+		t.Fatal(err)
+	}
+	// every other product shares only the common bigram "an" with "sepatu anak"
+	produkIds := map[string]int{}
+	for i, namaProduk := range []string{"Sepatu Anak Merah", "Tas Kanvas", "Gelang Tangan", "Bantal Kain", "Celana Panjang", "Jaket Hujan"} {
+		produk := &model.Produk{
+			NamaProduk:    namaProduk,
+			Slug:          fmt.Sprintf("produk-%d", i),
+			HargaKonsumen: 10000,
+			HargaReseller: 9000,
+			Stok:          1,
+			IdToko:        toko.ID,
+			IdCategory:    category.ID,
+		}
+		if err := db.Create(produk).Error; err != nil {
+			t.Fatal(err)
+		}
+		produkIds[namaProduk] = produk.ID
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{name: "common bigram does not match the catalog", query: "sepatu anak", want: []string{"Sepatu Anak Merah"}},
+		{name: "typo", query: "sepatv", want: []string{"Sepatu Anak Merah"}},
+		{name: "no match", query: "laptop", want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &model.ProdukSearchRequest{Query: tt.query, PaginationRequest: model.PaginationRequest{Page: 1, Limit: 10}}
+			ids, total := searchIds(t, NewProdukSearchIndex(cfg), req)
+			want := []int{}
+			for _, namaProduk := range tt.want {
+				want = append(want, produkIds[namaProduk])
+			}
+			if fmt.Sprint(ids) != fmt.Sprint(want) {
+				t.Errorf("got %v, want %v", ids, want)
+			}
+			if total != int64(len(want)) {
+				t.Errorf("got total %d, want %d", total, len(want))
+			}
+		})
+	}
+}
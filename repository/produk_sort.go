@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"marketplace-api/model"
+	"strconv"
+	"time"
+)
+
+type (
+	// produkSort is the sql of a whitelisted product sort, only these expressions ever reach the query
+	produkSort struct {
+		expression string
+		desc       bool
+		timeKey    bool
+	}
+
+	produkWithSortKey struct {
+		model.Produk
+		SortKey string `gorm:"column:sort_key"`
+	}
+)
+
+var produkSorts = map[string]produkSort{
+	model.PRODUK_SORT_DEFAULT:    {expression: "produk.id"},
+	model.PRODUK_SORT_HARGA_ASC:  {expression: "produk.harga_konsumen"},
+	model.PRODUK_SORT_HARGA_DESC: {expression: "produk.harga_konsumen", desc: true},
+	model.PRODUK_SORT_TERBARU:    {expression: "produk.created_at", desc: true, timeKey: true},
+	// sold quantity of the product, cancelled transactions are not counted
+	model.PRODUK_SORT_TERLARIS: {
+		expression: "(SELECT COALESCE(SUM(detail_trx.kuantitas), 0) FROM detail_trx " +
+			"JOIN log_produk ON log_produk.id = detail_trx.id_log_produk " +
+			"JOIN trx ON trx.id = detail_trx.id_trx " +
+			"WHERE log_produk.id_produk = produk.id AND trx.status <> '" + string(model.TRX_STATUS_CANCELLED) + "')",
+		desc: true,
+	},
+	model.PRODUK_SORT_STOK: {expression: "produk.stok", desc: true},
+}
+
+// orderBy breaks ties by id so every row has a stable position for the cursor
+func (p produkSort) orderBy() string {
+	direction := " ASC"
+	if p.desc {
+		direction = " DESC"
+	}
+	if p.expression == "produk.id" {
+		return p.expression + direction
+	}
+	return p.expression + direction + ", produk.id ASC"
+}
+
+// after returns the condition matching the rows that come after the cursor
+func (p produkSort) after(cursor *model.Cursor) (string, []interface{}, error) {
+	if p.expression == "produk.id" {
+		return "produk.id > ?", []interface{}{cursor.ID}, nil
+	}
+
+	var key interface{}
+	var err error
+	if p.timeKey {
+		key, err = time.Parse(time.RFC3339Nano, cursor.Key)
+	} else {
+		key, err = strconv.ParseInt(cursor.Key, 10, 64)
+	}
+	if err != nil {
+		return "", nil, model.ErrInvalidCursor
+	}
+
+	operator := " > ?"
+	if p.desc {
+		operator = " < ?"
+	}
+	condition := "(" + p.expression + operator + " OR (" + p.expression + " = ? AND produk.id > ?))"
+	return condition, []interface{}{key, key, cursor.ID}, nil
+}
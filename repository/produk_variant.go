@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"marketplace-api/config"
+	"marketplace-api/model"
+
+	"gorm.io/gorm"
+)
+
+type produkVariantRepository struct {
+	Cfg config.Config
+}
+
+func NewProdukVariantRepository(cfg config.Config) model.ProdukVariantRepository {
+	return &produkVariantRepository{Cfg: cfg}
+}
+
+func (p *produkVariantRepository) Create(ctx context.Context, produkVariant *model.ProdukVariant) (*model.ProdukVariant, error) {
+
+	transaction := p.Cfg.Database().WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			transaction.Rollback()
+		}
+	}()
+
+	if err := transaction.Error; err != nil {
+		return nil, err
+	}
+
+	if err := transaction.Create(&produkVariant).Error; err != nil {
+		transaction.Rollback()
+		if isDuplicateEntryError(err) {
+			return nil, model.ErrDuplicateSku
+		}
+		return nil, err
+	}
+
+	if err := syncProdukStok(transaction, produkVariant.IdProduk); err != nil {
+		transaction.Rollback()
+		return nil, err
+	}
+
+	return produkVariant, transaction.Commit().Error
+}
+
+func (p *produkVariantRepository) FindByID(ctx context.Context, produkVariantId int) (*model.ProdukVariant, error) {
+	produkVariant := new(model.ProdukVariant)
+
+	if err := p.Cfg.Database().
+		WithContext(ctx).
+		First(produkVariant, produkVariantId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("produk variant not found")
+		}
+		return nil, err
+	}
+	return produkVariant, nil
+}
+
+func (p *produkVariantRepository) FetchByProdukId(ctx context.Context, produkId int) ([]*model.ProdukVariant, error) {
+	data := []*model.ProdukVariant{}
+
+	if err := p.Cfg.Database().WithContext(ctx).
+		Where("id_produk = ?", produkId).
+		Order("id").
+		Find(&data).Error; err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (p *produkVariantRepository) FetchByProdukIds(ctx context.Context, produkIds []int) ([]*model.ProdukVariant, error) {
+	data := []*model.ProdukVariant{}
+	if len(produkIds) == 0 {
+		return data, nil
+	}
+
+	if err := p.Cfg.Database().WithContext(ctx).
+		Where("id_produk IN ?", produkIds).
+		Order("id").
+		Find(&data).Error; err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (p *produkVariantRepository) Update(
+	ctx context.Context,
+	produkVariantId int,
+	produkVariant *model.ProdukVariant,
+) (*model.ProdukVariant, error) {
+
+	transaction := p.Cfg.Database().WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			transaction.Rollback()
+		}
+	}()
+
+	if err := transaction.Error; err != nil {
+		return nil, err
+	}
+
+	// Select writes a zero stock too, Updates alone skips zero values
+	if err := transaction.
+		Model(&model.ProdukVariant{ID: produkVariantId}).
+		Select("sku", "atribut", "harga_reseller", "harga_konsumen", "stok").
+		Updates(produkVariant).Error; err != nil {
+		transaction.Rollback()
+		if isDuplicateEntryError(err) {
+			return nil, model.ErrDuplicateSku
+		}
+		return nil, err
+	}
+
+	if err := transaction.First(produkVariant, produkVariantId).Error; err != nil {
+		transaction.Rollback()
+		return nil, err
+	}
+
+	if err := syncProdukStok(transaction, produkVariant.IdProduk); err != nil {
+		transaction.Rollback()
+		return nil, err
+	}
+
+	return produkVariant, transaction.Commit().Error
+}
+
+func (p *produkVariantRepository) Delete(ctx context.Context, produkVariantId int) error {
+
+	transaction := p.Cfg.Database().WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			transaction.Rollback()
+		}
+	}()
+
+	if err := transaction.Error; err != nil {
+		return err
+	}
+
+	produkVariant := new(model.ProdukVariant)
+	if err := transaction.First(produkVariant, produkVariantId).Error; err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	if err := transaction.Delete(&model.ProdukVariant{}, produkVariantId).Error; err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	if err := syncProdukStok(transaction, produkVariant.IdProduk); err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	return transaction.Commit().Error
+}
+
+// syncProdukStok sets the stock of a product to the total stock of its variants,
+// once the last variant is deleted the product has no stock until the seller edits it
+func syncProdukStok(transaction *gorm.DB, produkId int) error {
+	return transaction.Model(&model.Produk{ID: produkId}).
+		Update("stok", gorm.Expr(
+			"(SELECT COALESCE(SUM(produk_variant.stok), 0) FROM produk_variant WHERE produk_variant.id_produk = ?)",
+			produkId,
+		)).Error
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"marketplace-api/model"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+type localStorage struct {
+	cfg model.LocalStorageConfig
+}
+
+func NewLocalStorage(cfg model.LocalStorageConfig) model.Storage {
+	return &localStorage{cfg: cfg}
+}
+
+// Put writes to a temporary file first, so a failed upload never leaves a partial object behind
+func (l *localStorage) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	filePath, err := l.filePath(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
+		return err
+	}
+
+	tempFile, err := os.CreateTemp(filepath.Dir(filePath), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+
+	if _, err := io.Copy(tempFile, body); err != nil {
+		tempFile.Close()
+		return err
+	}
+	if err := tempFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tempFile.Name(), filePath)
+}
+
+func (l *localStorage) Delete(ctx context.Context, key string) error {
+	filePath, err := l.filePath(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (l *localStorage) List(ctx context.Context, prefix string) ([]*model.StorageObject, error) {
+	objects := []*model.StorageObject{}
+	// only the folder of the prefix has to be walked
+	root := filepath.Join(l.cfg.Dir, filepath.FromSlash(path.Dir(prefix+"x")))
+	err := filepath.WalkDir(root, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		relativePath, err := filepath.Rel(l.cfg.Dir, filePath)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(relativePath)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, &model.StorageObject{Key: key, Size: info.Size(), LastModified: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return objects, nil
+}
+
+func (l *localStorage) URL(key string) string {
+	if key == "" {
+		return ""
+	}
+	return strings.TrimSuffix(l.cfg.PublicUrl, "/") + "/" + key
+}
+
+func (l *localStorage) filePath(key string) (string, error) {
+	if !isValidStorageKey(key) {
+		return "", model.ErrInvalidStorageKey
+	}
+	return filepath.Join(l.cfg.Dir, filepath.FromSlash(key)), nil
+}
+
+// isValidStorageKey rejects keys that could point outside of the storage
+func isValidStorageKey(key string) bool {
+	if key == "" || key == "." || key == ".." || path.Clean(key) != key {
+		return false
+	}
+	return !strings.HasPrefix(key, "/") && !strings.HasPrefix(key, "../")
+}
@@ -0,0 +1,253 @@
+package repository
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"marketplace-api/model"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	S3_REQUEST_TIMEOUT   = 30 * time.Second
+	S3_UNSIGNED_PAYLOAD  = "UNSIGNED-PAYLOAD"
+	S3_SIGNING_ALGORITHM = "AWS4-HMAC-SHA256"
+	S3_AMZ_DATE_FORMAT   = "20060102T150405Z"
+	S3_SCOPE_DATE_FORMAT = "20060102"
+)
+
+type (
+	s3ListBucketResult struct {
+		Contents              []s3ListBucketContent `xml:"Contents"`
+		IsTruncated           bool                  `xml:"IsTruncated"`
+		NextContinuationToken string                `xml:"NextContinuationToken"`
+	}
+
+	s3ListBucketContent struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	}
+)
+
+// s3Storage talks to the S3 REST API directly and signs the requests with AWS Signature Version 4
+type s3Storage struct {
+	cfg        model.S3StorageConfig
+	httpClient *http.Client
+}
+
+func NewS3Storage(cfg model.S3StorageConfig) model.Storage {
+	return &s3Storage{
+		cfg:        cfg,
+		httpClient: &http.Client{Timeout: S3_REQUEST_TIMEOUT},
+	}
+}
+
+func (s *s3Storage) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	if !isValidStorageKey(key) {
+		return model.ErrInvalidStorageKey
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectUrl(key), body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return s.do(req, key)
+}
+
+func (s *s3Storage) Delete(ctx context.Context, key string) error {
+	if !isValidStorageKey(key) {
+		return model.ErrInvalidStorageKey
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectUrl(key), nil)
+	if err != nil {
+		return err
+	}
+	// S3 answers 204 for a missing object too
+	return s.do(req, key)
+}
+
+// List pages through ListObjectsV2, the response is at most 1000 objects per page
+func (s *s3Storage) List(ctx context.Context, prefix string) ([]*model.StorageObject, error) {
+	objects := []*model.StorageObject{}
+	continuationToken := ""
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", prefix)
+		if continuationToken != "" {
+			query.Set("continuation-token", continuationToken)
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.bucketUrl(), nil)
+		if err != nil {
+			return nil, err
+		}
+		req.URL.RawQuery = s3CanonicalQuery(query)
+
+		var result s3ListBucketResult
+		if err := s.doXml(req, prefix, &result); err != nil {
+			return nil, err
+		}
+		for _, content := range result.Contents {
+			objects = append(objects, &model.StorageObject{
+				Key:          content.Key,
+				Size:         content.Size,
+				LastModified: content.LastModified,
+			})
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+		continuationToken = result.NextContinuationToken
+	}
+}
+
+func (s *s3Storage) URL(key string) string {
+	if key == "" {
+		return ""
+	}
+	if s.cfg.PublicUrl != "" {
+		return strings.TrimSuffix(s.cfg.PublicUrl, "/") + "/" + key
+	}
+	return s.objectUrl(key)
+}
+
+func (s *s3Storage) do(req *http.Request, key string) error {
+	return s.doXml(req, key, nil)
+}
+
+// doXml sends a signed request and decodes the xml response into result when it is not nil
+func (s *s3Storage) doXml(req *http.Request, key string, result interface{}) error {
+	s.sign(req, time.Now().UTC())
+	res, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound && req.Method == http.MethodDelete {
+		return nil
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("s3 %s %s: %s %s", req.Method, key, res.Status, strings.TrimSpace(string(message)))
+	}
+	if result == nil {
+		return nil
+	}
+	return xml.NewDecoder(res.Body).Decode(result)
+}
+
+// objectUrl addresses the object path-style, http(s)://endpoint/bucket/key
+func (s *s3Storage) objectUrl(key string) string {
+	return strings.TrimSuffix(s.cfg.Endpoint, "/") + s.canonicalUri(key)
+}
+
+func (s *s3Storage) bucketUrl() string {
+	return strings.TrimSuffix(s.cfg.Endpoint, "/") + "/" + s3UriEncode(s.cfg.Bucket)
+}
+
+func (s *s3Storage) canonicalUri(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = s3UriEncode(segment)
+	}
+	return "/" + s3UriEncode(s.cfg.Bucket) + "/" + strings.Join(segments, "/")
+}
+
+// sign adds the Signature Version 4 headers, the body is sent unsigned so it can be streamed.
+// The query has to be built by s3CanonicalQuery already
+func (s *s3Storage) sign(req *http.Request, now time.Time) {
+	s.signWithPayloadHash(req, now, S3_UNSIGNED_PAYLOAD)
+}
+
+// signWithPayloadHash signs req with payloadHash as its x-amz-content-sha256
+func (s *s3Storage) signWithPayloadHash(req *http.Request, now time.Time, payloadHash string) {
+	amzDate := now.Format(S3_AMZ_DATE_FORMAT)
+	scopeDate := now.Format(S3_SCOPE_DATE_FORMAT)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + payloadHash,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := scopeDate + "/" + s.cfg.Region + "/s3/aws4_request"
+	canonicalRequestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		S3_SIGNING_ALGORITHM,
+		amzDate,
+		scope,
+		hex.EncodeToString(canonicalRequestHash[:]),
+	}, "\n")
+
+	signingKey := hmacSha256([]byte("AWS4"+s.cfg.SecretKey), scopeDate)
+	signingKey = hmacSha256(signingKey, s.cfg.Region)
+	signingKey = hmacSha256(signingKey, "s3")
+	signingKey = hmacSha256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSha256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		S3_SIGNING_ALGORITHM, s.cfg.AccessKey, scope, signedHeaders, signature,
+	))
+}
+
+// s3CanonicalQuery sorts the parameters and encodes them the way Signature Version 4 expects
+func s3CanonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	parameters := []string{}
+	for _, key := range keys {
+		values := append([]string{}, query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			parameters = append(parameters, s3UriEncode(key)+"="+s3UriEncode(value))
+		}
+	}
+	return strings.Join(parameters, "&")
+}
+
+func hmacSha256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// s3UriEncode percent-encodes every byte except the unreserved characters, as Signature Version 4 requires
+func s3UriEncode(s string) string {
+	var builder strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			builder.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&builder, "%%%02X", c)
+	}
+	return builder.String()
+}
@@ -24,17 +24,23 @@ func (t *tokoRepository) Create(ctx context.Context, toko *model.Toko) (*model.T
 	return toko, nil
 }
 
-func (t *tokoRepository) FetchAndPaginate(ctx context.Context, req *model.TokoFetchPaginateRequest) ([]*model.Toko, error) {
+func (t *tokoRepository) FetchAndPaginate(ctx context.Context, req *model.TokoFetchPaginateRequest) ([]*model.Toko, int64, error) {
 	var data []*model.Toko
+	var total int64
 
-	offset := (req.Page - 1) * req.Limit
-	if err := t.Cfg.Database().WithContext(ctx).
+	query := t.Cfg.Database().WithContext(ctx).
+		Model(&model.Toko{}).
 		Where("nama_toko LIKE ?", "%"+req.Nama+"%").
-		Limit(req.Limit).Offset(offset).Find(&data).Error; err != nil {
-		return nil, err
+		Session(&gorm.Session{})
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := query.
+		Limit(req.Limit).Offset(req.Offset()).Find(&data).Error; err != nil {
+		return nil, 0, err
 	}
 
-	return data, nil
+	return data, total, nil
 }
 
 func (t *tokoRepository) FindByTokoID(ctx context.Context, tokoId int) (*model.Toko, error) {
@@ -75,3 +81,32 @@ func (t *tokoRepository) UpdateByTokoID(ctx context.Context, tokoId int, toko *m
 	}
 	return toko, nil
 }
+
+func (t *tokoRepository) FindByTokoIDs(ctx context.Context, tokoIds []int) ([]*model.Toko, error) {
+	data := []*model.Toko{}
+	if len(tokoIds) == 0 {
+		return data, nil
+	}
+
+	if err := t.Cfg.Database().WithContext(ctx).
+		Where("id IN ?", tokoIds).
+		Order("id").
+		Find(&data).Error; err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (t *tokoRepository) FetchUrlFotos(ctx context.Context) ([]string, error) {
+	urlFotos := []string{}
+
+	if err := t.Cfg.Database().WithContext(ctx).
+		Model(&model.Toko{}).
+		Where("url_foto <> ''").
+		Pluck("url_foto", &urlFotos).Error; err != nil {
+		return nil, err
+	}
+
+	return urlFotos, nil
+}
@@ -6,6 +6,7 @@ import (
 	"marketplace-api/config"
 	"marketplace-api/model"
 	"strings"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -35,32 +36,83 @@ func (t *trxRepository) CreateTrx(
 		return nil, err
 	}
 
+	trx.Status = model.TRX_STATUS_PENDING_PAYMENT
 	if err := transaction.Create(&trx).Error; err != nil {
+		transaction.Rollback()
+		if isDuplicateEntryError(err) {
+			return nil, model.ErrDuplicateKodeInvoice
+		}
+		return nil, err
+	}
+
+	idUser := trx.IdUser
+	history := &model.TrxStatusHistory{
+		IdTrx:         trx.ID,
+		StatusSesudah: trx.Status,
+		IdUser:        &idUser,
+		Peran:         model.TRX_ACTOR_BUYER,
+	}
+	if err := transaction.Create(&history).Error; err != nil {
 		transaction.Rollback()
 		return nil, err
 	}
 
+	if trx.IdVoucher != nil {
+		if err := claimVoucher(transaction, trx); err != nil {
+			transaction.Rollback()
+			return nil, err
+		}
+	}
+
 	for _, detailTrxWithLogProduk := range detailTrxWithLogProdukList {
 		logProduk := detailTrxWithLogProduk.LogProduk
 		detailTrx := detailTrxWithLogProduk.DetailTrx
 
-		produk := new(model.Produk)
+		// check and decrement the stock in one statement, so concurrent transactions cannot oversell
 		produkId := logProduk.IdProduk
-		if err := transaction.First(produk, produkId).Error; err != nil {
+		res := transaction.Model(&model.Produk{}).
+			Where("id = ? AND stok >= ?", produkId, detailTrx.Kuantitas).
+			Update("stok", gorm.Expr("stok - ?", detailTrx.Kuantitas))
+		if res.Error != nil {
 			transaction.Rollback()
-			return nil, err
+			return nil, res.Error
 		}
-
-		if detailTrx.Kuantitas > produk.Stok {
+		if res.RowsAffected == 0 {
+			// no row changed either because the stock is too low or because the product is gone
+			err := transaction.Select("id").First(&model.Produk{}, produkId).Error
+			transaction.Rollback()
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, errors.New("produk not found")
+			}
+			if err != nil {
+				return nil, err
+			}
 			return nil, errors.New("kuantitas melebihi stok produk")
 		}
-		newProdukStok := produk.Stok - detailTrx.Kuantitas
-		if err := transaction.
-			Model(&model.Produk{ID: produkId}).Update("stok", newProdukStok).Error; err != nil {
-			transaction.Rollback()
-			return nil, err
+		if logProduk.IdProdukVariant != nil {
+			res := transaction.Model(&model.ProdukVariant{}).
+				Where("id = ? AND stok >= ?", *logProduk.IdProdukVariant, detailTrx.Kuantitas).
+				Update("stok", gorm.Expr("stok - ?", detailTrx.Kuantitas))
+			if res.Error != nil {
+				transaction.Rollback()
+				return nil, res.Error
+			}
+			if res.RowsAffected == 0 {
+				err := transaction.Select("id").First(&model.ProdukVariant{}, *logProduk.IdProdukVariant).Error
+				transaction.Rollback()
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return nil, errors.New("produk variant not found")
+				}
+				if err != nil {
+					return nil, err
+				}
+				return nil, errors.New("kuantitas melebihi stok variant")
+			}
 		}
 
+		logProduk.Jenis = model.LOG_PRODUK_JENIS_TRX
+		logProduk.PerubahanStok = -detailTrx.Kuantitas
+		logProduk.Keterangan = trx.KodeInvoice
 		if err := transaction.Create(&logProduk).Error; err != nil {
 			transaction.Rollback()
 			return nil, err
@@ -78,19 +130,15 @@ func (t *trxRepository) CreateTrx(
 }
 
 // can search invoice code, product name, or toko name
-func (t *trxRepository) Fetch(ctx context.Context, req *model.TrxFetchRequest, userId int) ([]*model.Trx, error) {
+func (t *trxRepository) Fetch(ctx context.Context, req *model.TrxFetchRequest, userId int) ([]*model.Trx, *model.PageResult, error) {
 	var data []*model.Trx
-
-	offset := (req.Page - 1) * req.Limit
+	var query *gorm.DB
 
 	if strings.HasPrefix(req.Search, model.KODE_INVOICE_PREFIX) {
 		// search invoice code
-		if err := t.Cfg.Database().WithContext(ctx).
-			Where("id_user = ? AND kode_invoice LIKE ?", userId, req.Search).
-			Limit(req.Limit).Offset(offset).
-			Find(&data).Error; err != nil {
-			return nil, err
-		}
+		query = t.Cfg.Database().WithContext(ctx).
+			Model(&model.Trx{}).
+			Where("id_user = ? AND kode_invoice LIKE ?", userId, req.Search)
 	} else {
 		// search product name or toko name
 		type TrxIdStruct struct {
@@ -108,7 +156,7 @@ func (t *trxRepository) Fetch(ctx context.Context, req *model.TrxFetchRequest, u
 			Select("trx.id").
 			Find(&trxIdStructList).Error
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
 		trxIdList := []int{}
@@ -116,15 +164,22 @@ func (t *trxRepository) Fetch(ctx context.Context, req *model.TrxFetchRequest, u
 			trxIdList = append(trxIdList, trxIdStruct.ID)
 		}
 
-		if err := t.Cfg.Database().WithContext(ctx).
-			Where("id in ?", trxIdList).
-			Limit(req.Limit).Offset(offset).
-			Find(&data).Error; err != nil {
-			return nil, err
-		}
+		query = t.Cfg.Database().WithContext(ctx).
+			Model(&model.Trx{}).
+			Where("id in ?", trxIdList)
 	}
 
-	return data, nil
+	total, err := paginateByID(query, req.PaginationRequest, &data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cursors := []model.Cursor{}
+	for _, trx := range data {
+		cursors = append(cursors, model.Cursor{Sort: model.CURSOR_SORT_ID, ID: trx.ID})
+	}
+	fetched, pageResult := cursorPageResult(req.PaginationRequest, cursors, total)
+	return data[:fetched], pageResult, nil
 }
 
 func (t *trxRepository) FindByID(ctx context.Context, trxId int) (*model.Trx, error) {
@@ -140,3 +195,199 @@ func (t *trxRepository) FindByID(ctx context.Context, trxId int) (*model.Trx, er
 	}
 	return trx, nil
 }
+
+func (t *trxRepository) UpdateStatus(
+	ctx context.Context,
+	trxId int,
+	currentStatus model.TrxStatus,
+	history *model.TrxStatusHistory,
+) error {
+
+	transaction := t.Cfg.Database().WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			transaction.Rollback()
+		}
+	}()
+
+	if err := transaction.Error; err != nil {
+		return err
+	}
+
+	if err := updateTrxStatus(transaction, trxId, currentStatus, history); err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	return transaction.Commit().Error
+}
+
+// CancelTrx cancels the transaction and puts the stock of every detail trx back in one database transaction,
+// a compensating log produk is written for every restored product and the voucher use is given back.
+// The stock of a variant that has been deleted since is not restored
+func (t *trxRepository) CancelTrx(
+	ctx context.Context,
+	trxId int,
+	currentStatus model.TrxStatus,
+	history *model.TrxStatusHistory,
+) error {
+
+	transaction := t.Cfg.Database().WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			transaction.Rollback()
+		}
+	}()
+
+	if err := transaction.Error; err != nil {
+		return err
+	}
+
+	if err := cancelTrx(transaction, trxId, currentStatus, history); err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	return transaction.Commit().Error
+}
+
+func (t *trxRepository) FetchUnpaidCreatedBefore(
+	ctx context.Context,
+	createdBefore time.Time,
+	afterId int,
+	limit int,
+) ([]*model.Trx, error) {
+	var data []*model.Trx
+
+	if err := t.Cfg.Database().WithContext(ctx).
+		Where("status = ? AND created_at < ? AND id > ?", model.TRX_STATUS_PENDING_PAYMENT, createdBefore, afterId).
+		Order("id").
+		Limit(limit).
+		Find(&data).Error; err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ExpireTrx cancels an unpaid transaction like CancelTrx and expires its pending payment in the same database transaction
+func (t *trxRepository) ExpireTrx(ctx context.Context, trxId int, history *model.TrxStatusHistory) error {
+
+	transaction := t.Cfg.Database().WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			transaction.Rollback()
+		}
+	}()
+
+	if err := transaction.Error; err != nil {
+		return err
+	}
+
+	if err := cancelTrx(transaction, trxId, model.TRX_STATUS_PENDING_PAYMENT, history); err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	if err := transaction.Model(&model.Payment{}).
+		Where("id_trx = ? AND status = ?", trxId, model.PAYMENT_STATUS_PENDING).
+		Update("status", model.PAYMENT_STATUS_EXPIRED).Error; err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	return transaction.Commit().Error
+}
+
+func cancelTrx(transaction *gorm.DB, trxId int, currentStatus model.TrxStatus, history *model.TrxStatusHistory) error {
+	if err := updateTrxStatus(transaction, trxId, currentStatus, history); err != nil {
+		return err
+	}
+
+	trx := new(model.Trx)
+	if err := transaction.First(trx, trxId).Error; err != nil {
+		return err
+	}
+	if err := transaction.
+		Model(&model.Trx{ID: trxId}).Update("alasan_batal", history.Catatan).Error; err != nil {
+		return err
+	}
+
+	var detailTrxList []*model.DetailTrx
+	if err := transaction.Where("id_trx = ?", trxId).Find(&detailTrxList).Error; err != nil {
+		return err
+	}
+	for _, detailTrx := range detailTrxList {
+		logProduk := new(model.LogProduk)
+		if err := transaction.First(logProduk, detailTrx.IdLogProduk).Error; err != nil {
+			return err
+		}
+
+		if logProduk.IdProdukVariant != nil {
+			res := transaction.Model(&model.ProdukVariant{ID: *logProduk.IdProdukVariant}).
+				Update("stok", gorm.Expr("stok + ?", detailTrx.Kuantitas))
+			if res.Error != nil {
+				return res.Error
+			}
+			// the units of a deleted variant have nowhere to go back to, the product stock is the sum of
+			// the remaining variants
+			if res.RowsAffected == 0 {
+				continue
+			}
+			if err := syncProdukStok(transaction.Unscoped(), logProduk.IdProduk); err != nil {
+				return err
+			}
+		} else {
+			// an archived product still gets its stock back, it is there again once restored
+			if err := transaction.Unscoped().Model(&model.Produk{ID: logProduk.IdProduk}).
+				Update("stok", gorm.Expr("stok + ?", detailTrx.Kuantitas)).Error; err != nil {
+				return err
+			}
+		}
+
+		compensatingLogProduk := *logProduk
+		compensatingLogProduk.ID = 0
+		compensatingLogProduk.CreatedAt = time.Time{}
+		compensatingLogProduk.UpdatedAt = time.Time{}
+		compensatingLogProduk.Jenis = model.LOG_PRODUK_JENIS_PEMBATALAN
+		compensatingLogProduk.PerubahanStok = detailTrx.Kuantitas
+		compensatingLogProduk.Keterangan = trx.KodeInvoice
+		if err := transaction.Create(&compensatingLogProduk).Error; err != nil {
+			return err
+		}
+	}
+	return releaseVoucher(transaction, trxId)
+}
+
+// updateTrxStatus only succeeds when the transaction is still in currentStatus,
+// so two concurrent status changes cannot both be applied
+func updateTrxStatus(transaction *gorm.DB, trxId int, currentStatus model.TrxStatus, history *model.TrxStatusHistory) error {
+	res := transaction.Model(&model.Trx{}).
+		Where("id = ? AND status = ?", trxId, currentStatus).
+		Update("status", history.StatusSesudah)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("transaction status has been changed, please try again")
+	}
+
+	history.IdTrx = trxId
+	history.StatusSebelum = currentStatus
+	return transaction.Create(&history).Error
+}
+
+func (t *trxRepository) FindByIDs(ctx context.Context, trxIds []int) ([]*model.Trx, error) {
+	data := []*model.Trx{}
+	if len(trxIds) == 0 {
+		return data, nil
+	}
+
+	if err := t.Cfg.Database().WithContext(ctx).
+		Where("id IN ?", trxIds).
+		Order("id").
+		Find(&data).Error; err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
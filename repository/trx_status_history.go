@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+	"marketplace-api/config"
+	"marketplace-api/model"
+)
+
+type trxStatusHistoryRepository struct {
+	Cfg config.Config
+}
+
+func NewTrxStatusHistoryRepository(cfg config.Config) model.TrxStatusHistoryRepository {
+	return &trxStatusHistoryRepository{Cfg: cfg}
+}
+
+func (t *trxStatusHistoryRepository) FindByTrxID(ctx context.Context, trxId int) ([]*model.TrxStatusHistory, error) {
+	var data []*model.TrxStatusHistory
+
+	if err := t.Cfg.Database().WithContext(ctx).
+		Where("id_trx = ?", trxId).
+		Order("id").
+		Find(&data).Error; err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"marketplace-api/config/configtest"
+	"marketplace-api/model"
+	"testing"
+)
+
+func TestCreateTrxStockErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		deleted   bool
+		kuantitas int
+		want      string
+	}{
+		{"stock too low", false, 6, "kuantitas melebihi stok produk"},
+		{"product archived", true, 1, "produk not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := configtest.NewConfig(t)
+			db := cfg.Database()
+			produk := &model.Produk{NamaProduk: "produk", Slug: "produk", HargaKonsumen: 1000, HargaReseller: 900, Stok: 5}
+			if err := db.Create(produk).Error; err != nil {
+				t.Fatal(err)
+			}
+			if tt.deleted {
+				if err := db.Delete(produk).Error; err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			trx := &model.Trx{IdUser: 1, AlamatPengiriman: 1, KodeInvoice: "INV-1", MethodBayar: "bank_transfer"}
+			detailTrxWithLogProdukList := []*model.DetailTrxWithLogProduk{{
+				LogProduk: &model.LogProduk{IdProduk: produk.ID, NamaProduk: produk.NamaProduk, Slug: produk.Slug},
+				DetailTrx: &model.DetailTrx{Kuantitas: tt.kuantitas},
+			}}
+			_, err := NewTrxRepository(cfg).CreateTrx(context.Background(), trx, detailTrxWithLogProdukList)
+			if err == nil || err.Error() != tt.want {
+				t.Fatalf("got %v, want %s", err, tt.want)
+			}
+
+			var total int64
+			if err := db.Model(&model.Trx{}).Count(&total).Error; err != nil {
+				t.Fatal(err)
+			}
+			if total != 0 {
+				t.Error("the trx was not rolled back")
+			}
+			if err := db.Unscoped().First(produk, produk.ID).Error; err != nil {
+				t.Fatal(err)
+			}
+			if produk.Stok != 5 {
+				t.Errorf("stock changed to %d", produk.Stok)
+			}
+		})
+	}
+}
+
+func TestCancelTrxRestoresVariantStock(t *testing.T) {
+	tests := []struct {
+		name           string
+		deleteVariant  bool
+		wantProdukStok int
+		wantLogs       int64
+	}{
+		// both variants start with 5, the trx takes 2 of the first one
+		{"variant still exists", false, 10, 1},
+		{"variant deleted since", true, 5, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cfg := configtest.NewConfig(t)
+			db := cfg.Database()
+			produk := &model.Produk{NamaProduk: "produk", Slug: "produk", HargaKonsumen: 1000, HargaReseller: 900, Stok: 10}
+			if err := db.Create(produk).Error; err != nil {
+				t.Fatal(err)
+			}
+			produkVariantList := []*model.ProdukVariant{}
+			for _, sku := range []string{"SKU-1", "SKU-2"} {
+				produkVariant := &model.ProdukVariant{IdProduk: produk.ID, Sku: sku, Atribut: map[string]string{"ukuran": sku}, HargaKonsumen: 1000, HargaReseller: 900, Stok: 5}
+				if err := db.Create(produkVariant).Error; err != nil {
+					t.Fatal(err)
+				}
+				produkVariantList = append(produkVariantList, produkVariant)
+			}
+
+			trxRepository := NewTrxRepository(cfg)
+			trx := &model.Trx{IdUser: 1, AlamatPengiriman: 1, KodeInvoice: "INV-1", MethodBayar: "bank_transfer"}
+			if _, err := trxRepository.CreateTrx(ctx, trx, []*model.DetailTrxWithLogProduk{{
+				LogProduk: &model.LogProduk{IdProduk: produk.ID, IdProdukVariant: &produkVariantList[0].ID, NamaProduk: produk.NamaProduk, Slug: produk.Slug},
+				DetailTrx: &model.DetailTrx{Kuantitas: 2},
+			}}); err != nil {
+				t.Fatal(err)
+			}
+			if tt.deleteVariant {
+				if err := NewProdukVariantRepository(cfg).Delete(ctx, produkVariantList[0].ID); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			history := &model.TrxStatusHistory{StatusSesudah: model.TRX_STATUS_CANCELLED, Peran: model.TRX_ACTOR_BUYER}
+			if err := trxRepository.CancelTrx(ctx, trx.ID, model.TRX_STATUS_PENDING_PAYMENT, history); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := db.First(produk, produk.ID).Error; err != nil {
+				t.Fatal(err)
+			}
+			if produk.Stok != tt.wantProdukStok {
+				t.Errorf("produk stok is %d, want %d", produk.Stok, tt.wantProdukStok)
+			}
+			var logs int64
+			if err := db.Model(&model.LogProduk{}).Where("jenis = ?", model.LOG_PRODUK_JENIS_PEMBATALAN).Count(&logs).Error; err != nil {
+				t.Fatal(err)
+			}
+			if logs != tt.wantLogs {
+				t.Errorf("%d compensating logs, want %d", logs, tt.wantLogs)
+			}
+		})
+	}
+}
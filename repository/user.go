@@ -61,3 +61,17 @@ func (u *userRepository) UpdateByID(ctx context.Context, id int, user *model.Use
 	}
 	return user, nil
 }
+
+func (u *userRepository) UpdateIsResellerByID(ctx context.Context, id int, isReseller bool) (*model.User, error) {
+	user, err := u.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Update writes false too, Updates with a struct skips it
+	if err := u.Cfg.Database().WithContext(ctx).
+		Model(user).Update("is_reseller", isReseller).Error; err != nil {
+		return nil, err
+	}
+	return user, nil
+}
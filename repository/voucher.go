@@ -0,0 +1,277 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"marketplace-api/config"
+	"marketplace-api/model"
+
+	"gorm.io/gorm"
+)
+
+type voucherRepository struct {
+	Cfg config.Config
+}
+
+func NewVoucherRepository(cfg config.Config) model.VoucherRepository {
+	return &voucherRepository{Cfg: cfg}
+}
+
+func (v *voucherRepository) Create(ctx context.Context, voucher *model.Voucher, categoryIds []int) (*model.Voucher, error) {
+
+	transaction := v.Cfg.Database().WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			transaction.Rollback()
+		}
+	}()
+
+	if err := transaction.Error; err != nil {
+		return nil, err
+	}
+
+	if err := transaction.Create(&voucher).Error; err != nil {
+		transaction.Rollback()
+		if isDuplicateEntryError(err) {
+			return nil, model.ErrDuplicateKodeVoucher
+		}
+		return nil, err
+	}
+
+	if err := createVoucherCategories(transaction, voucher.ID, categoryIds); err != nil {
+		transaction.Rollback()
+		return nil, err
+	}
+
+	return voucher, transaction.Commit().Error
+}
+
+func (v *voucherRepository) Fetch(ctx context.Context, req *model.VoucherFetchRequest) ([]*model.Voucher, int64, error) {
+	var data []*model.Voucher
+	var total int64
+
+	query := v.Cfg.Database().WithContext(ctx).
+		Model(&model.Voucher{})
+	if req.IdToko != nil {
+		query = query.Where("id_toko = ?", *req.IdToko)
+	}
+	query = query.Session(&gorm.Session{})
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := query.
+		Order("id").
+		Limit(req.Limit).Offset(req.Offset()).Find(&data).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return data, total, nil
+}
+
+func (v *voucherRepository) FindByID(ctx context.Context, voucherId int) (*model.Voucher, error) {
+	voucher := new(model.Voucher)
+
+	if err := v.Cfg.Database().
+		WithContext(ctx).
+		First(voucher, voucherId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, model.ErrVoucherNotFound
+		}
+		return nil, err
+	}
+	return voucher, nil
+}
+
+func (v *voucherRepository) FindByKode(ctx context.Context, kode string) (*model.Voucher, error) {
+	voucher := new(model.Voucher)
+
+	if err := v.Cfg.Database().
+		WithContext(ctx).
+		Where("kode = ?", kode).
+		First(voucher).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, model.ErrVoucherNotFound
+		}
+		return nil, err
+	}
+	return voucher, nil
+}
+
+func (v *voucherRepository) FetchCategories(ctx context.Context, voucherIds []int) ([]*model.VoucherCategory, error) {
+	data := []*model.VoucherCategory{}
+	if len(voucherIds) == 0 {
+		return data, nil
+	}
+
+	if err := v.Cfg.Database().WithContext(ctx).
+		Where("id_voucher IN ?", voucherIds).
+		Order("id").
+		Find(&data).Error; err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (v *voucherRepository) CountPemakaianByUser(ctx context.Context, voucherId int, userId int) (int64, error) {
+	var total int64
+
+	if err := v.Cfg.Database().WithContext(ctx).
+		Model(&model.VoucherPemakaian{}).
+		Where("id_voucher = ? AND id_user = ?", voucherId, userId).
+		Count(&total).Error; err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+func (v *voucherRepository) Update(
+	ctx context.Context,
+	voucherId int,
+	voucher *model.Voucher,
+	categoryIds []int,
+) (*model.Voucher, error) {
+
+	transaction := v.Cfg.Database().WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			transaction.Rollback()
+		}
+	}()
+
+	if err := transaction.Error; err != nil {
+		return nil, err
+	}
+
+	// Select writes the zero limits too, Updates alone skips zero values
+	if err := transaction.
+		Model(&model.Voucher{ID: voucherId}).
+		Select(
+			"kode", "jenis_diskon", "nilai_diskon", "maks_diskon", "min_belanja",
+			"kuota_total", "kuota_per_user", "berlaku_mulai", "berlaku_sampai",
+		).
+		Updates(voucher).Error; err != nil {
+		transaction.Rollback()
+		if isDuplicateEntryError(err) {
+			return nil, model.ErrDuplicateKodeVoucher
+		}
+		return nil, err
+	}
+
+	if err := transaction.Delete(&model.VoucherCategory{}, "id_voucher = ?", voucherId).Error; err != nil {
+		transaction.Rollback()
+		return nil, err
+	}
+
+	if err := createVoucherCategories(transaction, voucherId, categoryIds); err != nil {
+		transaction.Rollback()
+		return nil, err
+	}
+
+	if err := transaction.First(voucher, voucherId).Error; err != nil {
+		transaction.Rollback()
+		return nil, err
+	}
+
+	return voucher, transaction.Commit().Error
+}
+
+func (v *voucherRepository) Delete(ctx context.Context, voucherId int) error {
+
+	transaction := v.Cfg.Database().WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			transaction.Rollback()
+		}
+	}()
+
+	if err := transaction.Error; err != nil {
+		return err
+	}
+
+	if err := transaction.Delete(&model.VoucherCategory{}, "id_voucher = ?", voucherId).Error; err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	// the transactions that used the voucher keep their kode voucher and diskon
+	if err := transaction.Delete(&model.VoucherPemakaian{}, "id_voucher = ?", voucherId).Error; err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	if err := transaction.Delete(&model.Voucher{}, voucherId).Error; err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	return transaction.Commit().Error
+}
+
+func createVoucherCategories(transaction *gorm.DB, voucherId int, categoryIds []int) error {
+	if len(categoryIds) == 0 {
+		return nil
+	}
+
+	voucherCategoryList := []*model.VoucherCategory{}
+	for _, categoryId := range categoryIds {
+		voucherCategoryList = append(voucherCategoryList, &model.VoucherCategory{IdVoucher: voucherId, IdCategory: categoryId})
+	}
+	return transaction.Create(&voucherCategoryList).Error
+}
+
+// claimVoucher records the use of the voucher by the transaction. The usage counter is incremented first,
+// which also locks the voucher row, so concurrent transactions cannot exceed either limit
+func claimVoucher(transaction *gorm.DB, trx *model.Trx) error {
+	res := transaction.Model(&model.Voucher{}).
+		Where("id = ? AND (kuota_total = 0 OR terpakai < kuota_total)", *trx.IdVoucher).
+		Update("terpakai", gorm.Expr("terpakai + 1"))
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return model.ErrVoucherKuotaHabis
+	}
+
+	voucher := new(model.Voucher)
+	if err := transaction.First(voucher, *trx.IdVoucher).Error; err != nil {
+		return err
+	}
+	if voucher.KuotaPerUser > 0 {
+		var total int64
+		if err := transaction.Model(&model.VoucherPemakaian{}).
+			Where("id_voucher = ? AND id_user = ?", voucher.ID, trx.IdUser).
+			Count(&total).Error; err != nil {
+			return err
+		}
+		if total >= int64(voucher.KuotaPerUser) {
+			return model.ErrVoucherKuotaUserHabis
+		}
+	}
+
+	voucherPemakaian := &model.VoucherPemakaian{
+		IdVoucher: voucher.ID,
+		IdUser:    trx.IdUser,
+		IdTrx:     trx.ID,
+		Diskon:    trx.Diskon,
+	}
+	return transaction.Create(&voucherPemakaian).Error
+}
+
+// releaseVoucher gives the use of the voucher by a cancelled transaction back
+func releaseVoucher(transaction *gorm.DB, trxId int) error {
+	var voucherPemakaianList []*model.VoucherPemakaian
+	if err := transaction.Where("id_trx = ?", trxId).Find(&voucherPemakaianList).Error; err != nil {
+		return err
+	}
+	for _, voucherPemakaian := range voucherPemakaianList {
+		if err := transaction.Model(&model.Voucher{ID: voucherPemakaian.IdVoucher}).
+			Update("terpakai", gorm.Expr("terpakai - 1")).Error; err != nil {
+			return err
+		}
+		if err := transaction.Delete(&model.VoucherPemakaian{}, voucherPemakaian.ID).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"errors"
+	"marketplace-api/config/configtest"
+	"marketplace-api/model"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func TestClaimVoucher(t *testing.T) {
+	type claim struct {
+		idUser  int
+		wantErr error
+	}
+	tests := []struct {
+		name         string
+		kuotaTotal   int
+		kuotaPerUser int
+		claims       []claim
+		wantTerpakai int
+	}{
+		{
+			name:         "unlimited",
+			claims:       []claim{{1, nil}, {1, nil}, {2, nil}},
+			wantTerpakai: 3,
+		},
+		{
+			name:         "kuota total",
+			kuotaTotal:   2,
+			claims:       []claim{{1, nil}, {2, nil}, {3, model.ErrVoucherKuotaHabis}},
+			wantTerpakai: 2,
+		},
+		{
+			name:         "kuota per user",
+			kuotaPerUser: 1,
+			claims:       []claim{{1, nil}, {1, model.ErrVoucherKuotaUserHabis}, {2, nil}},
+			wantTerpakai: 2,
+		},
+		{
+			name:         "both kuota",
+			kuotaTotal:   2,
+			kuotaPerUser: 1,
+			claims:       []claim{{1, nil}, {1, model.ErrVoucherKuotaUserHabis}, {2, nil}, {3, model.ErrVoucherKuotaHabis}},
+			wantTerpakai: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := configtest.NewConfig(t).Database()
+			voucher := &model.Voucher{
+				Kode:          "HEMAT",
+				JenisDiskon:   model.VOUCHER_JENIS_DISKON_NOMINAL,
+				NilaiDiskon:   1000,
+				KuotaTotal:    tt.kuotaTotal,
+				KuotaPerUser:  tt.kuotaPerUser,
+				BerlakuMulai:  time.Now().Add(-time.Hour),
+				BerlakuSampai: time.Now().Add(time.Hour),
+			}
+			if err := db.Create(voucher).Error; err != nil {
+				t.Fatal(err)
+			}
+
+			for i, c := range tt.claims {
+				trx := &model.Trx{ID: i + 1, IdUser: c.idUser, IdVoucher: &voucher.ID, Diskon: 1000}
+				// CreateTrx rolls the whole transaction back when the claim fails
+				err := db.Transaction(func(transaction *gorm.DB) error {
+					return claimVoucher(transaction, trx)
+				})
+				if !errors.Is(err, c.wantErr) {
+					t.Errorf("claim %d by user %d: got error %v, want %v", i+1, c.idUser, err, c.wantErr)
+				}
+			}
+
+			if err := db.First(voucher, voucher.ID).Error; err != nil {
+				t.Fatal(err)
+			}
+			if voucher.Terpakai != tt.wantTerpakai {
+				t.Errorf("terpakai is %d, want %d", voucher.Terpakai, tt.wantTerpakai)
+			}
+			var pemakaian int64
+			if err := db.Model(&model.VoucherPemakaian{}).Count(&pemakaian).Error; err != nil {
+				t.Fatal(err)
+			}
+			if pemakaian != int64(tt.wantTerpakai) {
+				t.Errorf("%d voucher_pemakaian rows, want %d", pemakaian, tt.wantTerpakai)
+			}
+		})
+	}
+}
+
+func TestReleaseVoucher(t *testing.T) {
+	db := configtest.NewConfig(t).Database()
+	voucher := &model.Voucher{
+		Kode:          "HEMAT",
+		JenisDiskon:   model.VOUCHER_JENIS_DISKON_NOMINAL,
+		NilaiDiskon:   1000,
+		KuotaTotal:    1,
+		BerlakuMulai:  time.Now().Add(-time.Hour),
+		BerlakuSampai: time.Now().Add(time.Hour),
+	}
+	if err := db.Create(voucher).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := claimVoucher(db, &model.Trx{ID: 1, IdUser: 1, IdVoucher: &voucher.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if err := releaseVoucher(db, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := claimVoucher(db, &model.Trx{ID: 2, IdUser: 2, IdVoucher: &voucher.ID}); err != nil {
+		t.Errorf("claim after a release: %v", err)
+	}
+}
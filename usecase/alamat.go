@@ -28,14 +28,17 @@ func (a *alamatUsecase) StoreAlamat(ctx context.Context, req *model.AlamatReques
 	return alamatResponse, nil
 }
 
-func (a *alamatUsecase) FetchAndFilterAlamat(ctx context.Context, userId int, judulAlamat string) ([]*model.AlamatResponse, error) {
-	alamatList, err := a.alamatRepository.FetchAndFilter(ctx, userId, judulAlamat)
+func (a *alamatUsecase) FetchAndFilterAlamat(ctx context.Context, req *model.AlamatFetchRequest, userId int) (*model.AlamatFetchResponse, error) {
+	alamatList, total, err := a.alamatRepository.FetchAndFilter(ctx, req, userId)
 	if err != nil {
 		return nil, err
 	}
+	alamatFetchResponse := new(model.AlamatFetchResponse)
+	alamatFetchResponse.Pagination = model.NewPagination(req.PaginationRequest, total)
 	alamatResponses := []*model.AlamatResponse{}
 	copier.Copy(&alamatResponses, &alamatList)
-	return alamatResponses, nil
+	alamatFetchResponse.Data = alamatResponses
+	return alamatFetchResponse, nil
 }
 
 func (a *alamatUsecase) GetAlamatByID(ctx context.Context, alamatId int, userId int) (*model.AlamatResponse, error) {
@@ -83,3 +86,13 @@ func (a *alamatUsecase) DestroyAlamat(ctx context.Context, alamatId int, userId
 	}
 	return nil
 }
+
+func (a *alamatUsecase) RestoreAlamat(ctx context.Context, alamatId int) (*model.AlamatResponse, error) {
+	alamat, err := a.alamatRepository.Restore(ctx, alamatId)
+	if err != nil {
+		return nil, err
+	}
+	alamatResponse := new(model.AlamatResponse)
+	copier.Copy(alamatResponse, alamat)
+	return alamatResponse, nil
+}
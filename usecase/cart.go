@@ -0,0 +1,398 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"marketplace-api/model"
+
+	"github.com/jinzhu/copier"
+)
+
+type cartUsecase struct {
+	cartRepository          model.CartRepository
+	userRepository          model.UserRepository
+	produkRepository        model.ProdukRepository
+	produkVariantRepository model.ProdukVariantRepository
+	tokoRepository          model.TokoRepository
+	fotoProdukRepository    model.FotoProdukRepository
+	storage                 model.Storage
+	trxUsecase              model.TrxUsecase
+}
+
+func NewCartUsecase(
+	cartRepository model.CartRepository,
+	userRepository model.UserRepository,
+	produkRepository model.ProdukRepository,
+	produkVariantRepository model.ProdukVariantRepository,
+	tokoRepository model.TokoRepository,
+	fotoProdukRepository model.FotoProdukRepository,
+	storage model.Storage,
+	trxUsecase model.TrxUsecase,
+) model.CartUsecase {
+	return &cartUsecase{
+		cartRepository:          cartRepository,
+		userRepository:          userRepository,
+		produkRepository:        produkRepository,
+		produkVariantRepository: produkVariantRepository,
+		tokoRepository:          tokoRepository,
+		fotoProdukRepository:    fotoProdukRepository,
+		storage:                 storage,
+		trxUsecase:              trxUsecase,
+	}
+}
+
+func (c *cartUsecase) GetCart(ctx context.Context, userId int) (*model.CartResponse, error) {
+	cart, err := c.cartRepository.FindOrCreateByUserID(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	return c.buildCartResponse(ctx, cart)
+}
+
+func (c *cartUsecase) StoreCartItem(ctx context.Context, req *model.CartItemRequest, userId int) (*model.CartResponse, error) {
+	produk, err := c.produkRepository.FindByID(ctx, req.ProductId)
+	if err != nil {
+		return nil, err
+	}
+	toko, err := c.tokoRepository.FindByTokoID(ctx, produk.IdToko)
+	if err != nil {
+		return nil, err
+	}
+	if toko.IdUser == userId {
+		return nil, errors.New("cannot buy product on self-owned store")
+	}
+	produkVariant, err := findProdukVariant(ctx, c.produkVariantRepository, produk.ID, req.VariantId)
+	if err != nil {
+		return nil, err
+	}
+	user, err := c.userRepository.FindByID(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	cart, err := c.cartRepository.FindOrCreateByUserID(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	cartItems, err := c.cartRepository.FetchItems(ctx, cart.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// adding a product variant that is already in the cart adds up the quantity
+	var existingCartItem *model.CartItem
+	for _, cartItem := range cartItems {
+		if cartItem.IdProduk == produk.ID && sameProdukVariant(cartItem.IdProdukVariant, produkVariant) {
+			existingCartItem = cartItem
+			break
+		}
+	}
+
+	if existingCartItem == nil {
+		harga, stok := cartItemHargaAndStok(produk, produkVariant, req.Kuantitas, user.IsReseller)
+		if req.Kuantitas > stok {
+			return nil, errors.New("kuantitas melebihi stok produk")
+		}
+		cartItem := new(model.CartItem)
+		cartItem.IdCart = cart.ID
+		cartItem.IdProduk = produk.ID
+		if produkVariant != nil {
+			cartItem.IdProdukVariant = &produkVariant.ID
+		}
+		cartItem.Kuantitas = req.Kuantitas
+		cartItem.Harga = harga
+		_, err = c.cartRepository.CreateItem(ctx, cartItem)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		kuantitas := existingCartItem.Kuantitas + req.Kuantitas
+		harga, stok := cartItemHargaAndStok(produk, produkVariant, kuantitas, user.IsReseller)
+		if kuantitas > stok {
+			return nil, errors.New("kuantitas melebihi stok produk")
+		}
+		cartItem := new(model.CartItem)
+		cartItem.Kuantitas = kuantitas
+		cartItem.Harga = harga
+		_, err = c.cartRepository.UpdateItemByID(ctx, existingCartItem.ID, cartItem)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return c.buildCartResponse(ctx, cart)
+}
+
+func (c *cartUsecase) EditCartItem(ctx context.Context, cartItemId int, req *model.CartItemUpdateRequest, userId int) (*model.CartResponse, error) {
+	cart, err := c.cartRepository.FindOrCreateByUserID(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	oldCartItem, err := c.cartRepository.FindItemByID(ctx, cartItemId)
+	if err != nil {
+		return nil, err
+	}
+	if oldCartItem.IdCart != cart.ID {
+		return nil, errors.New("unauthorized")
+	}
+
+	produk, err := c.produkRepository.FindByID(ctx, oldCartItem.IdProduk)
+	if err != nil {
+		return nil, err
+	}
+	produkVariant, err := c.findCartItemVariant(ctx, oldCartItem)
+	if err != nil {
+		return nil, err
+	}
+	user, err := c.userRepository.FindByID(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	harga, stok := cartItemHargaAndStok(produk, produkVariant, req.Kuantitas, user.IsReseller)
+	if req.Kuantitas > stok {
+		return nil, errors.New("kuantitas melebihi stok produk")
+	}
+
+	// the buyer is looking at the cart when editing it, so the price is refreshed too
+	cartItem := new(model.CartItem)
+	cartItem.Kuantitas = req.Kuantitas
+	cartItem.Harga = harga
+	_, err = c.cartRepository.UpdateItemByID(ctx, cartItemId, cartItem)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.buildCartResponse(ctx, cart)
+}
+
+func (c *cartUsecase) DestroyCartItem(ctx context.Context, cartItemId int, userId int) (*model.CartResponse, error) {
+	cart, err := c.cartRepository.FindOrCreateByUserID(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	cartItem, err := c.cartRepository.FindItemByID(ctx, cartItemId)
+	if err != nil {
+		return nil, err
+	}
+	if cartItem.IdCart != cart.ID {
+		return nil, errors.New("unauthorized")
+	}
+
+	err = c.cartRepository.DeleteItems(ctx, []int{cartItem.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	return c.buildCartResponse(ctx, cart)
+}
+
+func (c *cartUsecase) ClearCart(ctx context.Context, userId int) error {
+	cart, err := c.cartRepository.FindOrCreateByUserID(ctx, userId)
+	if err != nil {
+		return err
+	}
+	err = c.cartRepository.DeleteAllItems(ctx, cart.ID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Checkout creates one transaction per toko in the cart and removes the checked out items from the cart.
+// Every transaction is paid separately, so when one fails the ones created before it are kept and
+// returned together with the error
+func (c *cartUsecase) Checkout(ctx context.Context, req *model.CartCheckoutRequest, userId int) ([]*model.TrxGetByIDResponse, error) {
+	cart, err := c.cartRepository.FindOrCreateByUserID(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	cartItems, err := c.cartRepository.FetchItems(ctx, cart.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(cartItems) == 0 {
+		return nil, errors.New("cart is empty")
+	}
+	user, err := c.userRepository.FindByID(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	// re-validate every item before creating any transaction
+	tokoIds := []int{}
+	cartItemsByTokoId := map[int][]*model.CartItem{}
+	changedPriceProdukNames := []string{}
+	for _, cartItem := range cartItems {
+		produk, err := c.produkRepository.FindByID(ctx, cartItem.IdProduk)
+		if err != nil {
+			return nil, err
+		}
+		produkVariant, err := c.findCartItemVariant(ctx, cartItem)
+		if err != nil {
+			return nil, err
+		}
+		harga, stok := cartItemHargaAndStok(produk, produkVariant, cartItem.Kuantitas, user.IsReseller)
+		if cartItem.Kuantitas > stok {
+			return nil, fmt.Errorf("kuantitas %s melebihi stok produk", produk.NamaProduk)
+		}
+		if harga != cartItem.Harga {
+			updatedCartItem := new(model.CartItem)
+			updatedCartItem.Harga = harga
+			_, err = c.cartRepository.UpdateItemByID(ctx, cartItem.ID, updatedCartItem)
+			if err != nil {
+				return nil, err
+			}
+			changedPriceProdukNames = append(changedPriceProdukNames, produk.NamaProduk)
+		}
+
+		if _, ok := cartItemsByTokoId[produk.IdToko]; !ok {
+			tokoIds = append(tokoIds, produk.IdToko)
+		}
+		cartItemsByTokoId[produk.IdToko] = append(cartItemsByTokoId[produk.IdToko], cartItem)
+	}
+	if len(changedPriceProdukNames) > 0 {
+		return nil, fmt.Errorf("harga produk %v telah berubah, silakan periksa kembali keranjang", changedPriceProdukNames)
+	}
+
+	trxGetByIDResponses := []*model.TrxGetByIDResponse{}
+	for _, tokoId := range tokoIds {
+		trxStoreRequest := new(model.TrxStoreRequest)
+		trxStoreRequest.MethodBayar = req.MethodBayar
+		trxStoreRequest.AlamatPengiriman = req.AlamatPengiriman
+		checkedOutCartItemIds := []int{}
+		for _, cartItem := range cartItemsByTokoId[tokoId] {
+			detailTrxRequest := new(model.DetailTrxRequest)
+			detailTrxRequest.ProductId = cartItem.IdProduk
+			if cartItem.IdProdukVariant != nil {
+				detailTrxRequest.VariantId = *cartItem.IdProdukVariant
+			}
+			detailTrxRequest.Kuantitas = cartItem.Kuantitas
+			trxStoreRequest.DetailTrxRequests = append(trxStoreRequest.DetailTrxRequests, detailTrxRequest)
+			checkedOutCartItemIds = append(checkedOutCartItemIds, cartItem.ID)
+		}
+
+		trxGetByIDResponse, err := c.trxUsecase.StoreTrx(ctx, trxStoreRequest, userId)
+		if err != nil {
+			if len(trxGetByIDResponses) > 0 {
+				return trxGetByIDResponses, fmt.Errorf("checkout stopped after %d transaction(s) were created: %w", len(trxGetByIDResponses), err)
+			}
+			return nil, err
+		}
+		trxGetByIDResponses = append(trxGetByIDResponses, trxGetByIDResponse)
+
+		err = c.cartRepository.DeleteItems(ctx, checkedOutCartItemIds)
+		if err != nil {
+			return trxGetByIDResponses, fmt.Errorf("checkout stopped after %d transaction(s) were created: %w", len(trxGetByIDResponses), err)
+		}
+	}
+
+	return trxGetByIDResponses, nil
+}
+
+func (c *cartUsecase) buildCartResponse(ctx context.Context, cart *model.Cart) (*model.CartResponse, error) {
+	cartItems, err := c.cartRepository.FetchItems(ctx, cart.ID)
+	if err != nil {
+		return nil, err
+	}
+	user, err := c.userRepository.FindByID(ctx, cart.IdUser)
+	if err != nil {
+		return nil, err
+	}
+
+	cartResponse := new(model.CartResponse)
+	cartResponse.ID = cart.ID
+	cartResponse.Toko = []*model.CartTokoResponse{}
+	cartResponse.CanCheckout = len(cartItems) > 0
+
+	cartTokoResponseByTokoId := map[int]*model.CartTokoResponse{}
+	for _, cartItem := range cartItems {
+		produk, err := c.produkRepository.FindByID(ctx, cartItem.IdProduk)
+		if err != nil {
+			return nil, err
+		}
+
+		produkVariant, err := c.findCartItemVariant(ctx, cartItem)
+		if err != nil {
+			return nil, err
+		}
+		harga, stok := cartItemHargaAndStok(produk, produkVariant, cartItem.Kuantitas, user.IsReseller)
+
+		cartItemResponse := new(model.CartItemResponse)
+		copier.Copy(cartItemResponse, produk)
+		cartItemResponse.ID = cartItem.ID
+		cartItemResponse.IdProduk = produk.ID
+		if produkVariant != nil {
+			cartItemResponse.IdProdukVariant = &produkVariant.ID
+			cartItemResponse.Sku = produkVariant.Sku
+			cartItemResponse.AtributVariant = produkVariant.Atribut
+		}
+		cartItemResponse.Kuantitas = cartItem.Kuantitas
+		cartItemResponse.Harga = harga
+		cartItemResponse.HargaSaatDitambahkan = cartItem.Harga
+		cartItemResponse.HargaBerubah = harga != cartItem.Harga
+		cartItemResponse.Stok = stok
+		cartItemResponse.StokCukup = cartItem.Kuantitas <= stok
+		cartItemResponse.HargaTotal = harga.Times(cartItem.Kuantitas)
+
+		fotoProdukList, err := c.fotoProdukRepository.FetchByProdukId(ctx, produk.ID)
+		if err != nil {
+			return nil, err
+		}
+		if primaryFotoProduk := model.PrimaryFotoProduk(fotoProdukList); primaryFotoProduk != nil {
+			cartItemResponse.Photo = c.storage.URL(primaryFotoProduk.Url)
+		}
+
+		cartTokoResponse, ok := cartTokoResponseByTokoId[produk.IdToko]
+		if !ok {
+			toko, err := c.tokoRepository.FindByTokoID(ctx, produk.IdToko)
+			if err != nil {
+				return nil, err
+			}
+			cartTokoResponse = new(model.CartTokoResponse)
+			cartTokoResponse.Toko = newTokoGetByIDResponse(c.storage, toko)
+			cartTokoResponse.Items = []*model.CartItemResponse{}
+			cartTokoResponseByTokoId[produk.IdToko] = cartTokoResponse
+			cartResponse.Toko = append(cartResponse.Toko, cartTokoResponse)
+		}
+		cartTokoResponse.Items = append(cartTokoResponse.Items, cartItemResponse)
+		cartTokoResponse.SubtotalHarga += cartItemResponse.HargaTotal
+
+		cartResponse.TotalKuantitas += cartItemResponse.Kuantitas
+		cartResponse.TotalHarga += cartItemResponse.HargaTotal
+		if cartItemResponse.HargaBerubah || !cartItemResponse.StokCukup {
+			cartResponse.CanCheckout = false
+		}
+	}
+
+	return cartResponse, nil
+}
+
+// findCartItemVariant returns the variant chosen for the cart item, nil when the item has no variant
+func (c *cartUsecase) findCartItemVariant(ctx context.Context, cartItem *model.CartItem) (*model.ProdukVariant, error) {
+	if cartItem.IdProdukVariant == nil {
+		return nil, nil
+	}
+	return c.produkVariantRepository.FindByID(ctx, *cartItem.IdProdukVariant)
+}
+
+// cartItemHargaAndStok returns the unit price for the buyer and the stock of the variant,
+// or of the product when there is no variant
+func cartItemHargaAndStok(
+	produk *model.Produk,
+	produkVariant *model.ProdukVariant,
+	kuantitas int,
+	isReseller bool,
+) (model.Money, int) {
+	if produkVariant != nil {
+		return produk.HargaSatuan(produkVariant.HargaKonsumen, produkVariant.HargaReseller, kuantitas, isReseller), produkVariant.Stok
+	}
+	return produk.HargaSatuan(produk.HargaKonsumen, produk.HargaReseller, kuantitas, isReseller), produk.Stok
+}
+
+func sameProdukVariant(produkVariantId *int, produkVariant *model.ProdukVariant) bool {
+	if produkVariantId == nil || produkVariant == nil {
+		return produkVariantId == nil && produkVariant == nil
+	}
+	return *produkVariantId == produkVariant.ID
+}
@@ -0,0 +1,86 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"marketplace-api/model"
+	"testing"
+)
+
+func TestCartItemHargaAndStok(t *testing.T) {
+	produk := &model.Produk{HargaKonsumen: 10000, HargaReseller: 8000, Stok: 10, MinKuantitasReseller: 3}
+	produkVariant := &model.ProdukVariant{HargaKonsumen: 12000, HargaReseller: 9000, Stok: 4}
+	for _, tt := range hargaSatuanTests {
+		t.Run(tt.name, func(t *testing.T) {
+			variant, wantStok := (*model.ProdukVariant)(nil), produk.Stok
+			if tt.variant {
+				variant, wantStok = produkVariant, produkVariant.Stok
+			}
+			harga, stok := cartItemHargaAndStok(produk, variant, tt.kuantitas, tt.isReseller)
+			if harga != tt.want {
+				t.Errorf("harga is %d, want %d", harga, tt.want)
+			}
+			if stok != wantStok {
+				t.Errorf("stok is %d, want %d", stok, wantStok)
+			}
+		})
+	}
+}
+
+// failingStoreTrxUsecase fails every StoreTrx after the first succeeded calls
+type failingStoreTrxUsecase struct {
+	model.TrxUsecase
+	succeeded int
+}
+
+func (t *failingStoreTrxUsecase) StoreTrx(ctx context.Context, req *model.TrxStoreRequest, userId int) (*model.TrxGetByIDResponse, error) {
+	if t.succeeded == 0 {
+		return nil, errors.New("payment provider unavailable")
+	}
+	t.succeeded--
+	return t.TrxUsecase.StoreTrx(ctx, req, userId)
+}
+
+func TestCheckoutReturnsCreatedTrxWhenItStops(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+	category := f.createCategory()
+	produkList := []*model.Produk{}
+	for i := 0; i < 2; i++ {
+		seller := f.createUser(false)
+		toko := f.createToko(seller.ID)
+		produkList = append(produkList, f.createProduk(toko.ID, category.ID, 5, 10000, 9000))
+	}
+	buyer := f.createUser(false)
+	alamat := f.createAlamat(buyer.ID)
+
+	cartUsecase := f.newCartUsecase(&failingStoreTrxUsecase{TrxUsecase: f.newTrxUsecase(), succeeded: 1})
+	for _, produk := range produkList {
+		if _, err := cartUsecase.StoreCartItem(ctx, &model.CartItemRequest{ProductId: produk.ID, Kuantitas: 1}, buyer.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	trxGetByIDResponses, err := cartUsecase.Checkout(ctx, &model.CartCheckoutRequest{
+		MethodBayar:      string(model.PAYMENT_METHOD_BANK_TRANSFER),
+		AlamatPengiriman: alamat.ID,
+	}, buyer.ID)
+	if err == nil {
+		t.Fatal("checkout succeeded, want an error for the second toko")
+	}
+	if len(trxGetByIDResponses) != 1 {
+		t.Fatalf("got %d created trx, want 1", len(trxGetByIDResponses))
+	}
+
+	cartResponse, err := cartUsecase.GetCart(ctx, buyer.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cartResponse.Toko) != 1 {
+		t.Fatalf("%d toko left in the cart, want 1", len(cartResponse.Toko))
+	}
+	checkedOut := trxGetByIDResponses[0].DetailTrxResponses[0].LogProduk.IdProduk
+	if left := cartResponse.Toko[0].Items[0].IdProduk; left == checkedOut {
+		t.Errorf("the checked out produk %d is still in the cart", checkedOut)
+	}
+}
@@ -9,10 +9,20 @@ import (
 
 type categoryUsecase struct {
 	categoryRepository model.CategoryRepository
+	produkRepository   model.ProdukRepository
+	produkSearchIndex  model.ProdukSearchIndex
 }
 
-func NewCategoryUsecase(categoryRepository model.CategoryRepository) model.CategoryUsecase {
-	return &categoryUsecase{categoryRepository: categoryRepository}
+func NewCategoryUsecase(
+	categoryRepository model.CategoryRepository,
+	produkRepository model.ProdukRepository,
+	produkSearchIndex model.ProdukSearchIndex,
+) model.CategoryUsecase {
+	return &categoryUsecase{
+		categoryRepository: categoryRepository,
+		produkRepository:   produkRepository,
+		produkSearchIndex:  produkSearchIndex,
+	}
 }
 
 func (c *categoryUsecase) StoreCategory(ctx context.Context, req *model.CategoryRequest) (*model.CategoryResponse, error) {
@@ -48,12 +58,27 @@ func (c *categoryUsecase) GetCategoryByID(ctx context.Context, id int) (*model.C
 }
 
 func (c *categoryUsecase) EditCategory(ctx context.Context, id int, req *model.CategoryRequest) (*model.CategoryResponse, error) {
+	oldCategory, err := c.categoryRepository.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
 	category := new(model.Category)
 	copier.Copy(category, req)
-	category, err := c.categoryRepository.UpdateByID(ctx, id, category)
+	category, err = c.categoryRepository.UpdateByID(ctx, id, category)
 	if err != nil {
 		return nil, err
 	}
+	// the products are found by the name of their category too
+	if oldCategory.NamaCategory != category.NamaCategory {
+		documents, err := c.produkRepository.FetchSearchDocumentsByCategoryID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		err = indexProdukSearchDocuments(ctx, c.produkSearchIndex, documents)
+		if err != nil {
+			return nil, err
+		}
+	}
 	categoryResponse := new(model.CategoryResponse)
 	copier.Copy(categoryResponse, category)
 	return categoryResponse, nil
@@ -66,3 +91,13 @@ func (c *categoryUsecase) DestroyCategory(ctx context.Context, id int) error {
 	}
 	return nil
 }
+
+func (c *categoryUsecase) RestoreCategory(ctx context.Context, id int) (*model.CategoryResponse, error) {
+	category, err := c.categoryRepository.Restore(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	categoryResponse := new(model.CategoryResponse)
+	copier.Copy(categoryResponse, category)
+	return categoryResponse, nil
+}
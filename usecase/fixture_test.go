@@ -0,0 +1,194 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"marketplace-api/config"
+	"marketplace-api/config/configtest"
+	"marketplace-api/model"
+	"marketplace-api/repository"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const testWebhookSecret = "test-webhook-secret"
+
+// fixture creates rows straight in the test database, the usecases under test read them back through the repositories
+type fixture struct {
+	t   testing.TB
+	cfg config.Config
+	db  *gorm.DB
+	// n keeps the unique columns of the created rows apart
+	n int
+}
+
+func newFixture(t testing.TB) *fixture {
+	return newFixtureWithConfig(t, configtest.NewConfig(t))
+}
+
+func newFixtureWithConfig(t testing.TB, cfg config.Config) *fixture {
+	return &fixture{t: t, cfg: cfg, db: cfg.Database()}
+}
+
+// concurrencyDatabases are the databases the concurrency tests run on, MySQL only runs when
+// TEST_MYSQL_DSN is set
+var concurrencyDatabases = []struct {
+	name      string
+	newConfig func(t testing.TB) config.Config
+}{
+	{"sqlite", configtest.NewConfig},
+	{"mysql", configtest.NewMySQLConfig},
+}
+
+func (f *fixture) create(value interface{}) {
+	f.t.Helper()
+	if err := f.db.Create(value).Error; err != nil {
+		f.t.Fatal(err)
+	}
+}
+
+func (f *fixture) createUser(isReseller bool) *model.User {
+	f.n++
+	user := &model.User{
+		Nama:         fmt.Sprintf("user %d", f.n),
+		KataSandi:    "-",
+		NoTelp:       fmt.Sprintf("08%010d", f.n),
+		TanggalLahir: time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC),
+		Email:        fmt.Sprintf("user%d@example.com", f.n),
+		IsReseller:   isReseller,
+	}
+	f.create(user)
+	return user
+}
+
+func (f *fixture) createToko(userId int) *model.Toko {
+	f.n++
+	toko := &model.Toko{IdUser: userId, NamaToko: fmt.Sprintf("toko %d", f.n)}
+	f.create(toko)
+	return toko
+}
+
+func (f *fixture) createCategory() *model.Category {
+	f.n++
+	category := &model.Category{NamaCategory: fmt.Sprintf("category %d", f.n)}
+	f.create(category)
+	return category
+}
+
+func (f *fixture) createProduk(tokoId int, categoryId int, stok int, hargaKonsumen model.Money, hargaReseller model.Money) *model.Produk {
+	f.n++
+	produk := &model.Produk{
+		NamaProduk:    fmt.Sprintf("produk %d", f.n),
+		Slug:          fmt.Sprintf("produk-%d", f.n),
+		HargaKonsumen: hargaKonsumen,
+		HargaReseller: hargaReseller,
+		Stok:          stok,
+		IdToko:        tokoId,
+		IdCategory:    categoryId,
+	}
+	f.create(produk)
+	return produk
+}
+
+func (f *fixture) createProdukVariant(produkId int, stok int, hargaKonsumen model.Money, hargaReseller model.Money) *model.ProdukVariant {
+	f.n++
+	produkVariant := &model.ProdukVariant{
+		IdProduk:      produkId,
+		Sku:           fmt.Sprintf("SKU-%d", f.n),
+		Atribut:       map[string]string{"ukuran": fmt.Sprint(f.n)},
+		HargaKonsumen: hargaKonsumen,
+		HargaReseller: hargaReseller,
+		Stok:          stok,
+	}
+	f.create(produkVariant)
+	return produkVariant
+}
+
+func (f *fixture) createAlamat(userId int) *model.Alamat {
+	alamat := &model.Alamat{IdUser: userId, JudulAlamat: "rumah", NamaPenerima: "penerima", NoTelp: "0800", DetailAlamat: "jalan"}
+	f.create(alamat)
+	return alamat
+}
+
+func (f *fixture) findProduk(produkId int) *model.Produk {
+	f.t.Helper()
+	produk := new(model.Produk)
+	if err := f.db.Unscoped().First(produk, produkId).Error; err != nil {
+		f.t.Fatal(err)
+	}
+	return produk
+}
+
+func (f *fixture) newTrxUsecase() model.TrxUsecase {
+	cfg := f.cfg
+	return NewTrxUsecase(
+		repository.NewTrxRepository(cfg),
+		repository.NewUserRepository(cfg),
+		repository.NewAlamatRepository(cfg),
+		repository.NewDetailTrxRepository(cfg),
+		repository.NewLogProdukRepository(cfg),
+		repository.NewTokoRepository(cfg),
+		repository.NewCategoryRepository(cfg),
+		repository.NewFotoProdukRepository(cfg),
+		repository.NewProdukRepository(cfg),
+		repository.NewProdukVariantRepository(cfg),
+		repository.NewTrxStatusHistoryRepository(cfg),
+		new(sequenceKodeInvoiceGenerator),
+		repository.NewPaymentRepository(cfg),
+		NewPaymentProviderRegistry(
+			repository.NewFakePaymentProvider(model.PAYMENT_METHOD_BANK_TRANSFER, false, testWebhookSecret),
+			repository.NewFakePaymentProvider(model.PAYMENT_METHOD_COD, true, testWebhookSecret),
+		),
+		repository.NewVoucherRepository(cfg),
+		repository.NewLocalStorage(cfg.LocalStorage()),
+	)
+}
+
+// sequenceKodeInvoiceGenerator stands in for the invoice counter, whose upsert is specific to MySQL
+type sequenceKodeInvoiceGenerator struct {
+	nomor int64
+}
+
+func (s *sequenceKodeInvoiceGenerator) Generate(ctx context.Context) (string, error) {
+	return fmt.Sprintf("%sTEST-%06d", model.KODE_INVOICE_PREFIX, atomic.AddInt64(&s.nomor, 1)), nil
+}
+
+func newTrxStoreRequest(alamatId int, produkId int, variantId int, kuantitas int) *model.TrxStoreRequest {
+	return &model.TrxStoreRequest{
+		MethodBayar:      string(model.PAYMENT_METHOD_BANK_TRANSFER),
+		AlamatPengiriman: alamatId,
+		DetailTrxRequests: []*model.DetailTrxRequest{
+			{ProductId: produkId, VariantId: variantId, Kuantitas: kuantitas},
+		},
+	}
+}
+
+func (f *fixture) newCartUsecase(trxUsecase model.TrxUsecase) model.CartUsecase {
+	cfg := f.cfg
+	return NewCartUsecase(
+		repository.NewCartRepository(cfg),
+		repository.NewUserRepository(cfg),
+		repository.NewProdukRepository(cfg),
+		repository.NewProdukVariantRepository(cfg),
+		repository.NewTokoRepository(cfg),
+		repository.NewFotoProdukRepository(cfg),
+		repository.NewLocalStorage(cfg.LocalStorage()),
+		trxUsecase,
+	)
+}
+
+func (f *fixture) newProdukUsecase(produkSearchIndex model.ProdukSearchIndex) model.ProdukUsecase {
+	cfg := f.cfg
+	return NewProdukUsecase(
+		repository.NewProdukRepository(cfg),
+		repository.NewFotoProdukRepository(cfg),
+		repository.NewProdukVariantRepository(cfg),
+		repository.NewTokoRepository(cfg),
+		repository.NewCategoryRepository(cfg),
+		produkSearchIndex,
+		repository.NewLocalStorage(cfg.LocalStorage()),
+	)
+}
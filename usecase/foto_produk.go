@@ -0,0 +1,152 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"marketplace-api/model"
+)
+
+type fotoProdukUsecase struct {
+	fotoProdukRepository model.FotoProdukRepository
+	produkRepository     model.ProdukRepository
+	tokoRepository       model.TokoRepository
+	storage              model.Storage
+}
+
+func NewFotoProdukUsecase(
+	fotoProdukRepository model.FotoProdukRepository,
+	produkRepository model.ProdukRepository,
+	tokoRepository model.TokoRepository,
+	storage model.Storage,
+) model.FotoProdukUsecase {
+	return &fotoProdukUsecase{
+		fotoProdukRepository: fotoProdukRepository,
+		produkRepository:     produkRepository,
+		tokoRepository:       tokoRepository,
+		storage:              storage,
+	}
+}
+
+func (f *fotoProdukUsecase) StoreFotoProduk(
+	ctx context.Context,
+	produkId int,
+	userId int,
+	photoKeys []string,
+) ([]*model.FotoProdukResponse, error) {
+	err := f.authorizeProduk(ctx, produkId, userId)
+	if err != nil {
+		return nil, err
+	}
+	_, err = f.fotoProdukRepository.Create(ctx, produkId, photoKeys)
+	if err != nil {
+		return nil, err
+	}
+	return f.fetchFotoProdukResponses(ctx, produkId)
+}
+
+func (f *fotoProdukUsecase) ReorderFotoProduk(
+	ctx context.Context,
+	produkId int,
+	userId int,
+	req *model.FotoProdukOrderRequest,
+) ([]*model.FotoProdukResponse, error) {
+	err := f.authorizeProduk(ctx, produkId, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	fotoProdukList, err := f.fotoProdukRepository.FetchByProdukId(ctx, produkId)
+	if err != nil {
+		return nil, err
+	}
+	isFotoProdukOfProduk := map[int]bool{}
+	for _, fotoProduk := range fotoProdukList {
+		isFotoProdukOfProduk[fotoProduk.ID] = true
+	}
+	if len(uniqueIds(req.FotoProdukIds)) != len(req.FotoProdukIds) || len(req.FotoProdukIds) != len(fotoProdukList) {
+		return nil, model.ErrInvalidFotoProdukOrder
+	}
+	for _, fotoProdukId := range req.FotoProdukIds {
+		if !isFotoProdukOfProduk[fotoProdukId] {
+			return nil, model.ErrInvalidFotoProdukOrder
+		}
+	}
+
+	err = f.fotoProdukRepository.Reorder(ctx, produkId, req.FotoProdukIds)
+	if err != nil {
+		return nil, err
+	}
+	return f.fetchFotoProdukResponses(ctx, produkId)
+}
+
+func (f *fotoProdukUsecase) SetPrimaryFotoProduk(
+	ctx context.Context,
+	produkId int,
+	fotoProdukId int,
+	userId int,
+) ([]*model.FotoProdukResponse, error) {
+	err := f.authorizeProduk(ctx, produkId, userId)
+	if err != nil {
+		return nil, err
+	}
+	_, err = f.findFotoProduk(ctx, produkId, fotoProdukId)
+	if err != nil {
+		return nil, err
+	}
+	err = f.fotoProdukRepository.SetPrimary(ctx, produkId, fotoProdukId)
+	if err != nil {
+		return nil, err
+	}
+	return f.fetchFotoProdukResponses(ctx, produkId)
+}
+
+func (f *fotoProdukUsecase) DestroyFotoProduk(ctx context.Context, produkId int, fotoProdukId int, userId int) error {
+	err := f.authorizeProduk(ctx, produkId, userId)
+	if err != nil {
+		return err
+	}
+	fotoProduk, err := f.findFotoProduk(ctx, produkId, fotoProdukId)
+	if err != nil {
+		return err
+	}
+	err = f.fotoProdukRepository.Delete(ctx, fotoProdukId)
+	if err != nil {
+		return err
+	}
+	return deleteFotoProdukObjects(ctx, f.storage, []*model.FotoProduk{fotoProduk})
+}
+
+// authorizeProduk only lets the owner of the toko selling the product change its photos
+func (f *fotoProdukUsecase) authorizeProduk(ctx context.Context, produkId int, userId int) error {
+	produk, err := f.produkRepository.FindByID(ctx, produkId)
+	if err != nil {
+		return err
+	}
+	toko, err := f.tokoRepository.FindByTokoID(ctx, produk.IdToko)
+	if err != nil {
+		return err
+	}
+	if toko.IdUser != userId {
+		return errors.New("unauthorized")
+	}
+	return nil
+}
+
+func (f *fotoProdukUsecase) findFotoProduk(ctx context.Context, produkId int, fotoProdukId int) (*model.FotoProduk, error) {
+	fotoProduk, err := f.fotoProdukRepository.FindByID(ctx, fotoProdukId)
+	if err != nil {
+		return nil, err
+	}
+	if fotoProduk.IdProduk != produkId {
+		return nil, model.ErrFotoProdukMismatch
+	}
+	return fotoProduk, nil
+}
+
+func (f *fotoProdukUsecase) fetchFotoProdukResponses(ctx context.Context, produkId int) ([]*model.FotoProdukResponse, error) {
+	fotoProdukList, err := f.fotoProdukRepository.FetchByProdukId(ctx, produkId)
+	if err != nil {
+		return nil, err
+	}
+	return newFotoProdukResponses(f.storage, fotoProdukList), nil
+}
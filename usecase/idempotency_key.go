@@ -0,0 +1,69 @@
+package usecase
+
+import (
+	"context"
+	"marketplace-api/model"
+	"time"
+
+	"github.com/jinzhu/copier"
+)
+
+type idempotencyKeyUsecase struct {
+	idempotencyKeyRepository model.IdempotencyKeyRepository
+}
+
+func NewIdempotencyKeyUsecase(idempotencyKeyRepository model.IdempotencyKeyRepository) model.IdempotencyKeyUsecase {
+	return &idempotencyKeyUsecase{idempotencyKeyRepository: idempotencyKeyRepository}
+}
+
+func (i *idempotencyKeyUsecase) BeginRequest(ctx context.Context, req *model.IdempotencyKeyRequest) (*model.IdempotencyKey, bool, error) {
+	existingIdempotencyKey, err := i.idempotencyKeyRepository.FindByUserIDAndKey(ctx, req.IdUser, req.Key)
+	if err != nil {
+		return nil, false, err
+	}
+	now := time.Now()
+	if existingIdempotencyKey != nil && existingIdempotencyKey.IsExpired(now) {
+		idempotencyKey := new(model.IdempotencyKey)
+		copier.Copy(idempotencyKey, req)
+		idempotencyKey, err = i.idempotencyKeyRepository.Reclaim(ctx, existingIdempotencyKey.ID, idempotencyKey, now)
+		if err != nil {
+			return nil, false, err
+		}
+		return idempotencyKey, false, nil
+	}
+	if existingIdempotencyKey != nil {
+		if existingIdempotencyKey.RequestHash != req.RequestHash {
+			return nil, false, model.ErrIdempotencyKeyMismatch
+		}
+		if existingIdempotencyKey.StatusCode == 0 {
+			return nil, false, model.ErrIdempotencyKeyInProgress
+		}
+		return existingIdempotencyKey, true, nil
+	}
+
+	idempotencyKey := new(model.IdempotencyKey)
+	copier.Copy(idempotencyKey, req)
+	idempotencyKey, err = i.idempotencyKeyRepository.Create(ctx, idempotencyKey)
+	if err != nil {
+		return nil, false, err
+	}
+	return idempotencyKey, false, nil
+}
+
+func (i *idempotencyKeyUsecase) CompleteRequest(
+	ctx context.Context,
+	idempotencyKeyId int,
+	statusCode int,
+	responseBody []byte,
+) error {
+	return i.idempotencyKeyRepository.UpdateResponseByID(ctx, idempotencyKeyId, statusCode, responseBody)
+}
+
+// ReleaseRequest forgets the key so the client can retry a request that failed on the server side
+func (i *idempotencyKeyUsecase) ReleaseRequest(ctx context.Context, idempotencyKeyId int) error {
+	return i.idempotencyKeyRepository.Delete(ctx, idempotencyKeyId)
+}
+
+func (i *idempotencyKeyUsecase) DeleteExpiredKeys(ctx context.Context) (int64, error) {
+	return i.idempotencyKeyRepository.DeleteExpired(ctx, time.Now())
+}
@@ -0,0 +1,25 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"marketplace-api/model"
+	"time"
+)
+
+type kodeInvoiceGenerator struct {
+	invoiceCounterRepository model.InvoiceCounterRepository
+}
+
+func NewKodeInvoiceGenerator(invoiceCounterRepository model.InvoiceCounterRepository) model.KodeInvoiceGenerator {
+	return &kodeInvoiceGenerator{invoiceCounterRepository: invoiceCounterRepository}
+}
+
+func (k *kodeInvoiceGenerator) Generate(ctx context.Context) (string, error) {
+	tanggal := time.Now().Format(model.KODE_INVOICE_DATE_FORMAT)
+	nomor, err := k.invoiceCounterRepository.Next(ctx, tanggal)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s%s-%06d", model.KODE_INVOICE_PREFIX, tanggal, nomor), nil
+}
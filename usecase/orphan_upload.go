@@ -0,0 +1,99 @@
+package usecase
+
+import (
+	"context"
+	"marketplace-api/model"
+	"time"
+)
+
+type orphanUploadUsecase struct {
+	fotoProdukRepository model.FotoProdukRepository
+	tokoRepository       model.TokoRepository
+	storage              model.Storage
+}
+
+func NewOrphanUploadUsecase(
+	fotoProdukRepository model.FotoProdukRepository,
+	tokoRepository model.TokoRepository,
+	storage model.Storage,
+) model.OrphanUploadUsecase {
+	return &orphanUploadUsecase{
+		fotoProdukRepository: fotoProdukRepository,
+		tokoRepository:       tokoRepository,
+		storage:              storage,
+	}
+}
+
+func (o *orphanUploadUsecase) CollectOrphanUploads(
+	ctx context.Context,
+	req *model.OrphanUploadRequest,
+) (*model.OrphanUploadReport, error) {
+	// the storage is listed before the rows are read, an object uploaded in between is younger than the grace period
+	cutoff := time.Now().Add(-req.GracePeriod)
+	objects := []*model.StorageObject{}
+	for _, folder := range model.ORPHAN_UPLOAD_FOLDERS {
+		folderObjects, err := o.storage.List(ctx, folder+"/")
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, folderObjects...)
+	}
+
+	isReferenced, err := o.referencedKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := new(model.OrphanUploadReport)
+	report.Scanned = len(objects)
+	report.Orphans = []*model.StorageObject{}
+	report.Failures = []*model.OrphanUploadFailure{}
+	for _, object := range objects {
+		if isReferenced[object.Key] || object.LastModified.After(cutoff) {
+			continue
+		}
+		report.Orphans = append(report.Orphans, object)
+	}
+
+	if !req.Delete {
+		return report, nil
+	}
+	for _, object := range report.Orphans {
+		err := o.storage.Delete(ctx, object.Key)
+		if err != nil {
+			if ctx.Err() != nil {
+				return report, ctx.Err()
+			}
+			report.Failures = append(report.Failures, &model.OrphanUploadFailure{Key: object.Key, Err: err})
+			continue
+		}
+		report.Deleted++
+	}
+	return report, nil
+}
+
+// referencedKeys are the keys of every product photo and of every toko photo with their renditions
+func (o *orphanUploadUsecase) referencedKeys(ctx context.Context) (map[string]bool, error) {
+	isReferenced := map[string]bool{}
+
+	fotoProdukUrls, err := o.fotoProdukRepository.FetchUrls(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, url := range fotoProdukUrls {
+		for _, key := range model.FotoProdukObjectKeys(url) {
+			isReferenced[key] = true
+		}
+	}
+
+	urlFotos, err := o.tokoRepository.FetchUrlFotos(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, urlFoto := range urlFotos {
+		for _, key := range model.TokoFotoObjectKeys(urlFoto) {
+			isReferenced[key] = true
+		}
+	}
+	return isReferenced, nil
+}
@@ -0,0 +1,165 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"io"
+	"marketplace-api/model"
+	"marketplace-api/repository"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeStorage keeps the last modified time of every object, deleting a key in failDelete fails
+type fakeStorage struct {
+	objects    map[string]time.Time
+	failDelete map[string]bool
+}
+
+func (s *fakeStorage) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	s.objects[key] = time.Now()
+	return nil
+}
+
+func (s *fakeStorage) Delete(ctx context.Context, key string) error {
+	if s.failDelete[key] {
+		return errors.New("storage unavailable")
+	}
+	delete(s.objects, key)
+	return nil
+}
+
+func (s *fakeStorage) URL(key string) string {
+	return key
+}
+
+func (s *fakeStorage) List(ctx context.Context, prefix string) ([]*model.StorageObject, error) {
+	objects := []*model.StorageObject{}
+	for key, lastModified := range s.objects {
+		if strings.HasPrefix(key, prefix) {
+			objects = append(objects, &model.StorageObject{Key: key, LastModified: lastModified})
+		}
+	}
+	return objects, nil
+}
+
+func (s *fakeStorage) keys() string {
+	keys := []string{}
+	for key := range s.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
+
+func objectKeys(objects []*model.StorageObject) string {
+	keys := []string{}
+	for _, object := range objects {
+		keys = append(keys, object.Key)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
+
+func TestCollectOrphanUploads(t *testing.T) {
+	const gracePeriod = time.Hour
+	old := time.Now().Add(-2 * gracePeriod)
+	young := time.Now().Add(-gracePeriod / 2)
+
+	tests := []struct {
+		name        string
+		delete      bool
+		failDelete  []string
+		wantDeleted int
+		wantKept    string
+		wantFailed  string
+	}{
+		{
+			name:     "delete off",
+			wantKept: "produk/a.jpg,produk/a_large.jpg,produk/a_medium.jpg,produk/a_thumbnail.jpg,produk/new.jpg,produk/orphan.jpg,produk/orphan_thumbnail.jpg,toko/orphan.png,toko/t.png,toko/t_medium.png,toko/t_thumbnail.png",
+		},
+		{
+			name:        "delete on",
+			delete:      true,
+			wantDeleted: 3,
+			wantKept:    "produk/a.jpg,produk/a_large.jpg,produk/a_medium.jpg,produk/a_thumbnail.jpg,produk/new.jpg,toko/t.png,toko/t_medium.png,toko/t_thumbnail.png",
+		},
+		{
+			name:        "failed delete does not stop the others",
+			delete:      true,
+			failDelete:  []string{"produk/orphan.jpg"},
+			wantDeleted: 2,
+			wantKept:    "produk/a.jpg,produk/a_large.jpg,produk/a_medium.jpg,produk/a_thumbnail.jpg,produk/new.jpg,produk/orphan.jpg,toko/t.png,toko/t_medium.png,toko/t_thumbnail.png",
+			wantFailed:  "produk/orphan.jpg",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			seller := f.createUser(false)
+			toko := f.createToko(seller.ID)
+			if err := f.db.Model(toko).Update("url_foto", "toko/t.png").Error; err != nil {
+				t.Fatal(err)
+			}
+			produk := f.createProduk(toko.ID, f.createCategory().ID, 1, 10000, 9000)
+			f.create(&model.FotoProduk{IdProduk: produk.ID, Url: "produk/a.jpg", IsPrimary: true, HasRenditions: true})
+
+			storage := &fakeStorage{
+				objects: map[string]time.Time{
+					"produk/a.jpg":                old,
+					"produk/a_thumbnail.jpg":      old,
+					"produk/a_medium.jpg":         old,
+					"produk/a_large.jpg":          old,
+					"produk/orphan.jpg":           old,
+					"produk/orphan_thumbnail.jpg": old,
+					"produk/new.jpg":              young,
+					"toko/t.png":                  old,
+					"toko/t_thumbnail.png":        old,
+					"toko/t_medium.png":           old,
+					"toko/orphan.png":             old,
+				},
+				failDelete: map[string]bool{},
+			}
+			for _, key := range tt.failDelete {
+				storage.failDelete[key] = true
+			}
+			orphanUploadUsecase := NewOrphanUploadUsecase(
+				repository.NewFotoProdukRepository(f.cfg),
+				repository.NewTokoRepository(f.cfg),
+				storage,
+			)
+
+			report, err := orphanUploadUsecase.CollectOrphanUploads(context.Background(), &model.OrphanUploadRequest{
+				GracePeriod: gracePeriod,
+				Delete:      tt.delete,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if report.Scanned != 11 {
+				t.Errorf("scanned %d objects, want 11", report.Scanned)
+			}
+			if got, want := objectKeys(report.Orphans), "produk/orphan.jpg,produk/orphan_thumbnail.jpg,toko/orphan.png"; got != want {
+				t.Errorf("got orphans %s, want %s", got, want)
+			}
+			if report.Deleted != tt.wantDeleted {
+				t.Errorf("deleted %d, want %d", report.Deleted, tt.wantDeleted)
+			}
+			if got := storage.keys(); got != tt.wantKept {
+				t.Errorf("kept %s\nwant %s", got, tt.wantKept)
+			}
+			failed := []string{}
+			for _, failure := range report.Failures {
+				failed = append(failed, failure.Key)
+				if failure.Err == nil {
+					t.Errorf("failure of %s has no error", failure.Key)
+				}
+			}
+			if got := strings.Join(failed, ","); got != tt.wantFailed {
+				t.Errorf("got failures %s, want %s", got, tt.wantFailed)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"marketplace-api/model"
+)
+
+type paymentProviderRegistry struct {
+	methods           []model.PaymentMethod
+	providerByMethods map[model.PaymentMethod]model.PaymentProvider
+}
+
+func NewPaymentProviderRegistry(providers ...model.PaymentProvider) model.PaymentProviderRegistry {
+	registry := &paymentProviderRegistry{providerByMethods: map[model.PaymentMethod]model.PaymentProvider{}}
+	for _, provider := range providers {
+		registry.methods = append(registry.methods, provider.Method())
+		registry.providerByMethods[provider.Method()] = provider
+	}
+	return registry
+}
+
+func (p *paymentProviderRegistry) Get(method model.PaymentMethod) (model.PaymentProvider, error) {
+	provider, ok := p.providerByMethods[method]
+	if !ok {
+		return nil, model.ErrInvalidPaymentMethod
+	}
+	return provider, nil
+}
+
+func (p *paymentProviderRegistry) Methods() []model.PaymentMethod {
+	return p.methods
+}
+
+type paymentUsecase struct {
+	paymentRepository       model.PaymentRepository
+	paymentProviderRegistry model.PaymentProviderRegistry
+}
+
+func NewPaymentUsecase(
+	paymentRepository model.PaymentRepository,
+	paymentProviderRegistry model.PaymentProviderRegistry,
+) model.PaymentUsecase {
+	return &paymentUsecase{
+		paymentRepository:       paymentRepository,
+		paymentProviderRegistry: paymentProviderRegistry,
+	}
+}
+
+func (p *paymentUsecase) FetchMethods(ctx context.Context) []*model.PaymentMethodResponse {
+	paymentMethodResponses := []*model.PaymentMethodResponse{}
+	for _, method := range p.paymentProviderRegistry.Methods() {
+		provider, _ := p.paymentProviderRegistry.Get(method)
+		paymentMethodResponse := new(model.PaymentMethodResponse)
+		paymentMethodResponse.Method = method
+		paymentMethodResponse.PayOnDelivery = provider.PayOnDelivery()
+		paymentMethodResponses = append(paymentMethodResponses, paymentMethodResponse)
+	}
+	return paymentMethodResponses
+}
+
+// HandleNotification applies a webhook call of a provider, repeated calls for a finished payment are ignored.
+// An expired payment can still be settled so money that arrives late is recorded.
+func (p *paymentUsecase) HandleNotification(
+	ctx context.Context,
+	method model.PaymentMethod,
+	signature string,
+	body []byte,
+) error {
+	provider, err := p.paymentProviderRegistry.Get(method)
+	if err != nil {
+		return err
+	}
+	paymentNotification, err := provider.ParseNotification(signature, body)
+	if err != nil {
+		return err
+	}
+
+	payment, err := p.paymentRepository.FindByReferensi(ctx, paymentNotification.Referensi)
+	if err != nil {
+		return err
+	}
+	if payment.MethodBayar != method {
+		return errors.New("payment not found")
+	}
+	if payment.Status == model.PAYMENT_STATUS_SETTLED || payment.Status == model.PAYMENT_STATUS_FAILED {
+		return nil
+	}
+
+	switch paymentNotification.Status {
+	case model.PAYMENT_STATUS_SETTLED:
+		if paymentNotification.Jumlah != payment.Jumlah {
+			return errors.New("jumlah does not match the payment")
+		}
+		history := &model.TrxStatusHistory{
+			StatusSesudah: model.TRX_STATUS_PAID,
+			Peran:         model.TRX_ACTOR_SYSTEM,
+			Catatan:       "payment " + payment.Referensi + " settled",
+		}
+		return p.paymentRepository.Settle(ctx, payment.ID, history)
+	case model.PAYMENT_STATUS_FAILED:
+		if payment.Status == model.PAYMENT_STATUS_EXPIRED {
+			return nil
+		}
+		return p.paymentRepository.UpdateStatus(ctx, payment.ID, payment.Status, model.PAYMENT_STATUS_FAILED)
+	default:
+		return errors.New("invalid payment status")
+	}
+}
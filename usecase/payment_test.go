@@ -0,0 +1,157 @@
+package usecase
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"marketplace-api/model"
+	"marketplace-api/repository"
+	"testing"
+	"time"
+)
+
+// paymentTest is a bank transfer trx waiting for its payment
+type paymentTest struct {
+	f              *fixture
+	trxUsecase     model.TrxUsecase
+	paymentUsecase model.PaymentUsecase
+	trx            *model.TrxGetByIDResponse
+}
+
+func newPaymentTest(t *testing.T) *paymentTest {
+	f := newFixture(t)
+	seller := f.createUser(false)
+	toko := f.createToko(seller.ID)
+	category := f.createCategory()
+	produk := f.createProduk(toko.ID, category.ID, 5, 10000, 9000)
+	buyer := f.createUser(false)
+	alamat := f.createAlamat(buyer.ID)
+
+	pt := &paymentTest{f: f, trxUsecase: f.newTrxUsecase()}
+	pt.paymentUsecase = NewPaymentUsecase(
+		repository.NewPaymentRepository(f.cfg),
+		NewPaymentProviderRegistry(
+			repository.NewFakePaymentProvider(model.PAYMENT_METHOD_BANK_TRANSFER, false, testWebhookSecret),
+		),
+	)
+	trx, err := pt.trxUsecase.StoreTrx(context.Background(), newTrxStoreRequest(alamat.ID, produk.ID, 0, 1), buyer.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pt.trx = trx
+	return pt
+}
+
+func (pt *paymentTest) payment() *model.Payment {
+	pt.f.t.Helper()
+	payment := new(model.Payment)
+	if err := pt.f.db.Where("id_trx = ?", pt.trx.ID).First(payment).Error; err != nil {
+		pt.f.t.Fatal(err)
+	}
+	return payment
+}
+
+func (pt *paymentTest) trxStatus() model.TrxStatus {
+	pt.f.t.Helper()
+	trx := new(model.Trx)
+	if err := pt.f.db.Unscoped().First(trx, pt.trx.ID).Error; err != nil {
+		pt.f.t.Fatal(err)
+	}
+	return trx.Status
+}
+
+// notify sends a webhook call signed with the shared secret
+func (pt *paymentTest) notify(status model.PaymentStatus, jumlah model.Money) error {
+	body := fmt.Sprintf(`{"referensi":%q,"status":%q,"jumlah":%d}`, pt.payment().Referensi, status, jumlah)
+	mac := hmac.New(sha256.New, []byte(testWebhookSecret))
+	mac.Write([]byte(body))
+	return pt.paymentUsecase.HandleNotification(
+		context.Background(),
+		model.PAYMENT_METHOD_BANK_TRANSFER,
+		hex.EncodeToString(mac.Sum(nil)),
+		[]byte(body),
+	)
+}
+
+func TestHandleNotificationSettled(t *testing.T) {
+	pt := newPaymentTest(t)
+
+	if err := pt.notify(model.PAYMENT_STATUS_SETTLED, pt.trx.HargaTotal); err != nil {
+		t.Fatal(err)
+	}
+	payment := pt.payment()
+	if payment.Status != model.PAYMENT_STATUS_SETTLED || payment.SettledAt == nil {
+		t.Errorf("payment is %s, want settled", payment.Status)
+	}
+	if status := pt.trxStatus(); status != model.TRX_STATUS_PAID {
+		t.Errorf("trx is %s, want paid", status)
+	}
+
+	// the provider may repeat a webhook call
+	if err := pt.notify(model.PAYMENT_STATUS_SETTLED, pt.trx.HargaTotal); err != nil {
+		t.Errorf("repeated call: %v", err)
+	}
+}
+
+func TestHandleNotificationRejected(t *testing.T) {
+	pt := newPaymentTest(t)
+
+	if err := pt.notify(model.PAYMENT_STATUS_SETTLED, pt.trx.HargaTotal-1); err == nil {
+		t.Error("a payment with another jumlah was settled")
+	}
+	err := pt.paymentUsecase.HandleNotification(
+		context.Background(),
+		model.PAYMENT_METHOD_BANK_TRANSFER,
+		"forged",
+		[]byte(fmt.Sprintf(`{"referensi":%q,"status":"settled","jumlah":%d}`, pt.payment().Referensi, pt.trx.HargaTotal)),
+	)
+	if !errors.Is(err, model.ErrInvalidPaymentSignature) {
+		t.Errorf("forged signature: got %v", err)
+	}
+	if payment := pt.payment(); payment.Status != model.PAYMENT_STATUS_PENDING {
+		t.Errorf("payment is %s, want pending", payment.Status)
+	}
+	if status := pt.trxStatus(); status != model.TRX_STATUS_PENDING_PAYMENT {
+		t.Errorf("trx is %s, want pending_payment", status)
+	}
+}
+
+func TestExpireUnpaidTrxThenLateSettlement(t *testing.T) {
+	pt := newPaymentTest(t)
+
+	expired, err := pt.trxUsecase.ExpireUnpaidTrx(context.Background(), time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expired != 1 {
+		t.Fatalf("expired %d trx, want 1", expired)
+	}
+	if payment := pt.payment(); payment.Status != model.PAYMENT_STATUS_EXPIRED {
+		t.Errorf("payment is %s, want expired", payment.Status)
+	}
+	if status := pt.trxStatus(); status != model.TRX_STATUS_CANCELLED {
+		t.Errorf("trx is %s, want cancelled", status)
+	}
+
+	// a failure reported after the deadline keeps the payment expired
+	if err := pt.notify(model.PAYMENT_STATUS_FAILED, pt.trx.HargaTotal); err != nil {
+		t.Fatal(err)
+	}
+	if payment := pt.payment(); payment.Status != model.PAYMENT_STATUS_EXPIRED {
+		t.Errorf("payment is %s after a late failure, want expired", payment.Status)
+	}
+
+	// money that arrives late is recorded for a refund, the trx stays cancelled
+	if err := pt.notify(model.PAYMENT_STATUS_SETTLED, pt.trx.HargaTotal); err != nil {
+		t.Fatal(err)
+	}
+	if payment := pt.payment(); payment.Status != model.PAYMENT_STATUS_SETTLED {
+		t.Errorf("payment is %s after a late settlement, want settled", payment.Status)
+	}
+	if status := pt.trxStatus(); status != model.TRX_STATUS_CANCELLED {
+		t.Errorf("trx is %s after a late settlement, want cancelled", status)
+	}
+}
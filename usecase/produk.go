@@ -10,23 +10,32 @@ import (
 )
 
 type produkUsecase struct {
-	produkRepository     model.ProdukRepository
-	fotoProdukRepository model.FotoProdukRepository
-	tokoRepository       model.TokoRepository
-	categoryRepository   model.CategoryRepository
+	produkRepository        model.ProdukRepository
+	fotoProdukRepository    model.FotoProdukRepository
+	produkVariantRepository model.ProdukVariantRepository
+	tokoRepository          model.TokoRepository
+	categoryRepository      model.CategoryRepository
+	produkSearchIndex       model.ProdukSearchIndex
+	storage                 model.Storage
 }
 
 func NewProdukUsecase(
 	produkRepository model.ProdukRepository,
 	fotoProdukRepository model.FotoProdukRepository,
+	produkVariantRepository model.ProdukVariantRepository,
 	tokoRepository model.TokoRepository,
 	categoryRepository model.CategoryRepository,
+	produkSearchIndex model.ProdukSearchIndex,
+	storage model.Storage,
 ) model.ProdukUsecase {
 	return &produkUsecase{
-		produkRepository:     produkRepository,
-		fotoProdukRepository: fotoProdukRepository,
-		tokoRepository:       tokoRepository,
-		categoryRepository:   categoryRepository,
+		produkRepository:        produkRepository,
+		fotoProdukRepository:    fotoProdukRepository,
+		produkVariantRepository: produkVariantRepository,
+		tokoRepository:          tokoRepository,
+		categoryRepository:      categoryRepository,
+		produkSearchIndex:       produkSearchIndex,
+		storage:                 storage,
 	}
 }
 
@@ -47,20 +56,21 @@ func (p *produkUsecase) StoreProduk(ctx context.Context, req *model.ProdukReques
 
 	produk := new(model.Produk)
 	copier.Copy(produk, req)
-	produk, fotoProdukList, err := p.produkRepository.CreateProdukAndFotoProduk(ctx, produk, req.PhotoUrls)
+	produk, fotoProdukList, err := p.produkRepository.CreateProdukAndFotoProduk(ctx, produk, req.PhotoKeys)
+	if err != nil {
+		return nil, err
+	}
+	err = p.produkSearchIndex.Index(ctx, produkSearchDocument(produk, category, toko))
 	if err != nil {
 		return nil, err
 	}
 	produkResponse := new(model.ProdukResponse)
 	copier.Copy(produkResponse, produk)
 
-	fotoProdukResponses := []*model.FotoProdukResponse{}
-	copier.Copy(&fotoProdukResponses, &fotoProdukList)
-	produkResponse.Photos = fotoProdukResponses
+	produkResponse.Photos = newFotoProdukResponses(p.storage, fotoProdukList)
+	produkResponse.Variants = []*model.ProdukVariantResponse{}
 
-	tokoGetByIDResponse := new(model.TokoGetByIDResponse)
-	copier.Copy(tokoGetByIDResponse, toko)
-	produkResponse.Toko = tokoGetByIDResponse
+	produkResponse.Toko = newTokoGetByIDResponse(p.storage, toko)
 
 	categoryResponse := new(model.CategoryResponse)
 	copier.Copy(categoryResponse, category)
@@ -69,45 +79,81 @@ func (p *produkUsecase) StoreProduk(ctx context.Context, req *model.ProdukReques
 	return produkResponse, nil
 }
 
-func (p *produkUsecase) FetchProduk(ctx context.Context, req *model.ProdukFetchRequest) ([]*model.ProdukResponse, error) {
-	produkList, err := p.produkRepository.Fetch(ctx, req)
+func (p *produkUsecase) FetchProduk(ctx context.Context, req *model.ProdukFetchRequest) (*model.ProdukFetchResponse, error) {
+	produkList, pageResult, err := p.produkRepository.Fetch(ctx, req)
 	if err != nil {
 		return nil, err
 	}
-	produkResponses := []*model.ProdukResponse{}
+	produkFetchResponse := new(model.ProdukFetchResponse)
+	produkFetchResponse.Pagination = model.NewCursorPagination(req.PaginationRequest, pageResult)
+	produkResponses, err := p.buildProdukResponses(ctx, produkList)
+	if err != nil {
+		return nil, err
+	}
+	produkFetchResponse.Data = produkResponses
 
+	facets, err := p.produkRepository.FetchFacets(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	produkFetchResponse.Facets = facets
+
+	return produkFetchResponse, nil
+}
+
+func (p *produkUsecase) SearchProduk(ctx context.Context, req *model.ProdukSearchRequest) (*model.ProdukFetchResponse, error) {
+	hits, total, err := p.produkSearchIndex.Search(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	produkIds := []int{}
+	for _, hit := range hits {
+		produkIds = append(produkIds, hit.ID)
+	}
+	produkList, err := p.produkRepository.FindByIDs(ctx, produkIds)
+	if err != nil {
+		return nil, err
+	}
+	produkById := map[int]*model.Produk{}
 	for _, produk := range produkList {
-		produkResponse := new(model.ProdukResponse)
-		copier.Copy(produkResponse, produk)
+		produkById[produk.ID] = produk
+	}
 
-		toko, err := p.tokoRepository.FindByTokoID(ctx, produk.IdToko)
-		if err != nil {
-			return nil, err
+	// keep the ranking of the hits
+	rankedProdukList := []*model.Produk{}
+	for _, produkId := range produkIds {
+		if produk, ok := produkById[produkId]; ok {
+			rankedProdukList = append(rankedProdukList, produk)
 		}
-		tokoGetByIDResponse := new(model.TokoGetByIDResponse)
-		copier.Copy(tokoGetByIDResponse, toko)
-		produkResponse.Toko = tokoGetByIDResponse
+	}
+	produkResponses, err := p.buildProdukResponses(ctx, rankedProdukList)
+	if err != nil {
+		return nil, err
+	}
 
-		category, err := p.categoryRepository.FindByID(ctx, produk.IdCategory)
-		if err != nil {
-			return nil, err
-		}
-		categoryResponse := new(model.CategoryResponse)
-		copier.Copy(categoryResponse, category)
-		produkResponse.Category = categoryResponse
+	produkFetchResponse := new(model.ProdukFetchResponse)
+	produkFetchResponse.Pagination = model.NewPagination(req.PaginationRequest, total)
+	produkFetchResponse.Data = produkResponses
+	return produkFetchResponse, nil
+}
 
-		fotoProdukResponses := []*model.FotoProdukResponse{}
-		fotoProdukList, err := p.fotoProdukRepository.FetchByProdukId(ctx, produk.ID)
+func (p *produkUsecase) RebuildSearchIndex(ctx context.Context) error {
+	documents, err := p.produkRepository.FetchSearchDocuments(ctx)
+	if err != nil {
+		return err
+	}
+	return indexProdukSearchDocuments(ctx, p.produkSearchIndex, documents)
+}
+
+// indexProdukSearchDocuments indexes the documents again, e.g. after the name of their toko or category changed
+func indexProdukSearchDocuments(ctx context.Context, produkSearchIndex model.ProdukSearchIndex, documents []*model.ProdukSearchDocument) error {
+	for _, document := range documents {
+		err := produkSearchIndex.Index(ctx, document)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		copier.Copy(&fotoProdukResponses, &fotoProdukList)
-		produkResponse.Photos = fotoProdukResponses
-
-		produkResponses = append(produkResponses, produkResponse)
 	}
-
-	return produkResponses, nil
+	return nil
 }
 
 func (p *produkUsecase) GetProdukByID(ctx context.Context, produkId int) (*model.ProdukResponse, error) {
@@ -122,9 +168,7 @@ func (p *produkUsecase) GetProdukByID(ctx context.Context, produkId int) (*model
 	if err != nil {
 		return nil, err
 	}
-	tokoGetByIDResponse := new(model.TokoGetByIDResponse)
-	copier.Copy(tokoGetByIDResponse, toko)
-	produkResponse.Toko = tokoGetByIDResponse
+	produkResponse.Toko = newTokoGetByIDResponse(p.storage, toko)
 
 	category, err := p.categoryRepository.FindByID(ctx, produk.IdCategory)
 	if err != nil {
@@ -134,13 +178,19 @@ func (p *produkUsecase) GetProdukByID(ctx context.Context, produkId int) (*model
 	copier.Copy(categoryResponse, category)
 	produkResponse.Category = categoryResponse
 
-	fotoProdukResponses := []*model.FotoProdukResponse{}
 	fotoProdukList, err := p.fotoProdukRepository.FetchByProdukId(ctx, produk.ID)
 	if err != nil {
 		return nil, err
 	}
-	copier.Copy(&fotoProdukResponses, &fotoProdukList)
-	produkResponse.Photos = fotoProdukResponses
+	produkResponse.Photos = newFotoProdukResponses(p.storage, fotoProdukList)
+
+	produkVariantResponses := []*model.ProdukVariantResponse{}
+	produkVariantList, err := p.produkVariantRepository.FetchByProdukId(ctx, produk.ID)
+	if err != nil {
+		return nil, err
+	}
+	copier.Copy(&produkVariantResponses, &produkVariantList)
+	produkResponse.Variants = produkVariantResponses
 
 	return produkResponse, nil
 }
@@ -167,31 +217,48 @@ func (p *produkUsecase) EditProdukByID(ctx context.Context, produkId int, userId
 		return nil, err
 	}
 
+	produkVariantList, err := p.produkVariantRepository.FetchByProdukId(ctx, produkId)
+	if err != nil {
+		return nil, err
+	}
+	// the stock of a product with variants follows its variants
+	if len(produkVariantList) > 0 {
+		req.Stok = oldProduk.Stok
+	}
+
 	produk := new(model.Produk)
 	copier.Copy(produk, req)
 
-	fotoProdukList := []*model.FotoProduk{}
-	for _, photoUrl := range req.PhotoUrls {
-		fotoProduk := new(model.FotoProduk)
-		fotoProduk.IdProduk = produkId
-		fotoProduk.Url = photoUrl
-		fotoProdukList = append(fotoProdukList, fotoProduk)
+	oldFotoProdukList, err := p.fotoProdukRepository.FetchByProdukId(ctx, produkId)
+	if err != nil {
+		return nil, err
 	}
 
-	produk, fotoProdukList, err = p.produkRepository.UpdateProdukAndFotoProduk(ctx, produkId, produk, fotoProdukList)
+	produk, fotoProdukList, err := p.produkRepository.UpdateProdukAndFotoProduk(ctx, produkId, produk, req.PhotoKeys)
+	if err != nil {
+		return nil, err
+	}
+	// without new photos the old ones are kept
+	if len(req.PhotoKeys) > 0 {
+		err = deleteFotoProdukObjects(ctx, p.storage, oldFotoProdukList)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = p.produkSearchIndex.Index(ctx, produkSearchDocument(produk, category, toko))
 	if err != nil {
 		return nil, err
 	}
 	produkResponse := new(model.ProdukResponse)
 	copier.Copy(produkResponse, produk)
 
-	fotoProdukResponses := []*model.FotoProdukResponse{}
-	copier.Copy(&fotoProdukResponses, &fotoProdukList)
-	produkResponse.Photos = fotoProdukResponses
+	produkResponse.Photos = newFotoProdukResponses(p.storage, fotoProdukList)
+
+	produkVariantResponses := []*model.ProdukVariantResponse{}
+	copier.Copy(&produkVariantResponses, &produkVariantList)
+	produkResponse.Variants = produkVariantResponses
 
-	tokoGetByIDResponse := new(model.TokoGetByIDResponse)
-	copier.Copy(tokoGetByIDResponse, toko)
-	produkResponse.Toko = tokoGetByIDResponse
+	produkResponse.Toko = newTokoGetByIDResponse(p.storage, toko)
 
 	categoryResponse := new(model.CategoryResponse)
 	copier.Copy(categoryResponse, category)
@@ -213,10 +280,130 @@ func (p *produkUsecase) DestroyProduk(ctx context.Context, produkId int, userId
 		return errors.New("unauthorized")
 	}
 
-	err = p.produkRepository.DeleteProdukAndFotoProduk(ctx, produkId)
+	// the photos stay in the storage, orders of the product still show them
+	err = p.produkRepository.Delete(ctx, produkId)
+	if err != nil {
+		return err
+	}
+	err = p.produkSearchIndex.Remove(ctx, produkId)
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+func (p *produkUsecase) RestoreProduk(ctx context.Context, produkId int) (*model.ProdukResponse, error) {
+	produk, err := p.produkRepository.FindByIDWithDeleted(ctx, produkId)
+	if err != nil {
+		return nil, err
+	}
+	// a product cannot come back into a deleted category, the category has to be restored first
+	category, err := p.categoryRepository.FindByID(ctx, produk.IdCategory)
+	if err != nil {
+		return nil, err
+	}
+	toko, err := p.tokoRepository.FindByTokoID(ctx, produk.IdToko)
+	if err != nil {
+		return nil, err
+	}
+
+	err = p.produkRepository.Restore(ctx, produkId)
+	if err != nil {
+		return nil, err
+	}
+	err = p.produkSearchIndex.Index(ctx, produkSearchDocument(produk, category, toko))
+	if err != nil {
+		return nil, err
+	}
+
+	return p.GetProdukByID(ctx, produkId)
+}
+
+// buildProdukResponses assembles the responses of produkList with one query per related table
+func (p *produkUsecase) buildProdukResponses(ctx context.Context, produkList []*model.Produk) ([]*model.ProdukResponse, error) {
+	produkIds := []int{}
+	tokoIds := []int{}
+	categoryIds := []int{}
+	for _, produk := range produkList {
+		produkIds = append(produkIds, produk.ID)
+		tokoIds = append(tokoIds, produk.IdToko)
+		categoryIds = append(categoryIds, produk.IdCategory)
+	}
+
+	tokoList, err := p.tokoRepository.FindByTokoIDs(ctx, uniqueIds(tokoIds))
+	if err != nil {
+		return nil, err
+	}
+	tokoById := map[int]*model.Toko{}
+	for _, toko := range tokoList {
+		tokoById[toko.ID] = toko
+	}
+
+	categoryList, err := p.categoryRepository.FindByIDs(ctx, uniqueIds(categoryIds))
+	if err != nil {
+		return nil, err
+	}
+	categoryById := map[int]*model.Category{}
+	for _, category := range categoryList {
+		categoryById[category.ID] = category
+	}
+
+	fotoProdukList, err := p.fotoProdukRepository.FetchByProdukIds(ctx, produkIds)
+	if err != nil {
+		return nil, err
+	}
+	fotoProdukListByProdukId := map[int][]*model.FotoProduk{}
+	for _, fotoProduk := range fotoProdukList {
+		fotoProdukListByProdukId[fotoProduk.IdProduk] = append(fotoProdukListByProdukId[fotoProduk.IdProduk], fotoProduk)
+	}
+
+	produkVariantList, err := p.produkVariantRepository.FetchByProdukIds(ctx, produkIds)
+	if err != nil {
+		return nil, err
+	}
+	produkVariantListByProdukId := map[int][]*model.ProdukVariant{}
+	for _, produkVariant := range produkVariantList {
+		produkVariantListByProdukId[produkVariant.IdProduk] = append(produkVariantListByProdukId[produkVariant.IdProduk], produkVariant)
+	}
+
+	produkResponses := []*model.ProdukResponse{}
+	for _, produk := range produkList {
+		produkResponse := new(model.ProdukResponse)
+		copier.Copy(produkResponse, produk)
+
+		toko, ok := tokoById[produk.IdToko]
+		if !ok {
+			return nil, errors.New("toko not found")
+		}
+		produkResponse.Toko = newTokoGetByIDResponse(p.storage, toko)
+
+		category, ok := categoryById[produk.IdCategory]
+		if !ok {
+			return nil, errors.New("category not found")
+		}
+		categoryResponse := new(model.CategoryResponse)
+		copier.Copy(categoryResponse, category)
+		produkResponse.Category = categoryResponse
+
+		produkResponse.Photos = newFotoProdukResponses(p.storage, fotoProdukListByProdukId[produk.ID])
+
+		produkVariantResponses := []*model.ProdukVariantResponse{}
+		produkVariantList := produkVariantListByProdukId[produk.ID]
+		copier.Copy(&produkVariantResponses, &produkVariantList)
+		produkResponse.Variants = produkVariantResponses
+
+		produkResponses = append(produkResponses, produkResponse)
+	}
+	return produkResponses, nil
+}
+
+func produkSearchDocument(produk *model.Produk, category *model.Category, toko *model.Toko) *model.ProdukSearchDocument {
+	return &model.ProdukSearchDocument{
+		ID:           produk.ID,
+		NamaProduk:   produk.NamaProduk,
+		Deskripsi:    produk.Deskripsi,
+		NamaCategory: category.NamaCategory,
+		NamaToko:     toko.NamaToko,
+	}
+}
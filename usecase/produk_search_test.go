@@ -0,0 +1,104 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"marketplace-api/model"
+	"marketplace-api/repository"
+	"testing"
+)
+
+// searchIndexTest indexes a product whose toko and category are renamed by the tests
+type searchIndexTest struct {
+	f                 *fixture
+	produkRepository  model.ProdukRepository
+	produkSearchIndex model.ProdukSearchIndex
+	toko              *model.Toko
+	category          *model.Category
+	produk            *model.Produk
+}
+
+func newSearchIndexTest(t *testing.T) *searchIndexTest {
+	f := newFixture(t)
+	st := &searchIndexTest{
+		f:                 f,
+		produkRepository:  repository.NewProdukRepository(f.cfg),
+		produkSearchIndex: repository.NewMemoryProdukSearchIndex(),
+	}
+	seller := f.createUser(false)
+	st.toko = f.createToko(seller.ID)
+	st.category = f.createCategory()
+	// names no other row shares, so a search for them only finds st.produk
+	if err := f.db.Model(st.toko).Update("nama_toko", "Sumber Makmur").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := f.db.Model(st.category).Update("nama_category", "Perabot").Error; err != nil {
+		t.Fatal(err)
+	}
+	st.produk = f.createProduk(st.toko.ID, st.category.ID, 1, 10000, 9000)
+	// a product of another toko and category is left alone
+	f.createProduk(f.createToko(f.createUser(false).ID).ID, f.createCategory().ID, 1, 10000, 9000)
+
+	documents, err := st.produkRepository.FetchSearchDocuments(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := indexProdukSearchDocuments(context.Background(), st.produkSearchIndex, documents); err != nil {
+		t.Fatal(err)
+	}
+	return st
+}
+
+func (st *searchIndexTest) search(query string) string {
+	st.f.t.Helper()
+	hits, _, err := st.produkSearchIndex.Search(context.Background(), &model.ProdukSearchRequest{Query: query})
+	if err != nil {
+		st.f.t.Fatal(err)
+	}
+	ids := []int{}
+	for _, hit := range hits {
+		ids = append(ids, hit.ID)
+	}
+	return fmt.Sprint(ids)
+}
+
+func TestEditTokoReindexesProduk(t *testing.T) {
+	st := newSearchIndexTest(t)
+	tokoUsecase := NewTokoUsecase(
+		repository.NewTokoRepository(st.f.cfg),
+		st.produkRepository,
+		st.produkSearchIndex,
+		repository.NewLocalStorage(st.f.cfg.LocalStorage()),
+	)
+
+	_, err := tokoUsecase.EditToko(context.Background(), &model.TokoUpdateRequest{
+		ID:       st.toko.ID,
+		IdUser:   st.toko.IdUser,
+		NamaToko: "Grosir Sejahtera",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := st.search("sejahtera"), fmt.Sprint([]int{st.produk.ID}); got != want {
+		t.Errorf("new toko name: got %s, want %s", got, want)
+	}
+	if got := st.search("makmur"); got != "[]" {
+		t.Errorf("old toko name still finds %s", got)
+	}
+}
+
+func TestEditCategoryReindexesProduk(t *testing.T) {
+	st := newSearchIndexTest(t)
+	categoryUsecase := NewCategoryUsecase(repository.NewCategoryRepository(st.f.cfg), st.produkRepository, st.produkSearchIndex)
+
+	_, err := categoryUsecase.EditCategory(context.Background(), st.category.ID, &model.CategoryRequest{NamaCategory: "Elektronik"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := st.search("elektronik"), fmt.Sprint([]int{st.produk.ID}); got != want {
+		t.Errorf("new category name: got %s, want %s", got, want)
+	}
+	if got := st.search("perabot"); got != "[]" {
+		t.Errorf("old category name still finds %s", got)
+	}
+}
@@ -0,0 +1,269 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"marketplace-api/model"
+	"marketplace-api/repository"
+	"testing"
+)
+
+// newProdukFetchRequest lists every product without filters, sorted by sort
+func newProdukFetchRequest(sort string, limit int) *model.ProdukFetchRequest {
+	return &model.ProdukFetchRequest{
+		PaginationRequest: model.PaginationRequest{Limit: limit, Page: 1},
+		CategoryId:        -1,
+		TokoId:            -1,
+		MinHarga:          -1,
+		MaxHarga:          -1,
+		Sort:              sort,
+		HargaBuckets:      []model.Money{10000, 20000},
+	}
+}
+
+func produkResponseIds(produkResponses []*model.ProdukResponse) []int {
+	ids := []int{}
+	for _, produkResponse := range produkResponses {
+		ids = append(ids, produkResponse.ID)
+	}
+	return ids
+}
+
+func TestFetchProdukFacets(t *testing.T) {
+	f := newFixture(t)
+	tokoA := f.createToko(f.createUser(false).ID)
+	tokoB := f.createToko(f.createUser(false).ID)
+	categoryX := f.createCategory()
+	categoryY := f.createCategory()
+	f.createProduk(tokoA.ID, categoryX.ID, 5, 5000, 4000)
+	f.createProduk(tokoA.ID, categoryX.ID, 0, 15000, 14000)
+	f.createProduk(tokoA.ID, categoryY.ID, 2, 20000, 19000)
+	f.createProduk(tokoB.ID, categoryX.ID, 1, 50000, 45000)
+	// an archived product is in no facet
+	archived := f.createProduk(tokoB.ID, categoryY.ID, 9, 5000, 4000)
+	if err := f.db.Delete(archived).Error; err != nil {
+		t.Fatal(err)
+	}
+	produkUsecase := f.newProdukUsecase(repository.NewMemoryProdukSearchIndex())
+
+	tests := []struct {
+		name         string
+		configure    func(req *model.ProdukFetchRequest)
+		wantTotal    int64
+		wantCategory string
+		wantToko     string
+		wantHarga    string
+		wantStok     model.ProdukStokFacet
+	}{
+		{
+			name:         "no filter",
+			configure:    func(req *model.ProdukFetchRequest) {},
+			wantTotal:    4,
+			wantCategory: fmt.Sprintf("[%d:3 %d:1]", categoryX.ID, categoryY.ID),
+			wantToko:     fmt.Sprintf("[%d:3 %d:1]", tokoA.ID, tokoB.ID),
+			wantHarga:    "[0-10000:1 10000-20000:1 20000-:2]",
+			wantStok:     model.ProdukStokFacet{Tersedia: 3, Habis: 1},
+		},
+		{
+			name:         "filtered by toko",
+			configure:    func(req *model.ProdukFetchRequest) { req.TokoId = tokoA.ID },
+			wantTotal:    3,
+			wantCategory: fmt.Sprintf("[%d:2 %d:1]", categoryX.ID, categoryY.ID),
+			wantToko:     fmt.Sprintf("[%d:3]", tokoA.ID),
+			wantHarga:    "[0-10000:1 10000-20000:1 20000-:1]",
+			wantStok:     model.ProdukStokFacet{Tersedia: 2, Habis: 1},
+		},
+		{
+			name:         "filtered by harga",
+			configure:    func(req *model.ProdukFetchRequest) { req.MinHarga = 10000 },
+			wantTotal:    3,
+			wantCategory: fmt.Sprintf("[%d:2 %d:1]", categoryX.ID, categoryY.ID),
+			wantToko:     fmt.Sprintf("[%d:2 %d:1]", tokoA.ID, tokoB.ID),
+			wantHarga:    "[0-10000:0 10000-20000:1 20000-:2]",
+			wantStok:     model.ProdukStokFacet{Tersedia: 2, Habis: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newProdukFetchRequest(model.PRODUK_SORT_DEFAULT, -1)
+			tt.configure(req)
+			produkFetchResponse, err := produkUsecase.FetchProduk(context.Background(), req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if produkFetchResponse.Total != tt.wantTotal || int64(len(produkFetchResponse.Data)) != tt.wantTotal {
+				t.Errorf("got total %d and %d products, want %d", produkFetchResponse.Total, len(produkFetchResponse.Data), tt.wantTotal)
+			}
+
+			facets := produkFetchResponse.Facets
+			category := []string{}
+			for _, facet := range facets.Category {
+				category = append(category, fmt.Sprintf("%d:%d", facet.IdCategory, facet.Jumlah))
+			}
+			toko := []string{}
+			for _, facet := range facets.Toko {
+				toko = append(toko, fmt.Sprintf("%d:%d", facet.IdToko, facet.Jumlah))
+			}
+			harga := []string{}
+			for _, facet := range facets.Harga {
+				maxHarga := ""
+				if facet.MaxHarga != nil {
+					maxHarga = fmt.Sprint(*facet.MaxHarga)
+				}
+				harga = append(harga, fmt.Sprintf("%d-%s:%d", facet.MinHarga, maxHarga, facet.Jumlah))
+			}
+			if got := fmt.Sprint(category); got != tt.wantCategory {
+				t.Errorf("category facets %s, want %s", got, tt.wantCategory)
+			}
+			if got := fmt.Sprint(toko); got != tt.wantToko {
+				t.Errorf("toko facets %s, want %s", got, tt.wantToko)
+			}
+			if got := fmt.Sprint(harga); got != tt.wantHarga {
+				t.Errorf("harga facets %s, want %s", got, tt.wantHarga)
+			}
+			if *facets.Stok != tt.wantStok {
+				t.Errorf("stok facet %+v, want %+v", *facets.Stok, tt.wantStok)
+			}
+		})
+	}
+}
+
+func TestFetchProdukCursor(t *testing.T) {
+	f := newFixture(t)
+	toko := f.createToko(f.createUser(false).ID)
+	category := f.createCategory()
+	// two products share a price, the id keeps their order stable across pages
+	want := []int{}
+	for _, harga := range []model.Money{30000, 10000, 20000, 10000, 40000} {
+		want = append(want, f.createProduk(toko.ID, category.ID, 1, harga, harga).ID)
+	}
+	want = []int{want[1], want[3], want[2], want[0], want[4]}
+	produkUsecase := f.newProdukUsecase(repository.NewMemoryProdukSearchIndex())
+
+	got := []int{}
+	req := newProdukFetchRequest(model.PRODUK_SORT_HARGA_ASC, 2)
+	for pages := 0; ; pages++ {
+		if pages == len(want) {
+			t.Fatal("the cursor never reached the last page")
+		}
+		produkFetchResponse, err := produkUsecase.FetchProduk(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, produkResponseIds(produkFetchResponse.Data)...)
+		if produkFetchResponse.NextCursor == "" {
+			break
+		}
+		req.Cursor, err = model.DecodeCursor(produkFetchResponse.NextCursor)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("pages hold %v, want %v", got, want)
+	}
+
+	tampered := []struct {
+		name   string
+		cursor model.Cursor
+	}{
+		{"cursor of another sort", model.Cursor{Sort: model.PRODUK_SORT_STOK, Key: "1", ID: want[0]}},
+		{"key is not a price", model.Cursor{Sort: model.PRODUK_SORT_HARGA_ASC, Key: "0 OR 1=1", ID: want[0]}},
+		{"key is not a time", model.Cursor{Sort: model.PRODUK_SORT_TERBARU, Key: "yesterday", ID: want[0]}},
+	}
+	for _, tt := range tampered {
+		req := newProdukFetchRequest(tt.cursor.Sort, 2)
+		if tt.name == "cursor of another sort" {
+			req.Sort = model.PRODUK_SORT_HARGA_ASC
+		}
+		cursor := tt.cursor
+		req.Cursor = &cursor
+		req.Page = 0
+		if _, err := produkUsecase.FetchProduk(context.Background(), req); !errors.Is(err, model.ErrInvalidCursor) {
+			t.Errorf("%s: got error %v, want %v", tt.name, err, model.ErrInvalidCursor)
+		}
+	}
+}
+
+func TestDestroyAndRestoreProduk(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+	seller := f.createUser(false)
+	toko := f.createToko(seller.ID)
+	category := f.createCategory()
+	produk := f.createProduk(toko.ID, category.ID, 1, 10000, 9000)
+	other := f.createProduk(toko.ID, f.createCategory().ID, 1, 10000, 9000)
+	produkSearchIndex := repository.NewMemoryProdukSearchIndex()
+	produkUsecase := f.newProdukUsecase(produkSearchIndex)
+	if err := produkUsecase.RebuildSearchIndex(ctx); err != nil {
+		t.Fatal(err)
+	}
+	categoryUsecase := NewCategoryUsecase(repository.NewCategoryRepository(f.cfg), repository.NewProdukRepository(f.cfg), produkSearchIndex)
+
+	listed := func() string {
+		t.Helper()
+		produkFetchResponse, err := produkUsecase.FetchProduk(ctx, newProdukFetchRequest(model.PRODUK_SORT_DEFAULT, -1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return fmt.Sprint(produkResponseIds(produkFetchResponse.Data))
+	}
+	searched := func() bool {
+		t.Helper()
+		hits, _, err := produkSearchIndex.Search(ctx, &model.ProdukSearchRequest{Query: produk.NamaProduk})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, hit := range hits {
+			if hit.ID == produk.ID {
+				return true
+			}
+		}
+		return false
+	}
+
+	if err := produkUsecase.DestroyProduk(ctx, produk.ID, f.createUser(false).ID); err == nil {
+		t.Fatal("another user archived the product")
+	}
+	if err := produkUsecase.DestroyProduk(ctx, produk.ID, seller.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := listed(), fmt.Sprint([]int{other.ID}); got != want {
+		t.Errorf("listing holds %s after archiving, want %s", got, want)
+	}
+	if _, err := produkUsecase.GetProdukByID(ctx, produk.ID); err == nil {
+		t.Error("the archived product is still found")
+	}
+	if searched() {
+		t.Error("the archived product is still searchable")
+	}
+
+	// with no live product left, the category can be deleted, and the product cannot come back into it
+	if err := categoryUsecase.DestroyCategory(ctx, category.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := produkUsecase.RestoreProduk(ctx, produk.ID); err == nil {
+		t.Fatal("the product was restored into a deleted category")
+	}
+	if _, err := categoryUsecase.RestoreCategory(ctx, category.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	produkResponse, err := produkUsecase.RestoreProduk(ctx, produk.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if produkResponse.ID != produk.ID {
+		t.Errorf("restored product %d, want %d", produkResponse.ID, produk.ID)
+	}
+	if got, want := listed(), fmt.Sprint([]int{produk.ID, other.ID}); got != want {
+		t.Errorf("listing holds %s after restoring, want %s", got, want)
+	}
+	if !searched() {
+		t.Error("the restored product is not searchable")
+	}
+	if _, err := produkUsecase.RestoreProduk(ctx, produk.ID); err == nil {
+		t.Error("a live product was restored again")
+	}
+}
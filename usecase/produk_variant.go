@@ -0,0 +1,148 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"marketplace-api/model"
+
+	"github.com/jinzhu/copier"
+)
+
+type produkVariantUsecase struct {
+	produkVariantRepository model.ProdukVariantRepository
+	produkRepository        model.ProdukRepository
+	tokoRepository          model.TokoRepository
+}
+
+func NewProdukVariantUsecase(
+	produkVariantRepository model.ProdukVariantRepository,
+	produkRepository model.ProdukRepository,
+	tokoRepository model.TokoRepository,
+) model.ProdukVariantUsecase {
+	return &produkVariantUsecase{
+		produkVariantRepository: produkVariantRepository,
+		produkRepository:        produkRepository,
+		tokoRepository:          tokoRepository,
+	}
+}
+
+func (p *produkVariantUsecase) FetchProdukVariants(ctx context.Context, produkId int) ([]*model.ProdukVariantResponse, error) {
+	_, err := p.produkRepository.FindByID(ctx, produkId)
+	if err != nil {
+		return nil, err
+	}
+	produkVariantList, err := p.produkVariantRepository.FetchByProdukId(ctx, produkId)
+	if err != nil {
+		return nil, err
+	}
+	produkVariantResponses := []*model.ProdukVariantResponse{}
+	copier.Copy(&produkVariantResponses, &produkVariantList)
+	return produkVariantResponses, nil
+}
+
+func (p *produkVariantUsecase) StoreProdukVariant(
+	ctx context.Context,
+	produkId int,
+	userId int,
+	req *model.ProdukVariantRequest,
+) (*model.ProdukVariantResponse, error) {
+	err := p.authorizeProduk(ctx, produkId, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	produkVariant := new(model.ProdukVariant)
+	copier.Copy(produkVariant, req)
+	produkVariant.IdProduk = produkId
+	produkVariant, err = p.produkVariantRepository.Create(ctx, produkVariant)
+	if err != nil {
+		return nil, err
+	}
+	produkVariantResponse := new(model.ProdukVariantResponse)
+	copier.Copy(produkVariantResponse, produkVariant)
+	return produkVariantResponse, nil
+}
+
+func (p *produkVariantUsecase) EditProdukVariant(
+	ctx context.Context,
+	produkId int,
+	produkVariantId int,
+	userId int,
+	req *model.ProdukVariantRequest,
+) (*model.ProdukVariantResponse, error) {
+	err := p.authorizeProduk(ctx, produkId, userId)
+	if err != nil {
+		return nil, err
+	}
+	_, err = findProdukVariant(ctx, p.produkVariantRepository, produkId, produkVariantId)
+	if err != nil {
+		return nil, err
+	}
+
+	produkVariant := new(model.ProdukVariant)
+	copier.Copy(produkVariant, req)
+	produkVariant, err = p.produkVariantRepository.Update(ctx, produkVariantId, produkVariant)
+	if err != nil {
+		return nil, err
+	}
+	produkVariantResponse := new(model.ProdukVariantResponse)
+	copier.Copy(produkVariantResponse, produkVariant)
+	return produkVariantResponse, nil
+}
+
+func (p *produkVariantUsecase) DestroyProdukVariant(ctx context.Context, produkId int, produkVariantId int, userId int) error {
+	err := p.authorizeProduk(ctx, produkId, userId)
+	if err != nil {
+		return err
+	}
+	_, err = findProdukVariant(ctx, p.produkVariantRepository, produkId, produkVariantId)
+	if err != nil {
+		return err
+	}
+	return p.produkVariantRepository.Delete(ctx, produkVariantId)
+}
+
+// authorizeProduk only lets the owner of the toko selling the product change its variants
+func (p *produkVariantUsecase) authorizeProduk(ctx context.Context, produkId int, userId int) error {
+	produk, err := p.produkRepository.FindByID(ctx, produkId)
+	if err != nil {
+		return err
+	}
+	toko, err := p.tokoRepository.FindByTokoID(ctx, produk.IdToko)
+	if err != nil {
+		return err
+	}
+	if toko.IdUser != userId {
+		return errors.New("unauthorized")
+	}
+	return nil
+}
+
+// findProdukVariant returns the variant of the product being bought, nil when the product has no variants.
+// A variant must be chosen once the product has variants
+func findProdukVariant(
+	ctx context.Context,
+	produkVariantRepository model.ProdukVariantRepository,
+	produkId int,
+	produkVariantId int,
+) (*model.ProdukVariant, error) {
+	if produkVariantId == 0 {
+		produkVariantList, err := produkVariantRepository.FetchByProdukId(ctx, produkId)
+		if err != nil {
+			return nil, err
+		}
+		if len(produkVariantList) > 0 {
+			return nil, model.ErrProdukVariantRequired
+		}
+		return nil, nil
+	}
+
+	produkVariant, err := produkVariantRepository.FindByID(ctx, produkVariantId)
+	if err != nil {
+		return nil, err
+	}
+	if produkVariant.IdProduk != produkId {
+		return nil, model.ErrProdukVariantMismatch
+	}
+	return produkVariant, nil
+}
@@ -0,0 +1,59 @@
+package usecase
+
+import (
+	"context"
+	"marketplace-api/model"
+
+	"github.com/jinzhu/copier"
+)
+
+// newFotoProdukResponses copies the photos and turns their object keys into urls of the photo and its renditions
+func newFotoProdukResponses(storage model.Storage, fotoProdukList []*model.FotoProduk) []*model.FotoProdukResponse {
+	fotoProdukResponses := []*model.FotoProdukResponse{}
+	copier.Copy(&fotoProdukResponses, &fotoProdukList)
+	for i, fotoProdukResponse := range fotoProdukResponses {
+		key := fotoProdukResponse.Url
+		hasRenditions := fotoProdukList[i].HasRenditions
+		fotoProdukResponse.Url = storage.URL(key)
+		fotoProdukResponse.UrlThumbnail = renditionUrl(storage, key, hasRenditions, model.IMAGE_RENDITION_THUMBNAIL)
+		fotoProdukResponse.UrlMedium = renditionUrl(storage, key, hasRenditions, model.IMAGE_RENDITION_MEDIUM)
+		fotoProdukResponse.UrlLarge = renditionUrl(storage, key, hasRenditions, model.IMAGE_RENDITION_LARGE)
+	}
+	return fotoProdukResponses
+}
+
+// renditionUrl falls back to the original photo when it was uploaded before the renditions were generated
+func renditionUrl(storage model.Storage, key string, hasRenditions bool, rendition string) string {
+	if !hasRenditions {
+		return storage.URL(key)
+	}
+	return storage.URL(model.RenditionKey(key, rendition))
+}
+
+// tokoFotoUrls turns the object key of a toko photo into the urls of the photo and of its thumbnail and medium renditions
+func tokoFotoUrls(storage model.Storage, toko *model.Toko) (string, string, string) {
+	return storage.URL(toko.UrlFoto),
+		renditionUrl(storage, toko.UrlFoto, toko.FotoHasRenditions, model.IMAGE_RENDITION_THUMBNAIL),
+		renditionUrl(storage, toko.UrlFoto, toko.FotoHasRenditions, model.IMAGE_RENDITION_MEDIUM)
+}
+
+// newTokoGetByIDResponse copies the toko and turns the object key of its photo into urls
+func newTokoGetByIDResponse(storage model.Storage, toko *model.Toko) *model.TokoGetByIDResponse {
+	tokoGetByIDResponse := new(model.TokoGetByIDResponse)
+	copier.Copy(tokoGetByIDResponse, toko)
+	tokoGetByIDResponse.UrlFoto, tokoGetByIDResponse.UrlFotoThumbnail, tokoGetByIDResponse.UrlFotoMedium = tokoFotoUrls(storage, toko)
+	return tokoGetByIDResponse
+}
+
+// deleteFotoProdukObjects deletes the stored files and renditions of photos whose rows are already gone
+func deleteFotoProdukObjects(ctx context.Context, storage model.Storage, fotoProdukList []*model.FotoProduk) error {
+	for _, fotoProduk := range fotoProdukList {
+		for _, key := range model.FotoProdukObjectKeys(fotoProduk.Url) {
+			err := storage.Delete(ctx, key)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package usecase
+
+import (
+	"marketplace-api/model"
+	"marketplace-api/repository"
+	"testing"
+)
+
+func TestNewFotoProdukResponsesRenditions(t *testing.T) {
+	storage := repository.NewLocalStorage(model.LocalStorageConfig{Dir: t.TempDir(), PublicUrl: "/uploads"})
+	fotoProdukResponses := newFotoProdukResponses(storage, []*model.FotoProduk{
+		{ID: 1, Url: "produk/a.jpg", HasRenditions: true},
+		{ID: 2, Url: "legacy.jpg"},
+	})
+
+	want := []model.FotoProdukResponse{
+		{
+			ID:           1,
+			Url:          "/uploads/produk/a.jpg",
+			UrlThumbnail: "/uploads/produk/a_thumbnail.jpg",
+			UrlMedium:    "/uploads/produk/a_medium.jpg",
+			UrlLarge:     "/uploads/produk/a_large.jpg",
+		},
+		{
+			ID:           2,
+			Url:          "/uploads/legacy.jpg",
+			UrlThumbnail: "/uploads/legacy.jpg",
+			UrlMedium:    "/uploads/legacy.jpg",
+			UrlLarge:     "/uploads/legacy.jpg",
+		},
+	}
+	for i, fotoProdukResponse := range fotoProdukResponses {
+		if *fotoProdukResponse != want[i] {
+			t.Errorf("got %+v, want %+v", *fotoProdukResponse, want[i])
+		}
+	}
+}
+
+func TestNewTokoGetByIDResponseRenditions(t *testing.T) {
+	storage := repository.NewLocalStorage(model.LocalStorageConfig{Dir: t.TempDir(), PublicUrl: "/uploads"})
+
+	tests := []struct {
+		name string
+		toko *model.Toko
+		want model.TokoGetByIDResponse
+	}{
+		{
+			name: "with renditions",
+			toko: &model.Toko{ID: 1, NamaToko: "a", UrlFoto: "toko/a.png", FotoHasRenditions: true},
+			want: model.TokoGetByIDResponse{
+				ID:               1,
+				NamaToko:         "a",
+				UrlFoto:          "/uploads/toko/a.png",
+				UrlFotoThumbnail: "/uploads/toko/a_thumbnail.png",
+				UrlFotoMedium:    "/uploads/toko/a_medium.png",
+			},
+		},
+		{
+			name: "legacy photo",
+			toko: &model.Toko{ID: 2, NamaToko: "b", UrlFoto: "b.png"},
+			want: model.TokoGetByIDResponse{
+				ID:               2,
+				NamaToko:         "b",
+				UrlFoto:          "/uploads/b.png",
+				UrlFotoThumbnail: "/uploads/b.png",
+				UrlFotoMedium:    "/uploads/b.png",
+			},
+		},
+		{name: "no photo", toko: &model.Toko{ID: 3, NamaToko: "c"}, want: model.TokoGetByIDResponse{ID: 3, NamaToko: "c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newTokoGetByIDResponse(storage, tt.toko); *got != tt.want {
+				t.Errorf("got %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
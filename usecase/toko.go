@@ -9,22 +9,37 @@ import (
 )
 
 type tokoUsecase struct {
-	tokoRepository model.TokoRepository
+	tokoRepository    model.TokoRepository
+	produkRepository  model.ProdukRepository
+	produkSearchIndex model.ProdukSearchIndex
+	storage           model.Storage
 }
 
-func NewTokoUsecase(tokoRepository model.TokoRepository) model.TokoUsecase {
-	return &tokoUsecase{tokoRepository: tokoRepository}
+func NewTokoUsecase(
+	tokoRepository model.TokoRepository,
+	produkRepository model.ProdukRepository,
+	produkSearchIndex model.ProdukSearchIndex,
+	storage model.Storage,
+) model.TokoUsecase {
+	return &tokoUsecase{
+		tokoRepository:    tokoRepository,
+		produkRepository:  produkRepository,
+		produkSearchIndex: produkSearchIndex,
+		storage:           storage,
+	}
 }
 
 func (t *tokoUsecase) FetchAndPaginateToko(ctx context.Context, req *model.TokoFetchPaginateRequest) (*model.TokoFetchPaginateResponse, error) {
-	listToko, err := t.tokoRepository.FetchAndPaginate(ctx, req)
+	listToko, total, err := t.tokoRepository.FetchAndPaginate(ctx, req)
 	if err != nil {
 		return nil, err
 	}
 	tokoFetchPaginateResponse := new(model.TokoFetchPaginateResponse)
-	copier.Copy(tokoFetchPaginateResponse, req)
+	tokoFetchPaginateResponse.Pagination = model.NewPagination(req.PaginationRequest, total)
 	data := []*model.TokoGetByIDResponse{}
-	copier.Copy(&data, listToko)
+	for _, toko := range listToko {
+		data = append(data, newTokoGetByIDResponse(t.storage, toko))
+	}
 	tokoFetchPaginateResponse.Data = data
 	return tokoFetchPaginateResponse, nil
 }
@@ -34,9 +49,7 @@ func (t *tokoUsecase) GetTokoByID(ctx context.Context, tokoId int) (*model.TokoG
 	if err != nil {
 		return nil, err
 	}
-	tokoGetByIDResponse := new(model.TokoGetByIDResponse)
-	copier.Copy(tokoGetByIDResponse, toko)
-	return tokoGetByIDResponse, nil
+	return newTokoGetByIDResponse(t.storage, toko), nil
 }
 
 func (t *tokoUsecase) GetMyToko(ctx context.Context, userId int) (*model.GetMyTokoResponse, error) {
@@ -46,6 +59,7 @@ func (t *tokoUsecase) GetMyToko(ctx context.Context, userId int) (*model.GetMyTo
 	}
 	getMyTokoResponse := new(model.GetMyTokoResponse)
 	copier.Copy(getMyTokoResponse, toko)
+	getMyTokoResponse.UrlFoto, getMyTokoResponse.UrlFotoThumbnail, getMyTokoResponse.UrlFotoMedium = tokoFotoUrls(t.storage, toko)
 	return getMyTokoResponse, nil
 }
 
@@ -59,11 +73,34 @@ func (t *tokoUsecase) EditToko(ctx context.Context, req *model.TokoUpdateRequest
 	}
 	toko := new(model.Toko)
 	copier.Copy(toko, req)
+	// an uploaded photo always comes with its renditions
+	toko.FotoHasRenditions = req.UrlFoto != ""
 	toko, err = t.tokoRepository.UpdateByTokoID(ctx, toko.ID, toko)
 	if err != nil {
 		return nil, err
 	}
+	// the products are found by the name of their toko too
+	if myToko.NamaToko != toko.NamaToko {
+		documents, err := t.produkRepository.FetchSearchDocumentsByTokoID(ctx, toko.ID)
+		if err != nil {
+			return nil, err
+		}
+		err = indexProdukSearchDocuments(ctx, t.produkSearchIndex, documents)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// the old photo is only replaced when a new one was uploaded
+	if req.UrlFoto != "" && myToko.UrlFoto != "" && myToko.UrlFoto != toko.UrlFoto {
+		for _, key := range model.TokoFotoObjectKeys(myToko.UrlFoto) {
+			err = t.storage.Delete(ctx, key)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
 	tokoUpdateResponse := new(model.TokoUpdateResponse)
 	copier.Copy(tokoUpdateResponse, toko)
+	tokoUpdateResponse.UrlFoto, tokoUpdateResponse.UrlFotoThumbnail, tokoUpdateResponse.UrlFotoMedium = tokoFotoUrls(t.storage, toko)
 	return tokoUpdateResponse, nil
 }
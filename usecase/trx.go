@@ -3,26 +3,35 @@ package usecase
 import (
 	"context"
 	"errors"
+	"fmt"
 	"marketplace-api/model"
-	"math/rand"
-	"strconv"
+	"time"
 
 	"github.com/jinzhu/copier"
 )
 
 type trxUsecase struct {
-	trxRepository        model.TrxRepository
-	alamatRepository     model.AlamatRepository
-	detailTrxRepository  model.DetailTrxRepository
-	logProdukRepository  model.LogProdukRepository
-	tokoRepository       model.TokoRepository
-	categoryRepository   model.CategoryRepository
-	fotoProdukRepository model.FotoProdukRepository
-	produkRepository     model.ProdukRepository
+	trxRepository              model.TrxRepository
+	userRepository             model.UserRepository
+	alamatRepository           model.AlamatRepository
+	detailTrxRepository        model.DetailTrxRepository
+	logProdukRepository        model.LogProdukRepository
+	tokoRepository             model.TokoRepository
+	categoryRepository         model.CategoryRepository
+	fotoProdukRepository       model.FotoProdukRepository
+	produkRepository           model.ProdukRepository
+	produkVariantRepository    model.ProdukVariantRepository
+	trxStatusHistoryRepository model.TrxStatusHistoryRepository
+	kodeInvoiceGenerator       model.KodeInvoiceGenerator
+	paymentRepository          model.PaymentRepository
+	paymentProviderRegistry    model.PaymentProviderRegistry
+	voucherRepository          model.VoucherRepository
+	storage                    model.Storage
 }
 
 func NewTrxUsecase(
 	trxRepository model.TrxRepository,
+	userRepository model.UserRepository,
 	alamatRepository model.AlamatRepository,
 	detailTrxRepository model.DetailTrxRepository,
 	logProdukRepository model.LogProdukRepository,
@@ -30,22 +39,46 @@ func NewTrxUsecase(
 	categoryRepository model.CategoryRepository,
 	fotoProdukRepository model.FotoProdukRepository,
 	produkRepository model.ProdukRepository,
+	produkVariantRepository model.ProdukVariantRepository,
+	trxStatusHistoryRepository model.TrxStatusHistoryRepository,
+	kodeInvoiceGenerator model.KodeInvoiceGenerator,
+	paymentRepository model.PaymentRepository,
+	paymentProviderRegistry model.PaymentProviderRegistry,
+	voucherRepository model.VoucherRepository,
+	storage model.Storage,
 ) model.TrxUsecase {
 	return &trxUsecase{
-		trxRepository:        trxRepository,
-		alamatRepository:     alamatRepository,
-		detailTrxRepository:  detailTrxRepository,
-		logProdukRepository:  logProdukRepository,
-		tokoRepository:       tokoRepository,
-		categoryRepository:   categoryRepository,
-		fotoProdukRepository: fotoProdukRepository,
-		produkRepository:     produkRepository,
+		trxRepository:              trxRepository,
+		userRepository:             userRepository,
+		alamatRepository:           alamatRepository,
+		detailTrxRepository:        detailTrxRepository,
+		logProdukRepository:        logProdukRepository,
+		tokoRepository:             tokoRepository,
+		categoryRepository:         categoryRepository,
+		fotoProdukRepository:       fotoProdukRepository,
+		produkRepository:           produkRepository,
+		produkVariantRepository:    produkVariantRepository,
+		trxStatusHistoryRepository: trxStatusHistoryRepository,
+		kodeInvoiceGenerator:       kodeInvoiceGenerator,
+		paymentRepository:          paymentRepository,
+		paymentProviderRegistry:    paymentProviderRegistry,
+		voucherRepository:          voucherRepository,
+		storage:                    storage,
 	}
 }
 
 func (t *trxUsecase) StoreTrx(ctx context.Context, req *model.TrxStoreRequest, userId int) (*model.TrxGetByIDResponse, error) {
+	paymentProvider, err := t.paymentProviderRegistry.Get(model.PaymentMethod(req.MethodBayar))
+	if err != nil {
+		return nil, err
+	}
+	user, err := t.userRepository.FindByID(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
 	detailTrxWithLogProdukList := []*model.DetailTrxWithLogProduk{}
-	trxHargaTotal := 0
+	trxHargaTotal := model.Money(0)
 	for _, detailTrxRequest := range req.DetailTrxRequests {
 		detailTrxWithLogProduk := new(model.DetailTrxWithLogProduk)
 
@@ -60,6 +93,18 @@ func (t *trxUsecase) StoreTrx(ctx context.Context, req *model.TrxStoreRequest, u
 		copier.Copy(logProduk, logProdukRequest)
 		logProduk.IdProduk = produkId
 
+		produkVariant, err := findProdukVariant(ctx, t.produkVariantRepository, produkId, detailTrxRequest.VariantId)
+		if err != nil {
+			return nil, err
+		}
+		if produkVariant != nil {
+			logProduk.IdProdukVariant = &produkVariant.ID
+			logProduk.Sku = produkVariant.Sku
+			logProduk.AtributVariant = produkVariant.Atribut
+			logProduk.HargaReseller = produkVariant.HargaReseller
+			logProduk.HargaKonsumen = produkVariant.HargaKonsumen
+		}
+
 		toko, err := t.tokoRepository.FindByTokoID(ctx, produk.IdToko)
 		if err != nil {
 			return nil, err
@@ -71,11 +116,9 @@ func (t *trxUsecase) StoreTrx(ctx context.Context, req *model.TrxStoreRequest, u
 		detailTrx := new(model.DetailTrx)
 		detailTrx.IdToko = produk.IdToko
 		detailTrx.Kuantitas = detailTrxRequest.Kuantitas
-		hargaKonsumenInt, err := strconv.Atoi(produk.HargaKonsumen)
-		if err != nil {
-			return nil, err
-		}
-		detailTrx.HargaTotal = detailTrx.Kuantitas * hargaKonsumenInt
+		// the log keeps both prices of the product or variant, the line is charged the one of the buyer
+		hargaSatuan := produk.HargaSatuan(logProduk.HargaKonsumen, logProduk.HargaReseller, detailTrx.Kuantitas, user.IsReseller)
+		detailTrx.HargaTotal = hargaSatuan.Times(detailTrx.Kuantitas)
 		trxHargaTotal += detailTrx.HargaTotal
 
 		detailTrxWithLogProduk.LogProduk = logProduk
@@ -85,9 +128,18 @@ func (t *trxUsecase) StoreTrx(ctx context.Context, req *model.TrxStoreRequest, u
 
 	trx := new(model.Trx)
 	trx.IdUser = userId
+	if req.KodeVoucher != "" {
+		voucher, diskon, err := t.applyVoucher(ctx, req.KodeVoucher, userId, detailTrxWithLogProdukList)
+		if err != nil {
+			return nil, err
+		}
+		trx.IdVoucher = &voucher.ID
+		trx.KodeVoucher = voucher.Kode
+		trx.Diskon = diskon
+		trxHargaTotal -= diskon
+	}
 	trx.HargaTotal = trxHargaTotal
 	trx.MethodBayar = req.MethodBayar
-	trx.KodeInvoice = model.KODE_INVOICE_PREFIX + strconv.Itoa(1000000000+rand.Intn(9999999999-1000000000))
 
 	alamat, err := t.alamatRepository.FindByID(ctx, req.AlamatPengiriman)
 	if err != nil {
@@ -98,222 +150,385 @@ func (t *trxUsecase) StoreTrx(ctx context.Context, req *model.TrxStoreRequest, u
 	}
 	trx.AlamatPengiriman = req.AlamatPengiriman
 
-	trx, err = t.trxRepository.CreateTrx(ctx, trx, detailTrxWithLogProdukList)
+	// the counter should never repeat a code, retrying only guards against codes inserted by other means
+	for retry := 0; retry < model.KODE_INVOICE_MAX_RETRY; retry++ {
+		trx.KodeInvoice, err = t.kodeInvoiceGenerator.Generate(ctx)
+		if err != nil {
+			return nil, err
+		}
+		_, err = t.trxRepository.CreateTrx(ctx, trx, detailTrxWithLogProdukList)
+		if !errors.Is(err, model.ErrDuplicateKodeInvoice) {
+			break
+		}
+	}
 	if err != nil {
 		return nil, err
 	}
-	trxGetByIDResponse := new(model.TrxGetByIDResponse)
-	copier.Copy(trxGetByIDResponse, trx)
-
-	alamatResponse := new(model.AlamatResponse)
-	copier.Copy(alamatResponse, alamat)
-	trxGetByIDResponse.AlamatPengiriman = alamatResponse
 
-	detailTrxResponses := []*model.DetailTrxResponse{}
-	detailTrxList, err := t.detailTrxRepository.FindByTrxID(ctx, trx.ID)
+	err = t.createPayment(ctx, trx, paymentProvider)
 	if err != nil {
 		return nil, err
 	}
-	for _, detailTrx := range detailTrxList {
-		detailTrxResponse := new(model.DetailTrxResponse)
-		copier.Copy(detailTrxResponse, detailTrx)
 
-		logProduk, err := t.logProdukRepository.FindByID(ctx, detailTrx.IdLogProduk)
-		if err != nil {
-			return nil, err
-		}
-		logProdukResponse := new(model.LogProdukResponse)
-		copier.Copy(logProdukResponse, logProduk)
+	trxGetByIDResponses, err := t.buildTrxGetByIDResponses(ctx, []*model.Trx{trx})
+	if err != nil {
+		return nil, err
+	}
+	return trxGetByIDResponses[0], nil
+}
 
-		toko, err := t.tokoRepository.FindByTokoID(ctx, logProduk.IdToko)
+// applyVoucher checks the voucher can be used by the user and discounts the eligible detail trx,
+// the usage limits are checked again when the trx is created
+func (t *trxUsecase) applyVoucher(
+	ctx context.Context,
+	kodeVoucher string,
+	userId int,
+	detailTrxWithLogProdukList []*model.DetailTrxWithLogProduk,
+) (*model.Voucher, model.Money, error) {
+	voucher, err := t.voucherRepository.FindByKode(ctx, kodeVoucher)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !voucher.IsActive(time.Now()) {
+		return nil, 0, model.ErrVoucherNotActive
+	}
+	if voucher.KuotaTotal > 0 && voucher.Terpakai >= voucher.KuotaTotal {
+		return nil, 0, model.ErrVoucherKuotaHabis
+	}
+	if voucher.KuotaPerUser > 0 {
+		total, err := t.voucherRepository.CountPemakaianByUser(ctx, voucher.ID, userId)
 		if err != nil {
-			return nil, err
+			return nil, 0, err
 		}
-		tokoLogProdukResponse := new(model.TokoLogProdukResponse)
-		copier.Copy(tokoLogProdukResponse, toko)
-		logProdukResponse.Toko = tokoLogProdukResponse
-
-		category, err := t.categoryRepository.FindByID(ctx, logProduk.IdCategory)
-		if err != nil {
-			return nil, err
+		if total >= int64(voucher.KuotaPerUser) {
+			return nil, 0, model.ErrVoucherKuotaUserHabis
 		}
-		categoryResponse := new(model.CategoryResponse)
-		copier.Copy(categoryResponse, category)
-		logProdukResponse.Category = categoryResponse
+	}
 
-		fotoProdukResponses := []*model.FotoProdukResponse{}
-		fotoProdukList, err := t.fotoProdukRepository.FetchByProdukId(ctx, logProduk.IdProduk)
-		if err != nil {
-			return nil, err
-		}
-		copier.Copy(&fotoProdukResponses, &fotoProdukList)
-		logProdukResponse.Photos = fotoProdukResponses
+	voucherCategoryList, err := t.voucherRepository.FetchCategories(ctx, []int{voucher.ID})
+	if err != nil {
+		return nil, 0, err
+	}
+	categoryIds := []int{}
+	for _, voucherCategory := range voucherCategoryList {
+		categoryIds = append(categoryIds, voucherCategory.IdCategory)
+	}
 
-		detailTrxResponse.LogProduk = logProdukResponse
+	diskon, err := distributeDiskon(voucher, categoryIds, detailTrxWithLogProdukList)
+	if err != nil {
+		return nil, 0, err
+	}
+	return voucher, diskon, nil
+}
 
-		tokoGetByIDResponse := new(model.TokoGetByIDResponse)
-		copier.Copy(tokoGetByIDResponse, toko)
-		detailTrxResponse.Toko = tokoGetByIDResponse
+func (t *trxUsecase) FetchTrx(ctx context.Context, req *model.TrxFetchRequest, userId int) (*model.TrxFetchResponse, error) {
+	trxList, pageResult, err := t.trxRepository.Fetch(ctx, req, userId)
+	if err != nil {
+		return nil, err
+	}
+	trxFetchResponse := new(model.TrxFetchResponse)
+	trxFetchResponse.Pagination = model.NewCursorPagination(req.PaginationRequest, pageResult)
 
-		detailTrxResponses = append(detailTrxResponses, detailTrxResponse)
+	trxGetByIDResponses, err := t.buildTrxGetByIDResponses(ctx, trxList)
+	if err != nil {
+		return nil, err
 	}
+	trxFetchResponse.Data = trxGetByIDResponses
 
-	trxGetByIDResponse.DetailTrxResponses = detailTrxResponses
+	return trxFetchResponse, nil
+}
 
-	return trxGetByIDResponse, nil
+func (t *trxUsecase) GetTrxByID(ctx context.Context, trxId int, userId int) (*model.TrxGetByIDResponse, error) {
+	trx, err := t.trxRepository.FindByID(ctx, trxId)
+	if err != nil {
+		return nil, err
+	}
+	if trx.IdUser != userId {
+		return nil, errors.New("unauthorized")
+	}
+
+	trxGetByIDResponses, err := t.buildTrxGetByIDResponses(ctx, []*model.Trx{trx})
+	if err != nil {
+		return nil, err
+	}
+	return trxGetByIDResponses[0], nil
 }
 
-func (t *trxUsecase) FetchTrx(ctx context.Context, req *model.TrxFetchRequest, userId int) (*model.TrxFetchResponse, error) {
-	trxList, err := t.trxRepository.Fetch(ctx, req, userId)
+func (t *trxUsecase) GetTrxStatus(ctx context.Context, trxId int, userId int, isAdmin bool) (*model.TrxStatusResponse, error) {
+	trx, err := t.trxRepository.FindByID(ctx, trxId)
 	if err != nil {
 		return nil, err
 	}
-	trxFetchResponse := new(model.TrxFetchResponse)
-	copier.Copy(trxFetchResponse, req)
+	actors, err := t.trxActors(ctx, trx, userId, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if len(actors) == 0 {
+		return nil, errors.New("unauthorized")
+	}
 
-	trxGetByIDResponses := []*model.TrxGetByIDResponse{}
+	trxStatusResponse := new(model.TrxStatusResponse)
+	copier.Copy(trxStatusResponse, trx)
 
-	for _, trx := range trxList {
-		trxGetByIDResponse := new(model.TrxGetByIDResponse)
-		copier.Copy(trxGetByIDResponse, trx)
+	historyList, err := t.trxStatusHistoryRepository.FindByTrxID(ctx, trx.ID)
+	if err != nil {
+		return nil, err
+	}
+	historyResponses := []*model.TrxStatusHistoryResponse{}
+	copier.Copy(&historyResponses, &historyList)
+	trxStatusResponse.History = historyResponses
 
-		alamat, err := t.alamatRepository.FindByID(ctx, trx.AlamatPengiriman)
-		if err != nil {
-			return nil, err
-		}
-		alamatResponse := new(model.AlamatResponse)
-		copier.Copy(alamatResponse, alamat)
-		trxGetByIDResponse.AlamatPengiriman = alamatResponse
+	return trxStatusResponse, nil
+}
 
-		detailTrxResponses := []*model.DetailTrxResponse{}
-		detailTrxList, err := t.detailTrxRepository.FindByTrxID(ctx, trx.ID)
-		if err != nil {
-			return nil, err
-		}
-		for _, detailTrx := range detailTrxList {
-			detailTrxResponse := new(model.DetailTrxResponse)
-			copier.Copy(detailTrxResponse, detailTrx)
+func (t *trxUsecase) EditTrxStatus(
+	ctx context.Context,
+	trxId int,
+	userId int,
+	isAdmin bool,
+	req *model.TrxStatusUpdateRequest,
+) (*model.TrxStatusResponse, error) {
+	if !req.Status.IsValid() {
+		return nil, errors.New("invalid status")
+	}
 
-			logProduk, err := t.logProdukRepository.FindByID(ctx, detailTrx.IdLogProduk)
-			if err != nil {
-				return nil, err
-			}
-			logProdukResponse := new(model.LogProdukResponse)
-			copier.Copy(logProdukResponse, logProduk)
+	trx, err := t.trxRepository.FindByID(ctx, trxId)
+	if err != nil {
+		return nil, err
+	}
+	actors, err := t.trxActors(ctx, trx, userId, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if len(actors) == 0 {
+		return nil, errors.New("unauthorized")
+	}
 
-			toko, err := t.tokoRepository.FindByTokoID(ctx, logProduk.IdToko)
-			if err != nil {
-				return nil, err
-			}
-			tokoLogProdukResponse := new(model.TokoLogProdukResponse)
-			copier.Copy(tokoLogProdukResponse, toko)
-			logProdukResponse.Toko = tokoLogProdukResponse
+	var allowedActor model.TrxActor
+	for _, actor := range actors {
+		if trx.Status.CanTransitionTo(req.Status, actor) {
+			allowedActor = actor
+			break
+		}
+	}
+	if allowedActor == "" {
+		return nil, fmt.Errorf("cannot change status from %s to %s", trx.Status, req.Status)
+	}
 
-			category, err := t.categoryRepository.FindByID(ctx, logProduk.IdCategory)
-			if err != nil {
-				return nil, err
-			}
-			categoryResponse := new(model.CategoryResponse)
-			copier.Copy(categoryResponse, category)
-			logProdukResponse.Category = categoryResponse
-
-			fotoProdukResponses := []*model.FotoProdukResponse{}
-			fotoProdukList, err := t.fotoProdukRepository.FetchByProdukId(ctx, logProduk.IdProduk)
-			if err != nil {
-				return nil, err
-			}
-			copier.Copy(&fotoProdukResponses, &fotoProdukList)
-			logProdukResponse.Photos = fotoProdukResponses
+	history := &model.TrxStatusHistory{
+		StatusSesudah: req.Status,
+		IdUser:        &userId,
+		Peran:         allowedActor,
+		Catatan:       req.Catatan,
+	}
+	if req.Status == model.TRX_STATUS_CANCELLED {
+		// cancelling puts the stock back, so it has its own repository operation
+		err = t.trxRepository.CancelTrx(ctx, trx.ID, trx.Status, history)
+	} else {
+		err = t.trxRepository.UpdateStatus(ctx, trx.ID, trx.Status, history)
+	}
+	if err != nil {
+		return nil, err
+	}
 
-			detailTrxResponse.LogProduk = logProdukResponse
+	return t.GetTrxStatus(ctx, trx.ID, userId, isAdmin)
+}
 
-			tokoGetByIDResponse := new(model.TokoGetByIDResponse)
-			copier.Copy(tokoGetByIDResponse, toko)
-			detailTrxResponse.Toko = tokoGetByIDResponse
+func (t *trxUsecase) CancelTrx(
+	ctx context.Context,
+	trxId int,
+	userId int,
+	isAdmin bool,
+	req *model.TrxCancelRequest,
+) (*model.TrxStatusResponse, error) {
+	trxStatusUpdateRequest := new(model.TrxStatusUpdateRequest)
+	trxStatusUpdateRequest.Status = model.TRX_STATUS_CANCELLED
+	trxStatusUpdateRequest.Catatan = req.Alasan
+	return t.EditTrxStatus(ctx, trxId, userId, isAdmin, trxStatusUpdateRequest)
+}
 
-			detailTrxResponses = append(detailTrxResponses, detailTrxResponse)
+// ExpireUnpaidTrx walks the unpaid trx in batches by id. A trx that fails to expire is skipped so it does not
+// hold up the rest, the failures are returned together and the trx is tried again on the next run
+func (t *trxUsecase) ExpireUnpaidTrx(ctx context.Context, deadline time.Time) (int, error) {
+	expired := 0
+	lastId := 0
+	errs := []error{}
+	for {
+		trxList, err := t.trxRepository.FetchUnpaidCreatedBefore(ctx, deadline, lastId, model.EXPIRE_UNPAID_TRX_BATCH_SIZE)
+		if err != nil {
+			return expired, errors.Join(append(errs, err)...)
 		}
+		for _, trx := range trxList {
+			lastId = trx.ID
+			history := &model.TrxStatusHistory{
+				StatusSesudah: model.TRX_STATUS_CANCELLED,
+				Peran:         model.TRX_ACTOR_SYSTEM,
+				Catatan:       "payment deadline passed",
+			}
+			// a trx paid or cancelled since it was fetched is rejected by the conditional update
+			if err := t.trxRepository.ExpireTrx(ctx, trx.ID, history); err != nil {
+				if ctx.Err() != nil {
+					return expired, ctx.Err()
+				}
+				errs = append(errs, fmt.Errorf("trx %d: %w", trx.ID, err))
+				continue
+			}
+			expired++
+		}
+		if len(trxList) < model.EXPIRE_UNPAID_TRX_BATCH_SIZE {
+			return expired, errors.Join(errs...)
+		}
+	}
+}
 
-		trxGetByIDResponse.DetailTrxResponses = detailTrxResponses
-
-		trxGetByIDResponses = append(trxGetByIDResponses, trxGetByIDResponse)
+// trxActors returns every role the user holds on the transaction,
+// a user can be an admin and the buyer of the same transaction at the same time
+func (t *trxUsecase) trxActors(ctx context.Context, trx *model.Trx, userId int, isAdmin bool) ([]model.TrxActor, error) {
+	actors := []model.TrxActor{}
+	if trx.IdUser == userId {
+		actors = append(actors, model.TRX_ACTOR_BUYER)
 	}
 
-	trxFetchResponse.Data = trxGetByIDResponses
+	toko, err := t.tokoRepository.FindByUserID(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	detailTrxList, err := t.detailTrxRepository.FindByTrxID(ctx, trx.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, detailTrx := range detailTrxList {
+		if detailTrx.IdToko == toko.ID {
+			actors = append(actors, model.TRX_ACTOR_SELLER)
+			break
+		}
+	}
 
-	return trxFetchResponse, nil
+	if isAdmin {
+		actors = append(actors, model.TRX_ACTOR_ADMIN)
+	}
+	return actors, nil
 }
 
-func (t *trxUsecase) GetTrxByID(ctx context.Context, trxId int, userId int) (*model.TrxGetByIDResponse, error) {
-	trx, err := t.trxRepository.FindByID(ctx, trxId)
+func (t *trxUsecase) FetchTokoOrders(ctx context.Context, req *model.TokoOrderFetchRequest, userId int) (*model.TokoOrderFetchResponse, error) {
+	toko, err := t.tokoRepository.FindByUserID(ctx, userId)
 	if err != nil {
 		return nil, err
 	}
-	if trx.IdUser != userId {
-		return nil, errors.New("unauthorized")
+
+	detailTrxList, err := t.detailTrxRepository.FetchByTokoID(ctx, toko.ID, req)
+	if err != nil {
+		return nil, err
+	}
+	summary, err := t.detailTrxRepository.SummarizeByTokoID(ctx, toko.ID, req)
+	if err != nil {
+		return nil, err
 	}
 
-	trxGetByIDResponse := new(model.TrxGetByIDResponse)
-	copier.Copy(trxGetByIDResponse, trx)
+	tokoOrderFetchResponse := new(model.TokoOrderFetchResponse)
+	tokoOrderFetchResponse.Pagination = model.NewPagination(req.PaginationRequest, summary.Total)
+	tokoOrderFetchResponse.TotalKuantitas = summary.TotalKuantitas
+	tokoOrderFetchResponse.TotalHarga = summary.TotalHarga
 
-	alamat, err := t.alamatRepository.FindByID(ctx, trx.AlamatPengiriman)
+	trxIds := []int{}
+	logProdukIds := []int{}
+	for _, detailTrx := range detailTrxList {
+		trxIds = append(trxIds, detailTrx.IdTrx)
+		logProdukIds = append(logProdukIds, detailTrx.IdLogProduk)
+	}
+
+	trxList, err := t.trxRepository.FindByIDs(ctx, uniqueIds(trxIds))
 	if err != nil {
 		return nil, err
 	}
-	alamatResponse := new(model.AlamatResponse)
-	copier.Copy(alamatResponse, alamat)
-	trxGetByIDResponse.AlamatPengiriman = alamatResponse
-
-	detailTrxResponses := []*model.DetailTrxResponse{}
-	detailTrxList, err := t.detailTrxRepository.FindByTrxID(ctx, trx.ID)
+	trxById := map[int]*model.Trx{}
+	alamatIds := []int{}
+	for _, trx := range trxList {
+		trxById[trx.ID] = trx
+		alamatIds = append(alamatIds, trx.AlamatPengiriman)
+	}
+	alamatResponseById, err := t.alamatResponsesByID(ctx, uniqueIds(alamatIds))
 	if err != nil {
 		return nil, err
 	}
+	logProdukResponseById, _, err := t.logProdukResponsesByID(ctx, logProdukIds)
+	if err != nil {
+		return nil, err
+	}
+
+	tokoOrderResponses := []*model.TokoOrderResponse{}
 	for _, detailTrx := range detailTrxList {
-		detailTrxResponse := new(model.DetailTrxResponse)
-		copier.Copy(detailTrxResponse, detailTrx)
+		tokoOrderResponse := new(model.TokoOrderResponse)
+		copier.Copy(tokoOrderResponse, detailTrx)
 
-		logProduk, err := t.logProdukRepository.FindByID(ctx, detailTrx.IdLogProduk)
-		if err != nil {
-			return nil, err
+		trx, ok := trxById[detailTrx.IdTrx]
+		if !ok {
+			return nil, errors.New("transaction not found")
 		}
-		logProdukResponse := new(model.LogProdukResponse)
-		copier.Copy(logProdukResponse, logProduk)
+		tokoOrderResponse.KodeInvoice = trx.KodeInvoice
+		tokoOrderResponse.MethodBayar = trx.MethodBayar
+		tokoOrderResponse.Status = trx.Status
+		tokoOrderResponse.CreatedAt = trx.CreatedAt
+		tokoOrderResponse.AlamatPengiriman = alamatResponseById[trx.AlamatPengiriman]
+		tokoOrderResponse.LogProduk = logProdukResponseById[detailTrx.IdLogProduk]
+
+		tokoOrderResponses = append(tokoOrderResponses, tokoOrderResponse)
+	}
+	tokoOrderFetchResponse.Data = tokoOrderResponses
 
-		toko, err := t.tokoRepository.FindByTokoID(ctx, logProduk.IdToko)
-		if err != nil {
-			return nil, err
-		}
-		tokoLogProdukResponse := new(model.TokoLogProdukResponse)
-		copier.Copy(tokoLogProdukResponse, toko)
-		logProdukResponse.Toko = tokoLogProdukResponse
+	return tokoOrderFetchResponse, nil
+}
 
-		category, err := t.categoryRepository.FindByID(ctx, logProduk.IdCategory)
-		if err != nil {
-			return nil, err
+// createPayment charges the trx through its provider, pay on delivery trx are moved to paid right away
+// so the seller can process them. A trx that cannot be charged is cancelled to release its stock.
+func (t *trxUsecase) createPayment(ctx context.Context, trx *model.Trx, paymentProvider model.PaymentProvider) error {
+	if _, err := t.chargeTrx(ctx, trx, paymentProvider); err != nil {
+		history := &model.TrxStatusHistory{
+			StatusSesudah: model.TRX_STATUS_CANCELLED,
+			Peran:         model.TRX_ACTOR_SYSTEM,
+			Catatan:       "payment could not be created",
 		}
-		categoryResponse := new(model.CategoryResponse)
-		copier.Copy(categoryResponse, category)
-		logProdukResponse.Category = categoryResponse
-
-		fotoProdukResponses := []*model.FotoProdukResponse{}
-		fotoProdukList, err := t.fotoProdukRepository.FetchByProdukId(ctx, logProduk.IdProduk)
-		if err != nil {
-			return nil, err
+		if cancelErr := t.trxRepository.CancelTrx(ctx, trx.ID, trx.Status, history); cancelErr != nil {
+			return fmt.Errorf("%w, cancelling the transaction failed: %v", err, cancelErr)
 		}
-		copier.Copy(&fotoProdukResponses, &fotoProdukList)
-		logProdukResponse.Photos = fotoProdukResponses
-
-		detailTrxResponse.LogProduk = logProdukResponse
+		return err
+	}
 
-		tokoGetByIDResponse := new(model.TokoGetByIDResponse)
-		copier.Copy(tokoGetByIDResponse, toko)
-		detailTrxResponse.Toko = tokoGetByIDResponse
+	if paymentProvider.PayOnDelivery() {
+		history := &model.TrxStatusHistory{
+			StatusSesudah: model.TRX_STATUS_PAID,
+			Peran:         model.TRX_ACTOR_SYSTEM,
+			Catatan:       "pay on delivery",
+		}
+		if err := t.trxRepository.UpdateStatus(ctx, trx.ID, trx.Status, history); err != nil {
+			return err
+		}
+		trx.Status = model.TRX_STATUS_PAID
+	}
+	return nil
+}
 
-		detailTrxResponses = append(detailTrxResponses, detailTrxResponse)
+func (t *trxUsecase) chargeTrx(ctx context.Context, trx *model.Trx, paymentProvider model.PaymentProvider) (*model.Payment, error) {
+	paymentCharge, err := paymentProvider.CreateCharge(ctx, trx)
+	if err != nil {
+		return nil, err
 	}
 
-	trxGetByIDResponse.DetailTrxResponses = detailTrxResponses
+	payment := new(model.Payment)
+	payment.IdTrx = trx.ID
+	payment.MethodBayar = paymentProvider.Method()
+	payment.Jumlah = trx.HargaTotal
+	payment.Status = model.PAYMENT_STATUS_PENDING
+	payment.Referensi = paymentCharge.Referensi
+	payment.Instruksi = paymentCharge.Instruksi
+	return t.paymentRepository.Create(ctx, payment)
+}
 
-	return trxGetByIDResponse, nil
+func paymentResponse(payment *model.Payment) *model.PaymentResponse {
+	if payment == nil {
+		return nil
+	}
+	paymentResponse := new(model.PaymentResponse)
+	copier.Copy(paymentResponse, payment)
+	return paymentResponse
 }
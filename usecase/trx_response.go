@@ -0,0 +1,190 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"marketplace-api/model"
+
+	"github.com/jinzhu/copier"
+)
+
+// buildTrxGetByIDResponses assembles the responses of trxList with one query per related table,
+// so the number of queries does not grow with the number of trx or detail trx
+func (t *trxUsecase) buildTrxGetByIDResponses(ctx context.Context, trxList []*model.Trx) ([]*model.TrxGetByIDResponse, error) {
+	trxIds := []int{}
+	alamatIds := []int{}
+	for _, trx := range trxList {
+		trxIds = append(trxIds, trx.ID)
+		alamatIds = append(alamatIds, trx.AlamatPengiriman)
+	}
+
+	alamatResponseById, err := t.alamatResponsesByID(ctx, alamatIds)
+	if err != nil {
+		return nil, err
+	}
+
+	paymentList, err := t.paymentRepository.FetchByTrxIDs(ctx, trxIds)
+	if err != nil {
+		return nil, err
+	}
+	paymentByTrxId := map[int]*model.Payment{}
+	for _, payment := range paymentList {
+		paymentByTrxId[payment.IdTrx] = payment
+	}
+
+	detailTrxList, err := t.detailTrxRepository.FetchByTrxIDs(ctx, trxIds)
+	if err != nil {
+		return nil, err
+	}
+	logProdukIds := []int{}
+	for _, detailTrx := range detailTrxList {
+		logProdukIds = append(logProdukIds, detailTrx.IdLogProduk)
+	}
+	logProdukResponseById, tokoById, err := t.logProdukResponsesByID(ctx, logProdukIds)
+	if err != nil {
+		return nil, err
+	}
+
+	detailTrxResponsesByTrxId := map[int][]*model.DetailTrxResponse{}
+	for _, detailTrx := range detailTrxList {
+		detailTrxResponse := new(model.DetailTrxResponse)
+		copier.Copy(detailTrxResponse, detailTrx)
+
+		detailTrxResponse.LogProduk = logProdukResponseById[detailTrx.IdLogProduk]
+
+		// detail trx and its log produk always belong to the same toko
+		toko, ok := tokoById[detailTrx.IdToko]
+		if !ok {
+			return nil, errors.New("toko not found")
+		}
+		detailTrxResponse.Toko = newTokoGetByIDResponse(t.storage, toko)
+
+		detailTrxResponsesByTrxId[detailTrx.IdTrx] = append(detailTrxResponsesByTrxId[detailTrx.IdTrx], detailTrxResponse)
+	}
+
+	trxGetByIDResponses := []*model.TrxGetByIDResponse{}
+	for _, trx := range trxList {
+		trxGetByIDResponse := new(model.TrxGetByIDResponse)
+		copier.Copy(trxGetByIDResponse, trx)
+		trxGetByIDResponse.AlamatPengiriman = alamatResponseById[trx.AlamatPengiriman]
+		trxGetByIDResponse.Payment = paymentResponse(paymentByTrxId[trx.ID])
+		trxGetByIDResponse.DetailTrxResponses = detailTrxResponsesByTrxId[trx.ID]
+		if trxGetByIDResponse.DetailTrxResponses == nil {
+			trxGetByIDResponse.DetailTrxResponses = []*model.DetailTrxResponse{}
+		}
+		trxGetByIDResponses = append(trxGetByIDResponses, trxGetByIDResponse)
+	}
+
+	return trxGetByIDResponses, nil
+}
+
+func (t *trxUsecase) alamatResponsesByID(ctx context.Context, alamatIds []int) (map[int]*model.AlamatResponse, error) {
+	alamatList, err := t.alamatRepository.FindByIDsWithDeleted(ctx, alamatIds)
+	if err != nil {
+		return nil, err
+	}
+	alamatResponseById := map[int]*model.AlamatResponse{}
+	for _, alamat := range alamatList {
+		alamatResponse := new(model.AlamatResponse)
+		copier.Copy(alamatResponse, alamat)
+		alamatResponseById[alamat.ID] = alamatResponse
+	}
+	for _, alamatId := range alamatIds {
+		if _, ok := alamatResponseById[alamatId]; !ok {
+			return nil, errors.New("alamat not found")
+		}
+	}
+	return alamatResponseById, nil
+}
+
+// logProdukResponsesByID builds the log produk responses along with their toko, category and photos,
+// the toko are returned too because detail trx responses show them in full
+func (t *trxUsecase) logProdukResponsesByID(
+	ctx context.Context,
+	logProdukIds []int,
+) (map[int]*model.LogProdukResponse, map[int]*model.Toko, error) {
+	logProdukList, err := t.logProdukRepository.FindByIDs(ctx, logProdukIds)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(logProdukList) != len(uniqueIds(logProdukIds)) {
+		return nil, nil, errors.New("log produk not found")
+	}
+
+	tokoIds := []int{}
+	categoryIds := []int{}
+	produkIds := []int{}
+	for _, logProduk := range logProdukList {
+		tokoIds = append(tokoIds, logProduk.IdToko)
+		categoryIds = append(categoryIds, logProduk.IdCategory)
+		produkIds = append(produkIds, logProduk.IdProduk)
+	}
+
+	tokoList, err := t.tokoRepository.FindByTokoIDs(ctx, uniqueIds(tokoIds))
+	if err != nil {
+		return nil, nil, err
+	}
+	tokoById := map[int]*model.Toko{}
+	for _, toko := range tokoList {
+		tokoById[toko.ID] = toko
+	}
+
+	categoryList, err := t.categoryRepository.FindByIDsWithDeleted(ctx, uniqueIds(categoryIds))
+	if err != nil {
+		return nil, nil, err
+	}
+	categoryById := map[int]*model.Category{}
+	for _, category := range categoryList {
+		categoryById[category.ID] = category
+	}
+
+	fotoProdukList, err := t.fotoProdukRepository.FetchByProdukIds(ctx, uniqueIds(produkIds))
+	if err != nil {
+		return nil, nil, err
+	}
+	fotoProdukListByProdukId := map[int][]*model.FotoProduk{}
+	for _, fotoProduk := range fotoProdukList {
+		fotoProdukListByProdukId[fotoProduk.IdProduk] = append(fotoProdukListByProdukId[fotoProduk.IdProduk], fotoProduk)
+	}
+
+	logProdukResponseById := map[int]*model.LogProdukResponse{}
+	for _, logProduk := range logProdukList {
+		logProdukResponse := new(model.LogProdukResponse)
+		copier.Copy(logProdukResponse, logProduk)
+
+		toko, ok := tokoById[logProduk.IdToko]
+		if !ok {
+			return nil, nil, errors.New("toko not found")
+		}
+		tokoLogProdukResponse := new(model.TokoLogProdukResponse)
+		copier.Copy(tokoLogProdukResponse, toko)
+		tokoLogProdukResponse.UrlFoto, tokoLogProdukResponse.UrlFotoThumbnail, tokoLogProdukResponse.UrlFotoMedium = tokoFotoUrls(t.storage, toko)
+		logProdukResponse.Toko = tokoLogProdukResponse
+
+		category, ok := categoryById[logProduk.IdCategory]
+		if !ok {
+			return nil, nil, errors.New("category not found")
+		}
+		categoryResponse := new(model.CategoryResponse)
+		copier.Copy(categoryResponse, category)
+		logProdukResponse.Category = categoryResponse
+
+		logProdukResponse.Photos = newFotoProdukResponses(t.storage, fotoProdukListByProdukId[logProduk.IdProduk])
+
+		logProdukResponseById[logProduk.ID] = logProdukResponse
+	}
+
+	return logProdukResponseById, tokoById, nil
+}
+
+func uniqueIds(ids []int) []int {
+	seen := map[int]bool{}
+	result := []int{}
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
@@ -0,0 +1,130 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"marketplace-api/model"
+	"sync/atomic"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// countStatements counts every statement run on db while fn runs
+func countStatements(t testing.TB, db *gorm.DB, fn func()) int64 {
+	t.Helper()
+	var counting int32
+	var statements int64
+	count := func(*gorm.DB) {
+		if atomic.LoadInt32(&counting) == 1 {
+			atomic.AddInt64(&statements, 1)
+		}
+	}
+	callback := db.Callback()
+	for name, err := range map[string]error{
+		"query": callback.Query().After("gorm:query").Register("test:count_query", count),
+		"row":   callback.Row().After("gorm:row").Register("test:count_row", count),
+		"raw":   callback.Raw().After("gorm:raw").Register("test:count_raw", count),
+	} {
+		if err != nil {
+			t.Fatalf("register %s callback: %v", name, err)
+		}
+	}
+	t.Cleanup(func() {
+		callback.Query().Remove("test:count_query")
+		callback.Row().Remove("test:count_row")
+		callback.Raw().Remove("test:count_raw")
+	})
+
+	atomic.StoreInt32(&counting, 1)
+	fn()
+	atomic.StoreInt32(&counting, 0)
+	return atomic.LoadInt64(&statements)
+}
+
+// createTrxPage stores trxCount trx that each buy from two toko, each produk has a photo
+func createTrxPage(f *fixture, trxUsecase *trxUsecase, trxCount int) []*model.Trx {
+	f.t.Helper()
+	buyer := f.createUser(false)
+	alamat := f.createAlamat(buyer.ID)
+	for i := 0; i < trxCount; i++ {
+		req := &model.TrxStoreRequest{MethodBayar: string(model.PAYMENT_METHOD_BANK_TRANSFER), AlamatPengiriman: alamat.ID}
+		for j := 0; j < 2; j++ {
+			toko := f.createToko(f.createUser(false).ID)
+			produk := f.createProduk(toko.ID, f.createCategory().ID, 10, 10000, 9000)
+			f.create(&model.FotoProduk{IdProduk: produk.ID, Url: "foto.jpg", IsPrimary: true})
+			req.DetailTrxRequests = append(req.DetailTrxRequests, &model.DetailTrxRequest{ProductId: produk.ID, Kuantitas: 1})
+		}
+		if _, err := trxUsecase.StoreTrx(context.Background(), req, buyer.ID); err != nil {
+			f.t.Fatal(err)
+		}
+	}
+
+	trxList := []*model.Trx{}
+	if err := f.db.Where("id_user = ?", buyer.ID).Order("id").Find(&trxList).Error; err != nil {
+		f.t.Fatal(err)
+	}
+	if len(trxList) != trxCount {
+		f.t.Fatalf("created %d trx, want %d", len(trxList), trxCount)
+	}
+	return trxList
+}
+
+func buildTrxPage(t testing.TB, trxUsecase *trxUsecase, trxList []*model.Trx) {
+	t.Helper()
+	trxGetByIDResponses, err := trxUsecase.buildTrxGetByIDResponses(context.Background(), trxList)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trxGetByIDResponses) != len(trxList) {
+		t.Fatalf("got %d responses for %d trx", len(trxGetByIDResponses), len(trxList))
+	}
+	for _, trxGetByIDResponse := range trxGetByIDResponses {
+		if len(trxGetByIDResponse.DetailTrxResponses) != 2 {
+			t.Fatalf("trx %d has %d detail trx, want 2", trxGetByIDResponse.ID, len(trxGetByIDResponse.DetailTrxResponses))
+		}
+	}
+}
+
+func TestBuildTrxGetByIDResponsesQueryCount(t *testing.T) {
+	const trxCount = 5
+
+	f := newFixture(t)
+	trxUsecase := f.newTrxUsecase().(*trxUsecase)
+	trxList := createTrxPage(f, trxUsecase, trxCount)
+
+	build := func(trxList []*model.Trx) int64 {
+		return countStatements(t, f.db, func() {
+			buildTrxPage(t, trxUsecase, trxList)
+		})
+	}
+
+	one := build(trxList[:1])
+	many := build(trxList)
+	if one == 0 {
+		t.Fatal("no statement was counted")
+	}
+	if many != one {
+		t.Errorf("%d statements for 1 trx but %d for %d trx", one, many, trxCount)
+	}
+}
+
+// BenchmarkBuildTrxGetByIDResponses reports the statements run to build one page,
+// which should stay the same for every page size
+func BenchmarkBuildTrxGetByIDResponses(b *testing.B) {
+	for _, pageSize := range []int{1, 10, 50} {
+		b.Run(fmt.Sprintf("page=%d", pageSize), func(b *testing.B) {
+			f := newFixture(b)
+			trxUsecase := f.newTrxUsecase().(*trxUsecase)
+			trxList := createTrxPage(f, trxUsecase, pageSize)
+
+			b.ResetTimer()
+			statements := countStatements(b, f.db, func() {
+				for i := 0; i < b.N; i++ {
+					buildTrxPage(b, trxUsecase, trxList)
+				}
+			})
+			b.ReportMetric(float64(statements)/float64(b.N), "statements/page")
+		})
+	}
+}
@@ -0,0 +1,287 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"marketplace-api/model"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStoreTrxConcurrentCheckoutsDoNotOversell(t *testing.T) {
+	const stok = 5
+	const checkouts = 20
+
+	for _, database := range concurrencyDatabases {
+		t.Run(database.name, func(t *testing.T) {
+			f := newFixtureWithConfig(t, database.newConfig(t))
+			seller := f.createUser(false)
+			toko := f.createToko(seller.ID)
+			category := f.createCategory()
+			produk := f.createProduk(toko.ID, category.ID, stok, 10000, 9000)
+			buyer := f.createUser(false)
+			alamat := f.createAlamat(buyer.ID)
+			trxUsecase := f.newTrxUsecase()
+
+			var wg sync.WaitGroup
+			errs := make(chan error, checkouts)
+			for i := 0; i < checkouts; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, err := trxUsecase.StoreTrx(context.Background(), newTrxStoreRequest(alamat.ID, produk.ID, 0, 1), buyer.ID)
+					errs <- err
+				}()
+			}
+			wg.Wait()
+			close(errs)
+
+			succeeded := 0
+			for err := range errs {
+				if err == nil {
+					succeeded++
+					continue
+				}
+				if err.Error() != "kuantitas melebihi stok produk" {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}
+
+			sisaStok := f.findProduk(produk.ID).Stok
+			if sisaStok < 0 {
+				t.Fatalf("stock went negative: %d", sisaStok)
+			}
+			if succeeded > stok {
+				t.Errorf("%d orders succeeded for a stock of %d", succeeded, stok)
+			}
+			if succeeded != stok-sisaStok {
+				t.Errorf("%d orders succeeded but the stock went from %d to %d", succeeded, stok, sisaStok)
+			}
+		})
+	}
+}
+
+func TestStoreTrxVariantConcurrentCheckoutsDoNotOversell(t *testing.T) {
+	const stok = 3
+	const checkouts = 10
+
+	for _, database := range concurrencyDatabases {
+		t.Run(database.name, func(t *testing.T) {
+			f := newFixtureWithConfig(t, database.newConfig(t))
+			seller := f.createUser(false)
+			toko := f.createToko(seller.ID)
+			category := f.createCategory()
+			produk := f.createProduk(toko.ID, category.ID, stok, 10000, 9000)
+			produkVariant := f.createProdukVariant(produk.ID, stok, 12000, 11000)
+			buyer := f.createUser(false)
+			alamat := f.createAlamat(buyer.ID)
+			trxUsecase := f.newTrxUsecase()
+
+			var wg sync.WaitGroup
+			for i := 0; i < checkouts; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					trxUsecase.StoreTrx(context.Background(), newTrxStoreRequest(alamat.ID, produk.ID, produkVariant.ID, 1), buyer.ID)
+				}()
+			}
+			wg.Wait()
+
+			var sisaStokVariant int
+			if err := f.db.Table("produk_variant").Select("stok").Where("id = ?", produkVariant.ID).Scan(&sisaStokVariant).Error; err != nil {
+				t.Fatal(err)
+			}
+			if sisaStokVariant < 0 || f.findProduk(produk.ID).Stok < 0 {
+				t.Fatalf("stock went negative")
+			}
+			var totalTrx int64
+			if err := f.db.Table("trx").Where("status <> ?", "cancelled").Count(&totalTrx).Error; err != nil {
+				t.Fatal(err)
+			}
+			if totalTrx != int64(stok-sisaStokVariant) {
+				t.Errorf("%d orders succeeded but the variant stock went from %d to %d", totalTrx, stok, sisaStokVariant)
+			}
+		})
+	}
+}
+
+// failingExpireTrxRepository fails to expire one trx
+type failingExpireTrxRepository struct {
+	model.TrxRepository
+	trxId int
+}
+
+func (f *failingExpireTrxRepository) ExpireTrx(ctx context.Context, trxId int, history *model.TrxStatusHistory) error {
+	if trxId == f.trxId {
+		return errors.New("lock wait timeout exceeded")
+	}
+	return f.TrxRepository.ExpireTrx(ctx, trxId, history)
+}
+
+func TestExpireUnpaidTrxSkipsFailures(t *testing.T) {
+	f := newFixture(t)
+	seller := f.createUser(false)
+	toko := f.createToko(seller.ID)
+	produk := f.createProduk(toko.ID, f.createCategory().ID, 10, 10000, 9000)
+	buyer := f.createUser(false)
+	alamat := f.createAlamat(buyer.ID)
+	trxUsecase := f.newTrxUsecase().(*trxUsecase)
+
+	trxIds := []int{}
+	for i := 0; i < 3; i++ {
+		trx, err := trxUsecase.StoreTrx(context.Background(), newTrxStoreRequest(alamat.ID, produk.ID, 0, 1), buyer.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		trxIds = append(trxIds, trx.ID)
+	}
+	trxUsecase.trxRepository = &failingExpireTrxRepository{TrxRepository: trxUsecase.trxRepository, trxId: trxIds[1]}
+
+	expired, err := trxUsecase.ExpireUnpaidTrx(context.Background(), time.Now().Add(time.Minute))
+	if expired != 2 {
+		t.Errorf("expired %d trx, want 2", expired)
+	}
+	if err == nil || !strings.Contains(err.Error(), fmt.Sprintf("trx %d:", trxIds[1])) {
+		t.Errorf("got error %v, want the failure of trx %d", err, trxIds[1])
+	}
+
+	wantStatus := []model.TrxStatus{model.TRX_STATUS_CANCELLED, model.TRX_STATUS_PENDING_PAYMENT, model.TRX_STATUS_CANCELLED}
+	for i, trxId := range trxIds {
+		trx := new(model.Trx)
+		if err := f.db.First(trx, trxId).Error; err != nil {
+			t.Fatal(err)
+		}
+		if trx.Status != wantStatus[i] {
+			t.Errorf("trx %d is %s, want %s", trxId, trx.Status, wantStatus[i])
+		}
+	}
+}
+
+// hargaSatuanTests price a product of 10000/8000 and a variant of 12000/9000, resellers get their
+// price from 3 items on
+var hargaSatuanTests = []struct {
+	name       string
+	isReseller bool
+	variant    bool
+	kuantitas  int
+	want       model.Money
+}{
+	{"consumer below threshold", false, false, 2, 10000},
+	{"consumer at threshold", false, false, 3, 10000},
+	{"reseller below threshold", true, false, 2, 10000},
+	{"reseller at threshold", true, false, 3, 8000},
+	{"consumer variant below threshold", false, true, 2, 12000},
+	{"consumer variant at threshold", false, true, 3, 12000},
+	{"reseller variant below threshold", true, true, 2, 12000},
+	{"reseller variant at threshold", true, true, 3, 9000},
+}
+
+func TestStoreTrxHargaSatuan(t *testing.T) {
+	for _, tt := range hargaSatuanTests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			seller := f.createUser(false)
+			toko := f.createToko(seller.ID)
+			produk := f.createProduk(toko.ID, f.createCategory().ID, 10, 10000, 8000)
+			if err := f.db.Model(produk).Update("min_kuantitas_reseller", 3).Error; err != nil {
+				t.Fatal(err)
+			}
+			variantId := 0
+			if tt.variant {
+				variantId = f.createProdukVariant(produk.ID, 10, 12000, 9000).ID
+			}
+			buyer := f.createUser(tt.isReseller)
+			alamat := f.createAlamat(buyer.ID)
+
+			trx, err := f.newTrxUsecase().StoreTrx(context.Background(), newTrxStoreRequest(alamat.ID, produk.ID, variantId, tt.kuantitas), buyer.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := tt.want.Times(tt.kuantitas)
+			if trx.HargaTotal != want {
+				t.Errorf("harga total is %d, want %d", trx.HargaTotal, want)
+			}
+			if got := trx.DetailTrxResponses[0].HargaTotal; got != want {
+				t.Errorf("detail harga total is %d, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestCancelTrx(t *testing.T) {
+	const stok = 10
+	const kuantitas = 3
+
+	tests := []struct {
+		name    string
+		status  model.TrxStatus
+		actor   model.TrxActor
+		wantErr bool
+	}{
+		{"buyer cancels unpaid trx", model.TRX_STATUS_PENDING_PAYMENT, model.TRX_ACTOR_BUYER, false},
+		{"seller cancels paid trx", model.TRX_STATUS_PAID, model.TRX_ACTOR_SELLER, false},
+		{"buyer cannot cancel shipped trx", model.TRX_STATUS_SHIPPED, model.TRX_ACTOR_BUYER, true},
+		{"seller cannot cancel shipped trx", model.TRX_STATUS_SHIPPED, model.TRX_ACTOR_SELLER, true},
+		{"admin cancels shipped trx", model.TRX_STATUS_SHIPPED, model.TRX_ACTOR_ADMIN, false},
+		{"stranger cannot cancel", model.TRX_STATUS_PENDING_PAYMENT, "", true},
+		{"cancelled trx cannot be cancelled again", model.TRX_STATUS_CANCELLED, model.TRX_ACTOR_ADMIN, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			seller := f.createUser(false)
+			toko := f.createToko(seller.ID)
+			produk := f.createProduk(toko.ID, f.createCategory().ID, stok, 10000, 9000)
+			buyer := f.createUser(false)
+			f.createToko(buyer.ID)
+			alamat := f.createAlamat(buyer.ID)
+			trxUsecase := f.newTrxUsecase()
+
+			trx, err := trxUsecase.StoreTrx(context.Background(), newTrxStoreRequest(alamat.ID, produk.ID, 0, kuantitas), buyer.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := f.db.Model(&model.Trx{}).Where("id = ?", trx.ID).Update("status", tt.status).Error; err != nil {
+				t.Fatal(err)
+			}
+
+			userId, isAdmin := 0, false
+			switch tt.actor {
+			case model.TRX_ACTOR_BUYER:
+				userId = buyer.ID
+			case model.TRX_ACTOR_SELLER:
+				userId = seller.ID
+			default:
+				other := f.createUser(false)
+				f.createToko(other.ID)
+				userId, isAdmin = other.ID, tt.actor == model.TRX_ACTOR_ADMIN
+			}
+
+			trxStatusResponse, err := trxUsecase.CancelTrx(context.Background(), trx.ID, userId, isAdmin, &model.TrxCancelRequest{Alasan: "stok salah"})
+			wantStok := stok - kuantitas
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("cancel succeeded, want an error")
+				}
+			} else {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if trxStatusResponse.Status != model.TRX_STATUS_CANCELLED {
+					t.Errorf("status is %s, want %s", trxStatusResponse.Status, model.TRX_STATUS_CANCELLED)
+				}
+				last := trxStatusResponse.History[len(trxStatusResponse.History)-1]
+				if last.Peran != tt.actor || last.StatusSebelum != tt.status || last.Catatan != "stok salah" {
+					t.Errorf("last history is %+v", last)
+				}
+				wantStok = stok
+			}
+			if sisaStok := f.findProduk(produk.ID).Stok; sisaStok != wantStok {
+				t.Errorf("stock is %d, want %d", sisaStok, wantStok)
+			}
+		})
+	}
+}
@@ -221,3 +221,11 @@ func (u *userUsecase) EditCurrentUser(ctx context.Context, userId int, req *mode
 
 	return userResponse, nil
 }
+
+func (u *userUsecase) EditUserReseller(ctx context.Context, userId int, req *model.UserResellerRequest) (*model.UserResponse, error) {
+	_, err := u.userRepository.UpdateIsResellerByID(ctx, userId, req.IsReseller)
+	if err != nil {
+		return nil, err
+	}
+	return u.GetCurrentUser(ctx, userId)
+}
@@ -0,0 +1,269 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"marketplace-api/model"
+
+	"github.com/jinzhu/copier"
+)
+
+type voucherUsecase struct {
+	voucherRepository  model.VoucherRepository
+	tokoRepository     model.TokoRepository
+	categoryRepository model.CategoryRepository
+}
+
+func NewVoucherUsecase(
+	voucherRepository model.VoucherRepository,
+	tokoRepository model.TokoRepository,
+	categoryRepository model.CategoryRepository,
+) model.VoucherUsecase {
+	return &voucherUsecase{
+		voucherRepository:  voucherRepository,
+		tokoRepository:     tokoRepository,
+		categoryRepository: categoryRepository,
+	}
+}
+
+func (v *voucherUsecase) StoreVoucher(ctx context.Context, userId int, isAdmin bool, req *model.VoucherRequest) (*model.VoucherResponse, error) {
+	voucher := new(model.Voucher)
+	copier.Copy(voucher, req)
+
+	if req.Global {
+		if !isAdmin {
+			return nil, errors.New("unauthorized")
+		}
+	} else {
+		toko, err := v.tokoRepository.FindByUserID(ctx, userId)
+		if err != nil {
+			return nil, err
+		}
+		voucher.IdToko = &toko.ID
+	}
+
+	req.CategoryIds = uniqueIds(req.CategoryIds)
+	err := v.checkCategories(ctx, req.CategoryIds)
+	if err != nil {
+		return nil, err
+	}
+
+	voucher, err = v.voucherRepository.Create(ctx, voucher, req.CategoryIds)
+	if err != nil {
+		return nil, err
+	}
+	voucherResponses, err := v.buildVoucherResponses(ctx, []*model.Voucher{voucher})
+	if err != nil {
+		return nil, err
+	}
+	return voucherResponses[0], nil
+}
+
+// FetchVoucher lists every voucher to an admin and the vouchers of their own toko to other users
+func (v *voucherUsecase) FetchVoucher(
+	ctx context.Context,
+	userId int,
+	isAdmin bool,
+	req *model.VoucherFetchRequest,
+) (*model.VoucherFetchResponse, error) {
+	if !isAdmin {
+		toko, err := v.tokoRepository.FindByUserID(ctx, userId)
+		if err != nil {
+			return nil, err
+		}
+		req.IdToko = &toko.ID
+	}
+
+	voucherList, total, err := v.voucherRepository.Fetch(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	voucherFetchResponse := new(model.VoucherFetchResponse)
+	voucherFetchResponse.Pagination = model.NewPagination(req.PaginationRequest, total)
+
+	voucherResponses, err := v.buildVoucherResponses(ctx, voucherList)
+	if err != nil {
+		return nil, err
+	}
+	voucherFetchResponse.Data = voucherResponses
+
+	return voucherFetchResponse, nil
+}
+
+func (v *voucherUsecase) GetVoucherByID(ctx context.Context, voucherId int, userId int, isAdmin bool) (*model.VoucherResponse, error) {
+	voucher, err := v.findAuthorizedVoucher(ctx, voucherId, userId, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+	voucherResponses, err := v.buildVoucherResponses(ctx, []*model.Voucher{voucher})
+	if err != nil {
+		return nil, err
+	}
+	return voucherResponses[0], nil
+}
+
+// EditVoucher cannot move a voucher between a toko and admin-wide, req.Global is ignored
+func (v *voucherUsecase) EditVoucher(
+	ctx context.Context,
+	voucherId int,
+	userId int,
+	isAdmin bool,
+	req *model.VoucherRequest,
+) (*model.VoucherResponse, error) {
+	_, err := v.findAuthorizedVoucher(ctx, voucherId, userId, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+	req.CategoryIds = uniqueIds(req.CategoryIds)
+	err = v.checkCategories(ctx, req.CategoryIds)
+	if err != nil {
+		return nil, err
+	}
+
+	voucher := new(model.Voucher)
+	copier.Copy(voucher, req)
+	voucher, err = v.voucherRepository.Update(ctx, voucherId, voucher, req.CategoryIds)
+	if err != nil {
+		return nil, err
+	}
+	voucherResponses, err := v.buildVoucherResponses(ctx, []*model.Voucher{voucher})
+	if err != nil {
+		return nil, err
+	}
+	return voucherResponses[0], nil
+}
+
+func (v *voucherUsecase) DestroyVoucher(ctx context.Context, voucherId int, userId int, isAdmin bool) error {
+	_, err := v.findAuthorizedVoucher(ctx, voucherId, userId, isAdmin)
+	if err != nil {
+		return err
+	}
+	return v.voucherRepository.Delete(ctx, voucherId)
+}
+
+// findAuthorizedVoucher lets admins manage every voucher and other users only the vouchers of their toko
+func (v *voucherUsecase) findAuthorizedVoucher(ctx context.Context, voucherId int, userId int, isAdmin bool) (*model.Voucher, error) {
+	voucher, err := v.voucherRepository.FindByID(ctx, voucherId)
+	if err != nil {
+		return nil, err
+	}
+	if isAdmin {
+		return voucher, nil
+	}
+	if voucher.IdToko == nil {
+		return nil, errors.New("unauthorized")
+	}
+	toko, err := v.tokoRepository.FindByUserID(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if toko.ID != *voucher.IdToko {
+		return nil, errors.New("unauthorized")
+	}
+	return voucher, nil
+}
+
+// checkCategories expects unique category ids
+func (v *voucherUsecase) checkCategories(ctx context.Context, categoryIds []int) error {
+	categoryList, err := v.categoryRepository.FindByIDs(ctx, categoryIds)
+	if err != nil {
+		return err
+	}
+	if len(categoryList) != len(categoryIds) {
+		return errors.New("category not found")
+	}
+	return nil
+}
+
+func (v *voucherUsecase) buildVoucherResponses(ctx context.Context, voucherList []*model.Voucher) ([]*model.VoucherResponse, error) {
+	voucherIds := []int{}
+	for _, voucher := range voucherList {
+		voucherIds = append(voucherIds, voucher.ID)
+	}
+	voucherCategoryList, err := v.voucherRepository.FetchCategories(ctx, voucherIds)
+	if err != nil {
+		return nil, err
+	}
+
+	categoryIds := []int{}
+	for _, voucherCategory := range voucherCategoryList {
+		categoryIds = append(categoryIds, voucherCategory.IdCategory)
+	}
+	categoryList, err := v.categoryRepository.FindByIDsWithDeleted(ctx, uniqueIds(categoryIds))
+	if err != nil {
+		return nil, err
+	}
+	categoryResponseById := map[int]*model.CategoryResponse{}
+	for _, category := range categoryList {
+		categoryResponse := new(model.CategoryResponse)
+		copier.Copy(categoryResponse, category)
+		categoryResponseById[category.ID] = categoryResponse
+	}
+	categoryResponsesByVoucherId := map[int][]*model.CategoryResponse{}
+	for _, voucherCategory := range voucherCategoryList {
+		categoryResponsesByVoucherId[voucherCategory.IdVoucher] = append(
+			categoryResponsesByVoucherId[voucherCategory.IdVoucher],
+			categoryResponseById[voucherCategory.IdCategory],
+		)
+	}
+
+	voucherResponses := []*model.VoucherResponse{}
+	for _, voucher := range voucherList {
+		voucherResponse := new(model.VoucherResponse)
+		copier.Copy(voucherResponse, voucher)
+		voucherResponse.Categories = categoryResponsesByVoucherId[voucher.ID]
+		if voucherResponse.Categories == nil {
+			voucherResponse.Categories = []*model.CategoryResponse{}
+		}
+		voucherResponses = append(voucherResponses, voucherResponse)
+	}
+	return voucherResponses, nil
+}
+
+// distributeDiskon spreads the voucher discount over the eligible detail trx in proportion to their price
+// and returns the total discount. Shares are taken from the running total so they add up to the discount exactly
+func distributeDiskon(
+	voucher *model.Voucher,
+	categoryIds []int,
+	detailTrxWithLogProdukList []*model.DetailTrxWithLogProduk,
+) (model.Money, error) {
+	isEligibleCategory := map[int]bool{}
+	for _, categoryId := range categoryIds {
+		isEligibleCategory[categoryId] = true
+	}
+
+	eligibleDetailTrxList := []*model.DetailTrx{}
+	subtotal := model.Money(0)
+	for _, detailTrxWithLogProduk := range detailTrxWithLogProdukList {
+		detailTrx := detailTrxWithLogProduk.DetailTrx
+		if voucher.IdToko != nil && detailTrx.IdToko != *voucher.IdToko {
+			continue
+		}
+		if len(categoryIds) > 0 && !isEligibleCategory[detailTrxWithLogProduk.LogProduk.IdCategory] {
+			continue
+		}
+		eligibleDetailTrxList = append(eligibleDetailTrxList, detailTrx)
+		subtotal += detailTrx.HargaTotal
+	}
+	if len(eligibleDetailTrxList) == 0 {
+		return 0, model.ErrVoucherNoEligibleItems
+	}
+	if subtotal < voucher.MinBelanja {
+		return 0, model.ErrVoucherMinBelanja
+	}
+
+	diskon := voucher.Diskon(subtotal)
+	if subtotal == 0 {
+		return diskon, nil
+	}
+	runningHarga := model.Money(0)
+	runningDiskon := model.Money(0)
+	for _, detailTrx := range eligibleDetailTrxList {
+		runningHarga += detailTrx.HargaTotal
+		share := diskon*runningHarga/subtotal - runningDiskon
+		runningDiskon += share
+		detailTrx.Diskon = share
+		detailTrx.HargaTotal -= share
+	}
+	return diskon, nil
+}
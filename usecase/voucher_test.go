@@ -0,0 +1,314 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"marketplace-api/model"
+	"sync"
+	"testing"
+	"time"
+)
+
+// voucherTestLine is a detail trx of idToko in idCategory priced harga before the discount
+type voucherTestLine struct {
+	idToko     int
+	idCategory int
+	harga      model.Money
+}
+
+func TestDistributeDiskon(t *testing.T) {
+	tokoId := 1
+	tests := []struct {
+		name        string
+		voucher     model.Voucher
+		categoryIds []int
+		lines       []voucherTestLine
+		wantDiskon  []model.Money
+		wantErr     error
+	}{
+		{
+			name:       "nominal split by price",
+			voucher:    model.Voucher{JenisDiskon: model.VOUCHER_JENIS_DISKON_NOMINAL, NilaiDiskon: 3000},
+			lines:      []voucherTestLine{{1, 1, 10000}, {2, 1, 20000}},
+			wantDiskon: []model.Money{1000, 2000},
+		},
+		{
+			name:       "rounding leftover goes to the last line",
+			voucher:    model.Voucher{JenisDiskon: model.VOUCHER_JENIS_DISKON_NOMINAL, NilaiDiskon: 100},
+			lines:      []voucherTestLine{{1, 1, 100}, {1, 1, 100}, {1, 1, 100}},
+			wantDiskon: []model.Money{33, 33, 34},
+		},
+		{
+			name:       "uneven prices",
+			voucher:    model.Voucher{JenisDiskon: model.VOUCHER_JENIS_DISKON_NOMINAL, NilaiDiskon: 1000},
+			lines:      []voucherTestLine{{1, 1, 333}, {1, 1, 333}, {1, 1, 334}},
+			wantDiskon: []model.Money{333, 333, 334},
+		},
+		{
+			name:       "nominal above the subtotal",
+			voucher:    model.Voucher{JenisDiskon: model.VOUCHER_JENIS_DISKON_NOMINAL, NilaiDiskon: 50000},
+			lines:      []voucherTestLine{{1, 1, 10000}, {1, 1, 5000}},
+			wantDiskon: []model.Money{10000, 5000},
+		},
+		{
+			name:       "percent",
+			voucher:    model.Voucher{JenisDiskon: model.VOUCHER_JENIS_DISKON_PERSEN, NilaiDiskon: 10},
+			lines:      []voucherTestLine{{1, 1, 9999}, {1, 1, 5001}},
+			wantDiskon: []model.Money{999, 501},
+		},
+		{
+			name:       "percent capped",
+			voucher:    model.Voucher{JenisDiskon: model.VOUCHER_JENIS_DISKON_PERSEN, NilaiDiskon: 50, MaksDiskon: 5000},
+			lines:      []voucherTestLine{{1, 1, 10000}, {1, 1, 30000}},
+			wantDiskon: []model.Money{1250, 3750},
+		},
+		{
+			name:       "percent below the cap",
+			voucher:    model.Voucher{JenisDiskon: model.VOUCHER_JENIS_DISKON_PERSEN, NilaiDiskon: 10, MaksDiskon: 5000},
+			lines:      []voucherTestLine{{1, 1, 10000}},
+			wantDiskon: []model.Money{1000},
+		},
+		{
+			name:       "minimum spend reached",
+			voucher:    model.Voucher{JenisDiskon: model.VOUCHER_JENIS_DISKON_NOMINAL, NilaiDiskon: 1000, MinBelanja: 20000},
+			lines:      []voucherTestLine{{1, 1, 10000}, {1, 1, 10000}},
+			wantDiskon: []model.Money{500, 500},
+		},
+		{
+			name:    "minimum spend not reached",
+			voucher: model.Voucher{JenisDiskon: model.VOUCHER_JENIS_DISKON_NOMINAL, NilaiDiskon: 1000, MinBelanja: 20001},
+			lines:   []voucherTestLine{{1, 1, 10000}, {1, 1, 10000}},
+			wantErr: model.ErrVoucherMinBelanja,
+		},
+		{
+			name:        "minimum spend only counts eligible lines",
+			voucher:     model.Voucher{JenisDiskon: model.VOUCHER_JENIS_DISKON_NOMINAL, NilaiDiskon: 1000, MinBelanja: 20000},
+			categoryIds: []int{1},
+			lines:       []voucherTestLine{{1, 1, 10000}, {1, 2, 50000}},
+			wantErr:     model.ErrVoucherMinBelanja,
+		},
+		{
+			name:        "category",
+			voucher:     model.Voucher{JenisDiskon: model.VOUCHER_JENIS_DISKON_NOMINAL, NilaiDiskon: 900},
+			categoryIds: []int{1, 3},
+			lines:       []voucherTestLine{{1, 1, 10000}, {1, 2, 10000}, {1, 3, 20000}},
+			wantDiskon:  []model.Money{300, 0, 600},
+		},
+		{
+			name:       "toko",
+			voucher:    model.Voucher{IdToko: &tokoId, JenisDiskon: model.VOUCHER_JENIS_DISKON_PERSEN, NilaiDiskon: 10},
+			lines:      []voucherTestLine{{2, 1, 10000}, {1, 1, 10000}},
+			wantDiskon: []model.Money{0, 1000},
+		},
+		{
+			name:        "toko and category",
+			voucher:     model.Voucher{IdToko: &tokoId, JenisDiskon: model.VOUCHER_JENIS_DISKON_PERSEN, NilaiDiskon: 10},
+			categoryIds: []int{2},
+			lines:       []voucherTestLine{{1, 1, 10000}, {2, 2, 10000}, {1, 2, 10000}},
+			wantDiskon:  []model.Money{0, 0, 1000},
+		},
+		{
+			name:        "no eligible line",
+			voucher:     model.Voucher{IdToko: &tokoId, JenisDiskon: model.VOUCHER_JENIS_DISKON_NOMINAL, NilaiDiskon: 1000},
+			categoryIds: []int{1},
+			lines:       []voucherTestLine{{2, 1, 10000}, {1, 2, 10000}},
+			wantErr:     model.ErrVoucherNoEligibleItems,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detailTrxWithLogProdukList := []*model.DetailTrxWithLogProduk{}
+			undiscounted := model.Money(0)
+			for _, line := range tt.lines {
+				detailTrxWithLogProdukList = append(detailTrxWithLogProdukList, &model.DetailTrxWithLogProduk{
+					DetailTrx: &model.DetailTrx{IdToko: line.idToko, HargaTotal: line.harga},
+					LogProduk: &model.LogProduk{IdCategory: line.idCategory},
+				})
+				undiscounted += line.harga
+			}
+
+			diskon, err := distributeDiskon(&tt.voucher, tt.categoryIds, detailTrxWithLogProdukList)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			gotDiskon := []model.Money{}
+			hargaTotal := model.Money(0)
+			sumDiskon := model.Money(0)
+			for i, detailTrxWithLogProduk := range detailTrxWithLogProdukList {
+				detailTrx := detailTrxWithLogProduk.DetailTrx
+				gotDiskon = append(gotDiskon, detailTrx.Diskon)
+				if detailTrx.HargaTotal+detailTrx.Diskon != tt.lines[i].harga {
+					t.Errorf("line %d: harga_total %d + diskon %d is not %d", i, detailTrx.HargaTotal, detailTrx.Diskon, tt.lines[i].harga)
+				}
+				hargaTotal += detailTrx.HargaTotal
+				sumDiskon += detailTrx.Diskon
+			}
+			if fmt.Sprint(gotDiskon) != fmt.Sprint(tt.wantDiskon) {
+				t.Errorf("got diskon per line %v, want %v", gotDiskon, tt.wantDiskon)
+			}
+			if sumDiskon != diskon {
+				t.Errorf("lines add up to %d, the voucher gives %d", sumDiskon, diskon)
+			}
+			if hargaTotal+diskon != undiscounted {
+				t.Errorf("harga_total %d + diskon %d is not the undiscounted %d", hargaTotal, diskon, undiscounted)
+			}
+		})
+	}
+}
+
+// voucherQuotaTest sells one product that buyers check out with a voucher
+type voucherQuotaTest struct {
+	f          *fixture
+	trxUsecase model.TrxUsecase
+	produk     *model.Produk
+	voucher    *model.Voucher
+}
+
+func newVoucherQuotaTest(t *testing.T, kuotaTotal int, kuotaPerUser int) *voucherQuotaTest {
+	f := newFixture(t)
+	seller := f.createUser(false)
+	toko := f.createToko(seller.ID)
+	vt := &voucherQuotaTest{
+		f:          f,
+		trxUsecase: f.newTrxUsecase(),
+		produk:     f.createProduk(toko.ID, f.createCategory().ID, 100, 10000, 9000),
+		voucher: &model.Voucher{
+			Kode:          "HEMAT",
+			JenisDiskon:   model.VOUCHER_JENIS_DISKON_NOMINAL,
+			NilaiDiskon:   1000,
+			KuotaTotal:    kuotaTotal,
+			KuotaPerUser:  kuotaPerUser,
+			BerlakuMulai:  time.Now().Add(-time.Hour),
+			BerlakuSampai: time.Now().Add(time.Hour),
+		},
+	}
+	f.create(vt.voucher)
+	return vt
+}
+
+// checkout buys the product with the voucher for buyer
+func (vt *voucherQuotaTest) checkout(buyer *model.User, alamat *model.Alamat) (*model.TrxGetByIDResponse, error) {
+	req := newTrxStoreRequest(alamat.ID, vt.produk.ID, 0, 1)
+	req.KodeVoucher = vt.voucher.Kode
+	return vt.trxUsecase.StoreTrx(context.Background(), req, buyer.ID)
+}
+
+func (vt *voucherQuotaTest) terpakai() int {
+	vt.f.t.Helper()
+	voucher := new(model.Voucher)
+	if err := vt.f.db.First(voucher, vt.voucher.ID).Error; err != nil {
+		vt.f.t.Fatal(err)
+	}
+	return voucher.Terpakai
+}
+
+func TestStoreTrxVoucherKuotaTotal(t *testing.T) {
+	vt := newVoucherQuotaTest(t, 2, 0)
+	buyers := []*model.User{}
+	alamatList := []*model.Alamat{}
+	for i := 0; i < 3; i++ {
+		buyer := vt.f.createUser(false)
+		// every registered user has a toko, CancelTrx looks it up
+		vt.f.createToko(buyer.ID)
+		buyers = append(buyers, buyer)
+		alamatList = append(alamatList, vt.f.createAlamat(buyer.ID))
+	}
+
+	first, err := vt.checkout(buyers[0], alamatList[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Diskon != 1000 || first.HargaTotal != 9000 {
+		t.Errorf("got harga_total %d diskon %d, want 9000 and 1000", first.HargaTotal, first.Diskon)
+	}
+	if _, err := vt.checkout(buyers[1], alamatList[1]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := vt.checkout(buyers[2], alamatList[2]); !errors.Is(err, model.ErrVoucherKuotaHabis) {
+		t.Fatalf("third use: got error %v, want %v", err, model.ErrVoucherKuotaHabis)
+	}
+
+	// cancelling a trx gives its use back
+	_, err = vt.trxUsecase.CancelTrx(context.Background(), first.ID, buyers[0].ID, false, &model.TrxCancelRequest{Alasan: "salah pilih"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := vt.checkout(buyers[2], alamatList[2]); err != nil {
+		t.Errorf("after a cancel: %v", err)
+	}
+	if terpakai := vt.terpakai(); terpakai != 2 {
+		t.Errorf("terpakai is %d, want 2", terpakai)
+	}
+}
+
+func TestStoreTrxVoucherKuotaPerUser(t *testing.T) {
+	vt := newVoucherQuotaTest(t, 0, 2)
+	buyer := vt.f.createUser(false)
+	alamat := vt.f.createAlamat(buyer.ID)
+	otherBuyer := vt.f.createUser(false)
+	otherAlamat := vt.f.createAlamat(otherBuyer.ID)
+
+	for i := 0; i < 2; i++ {
+		if _, err := vt.checkout(buyer, alamat); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := vt.checkout(buyer, alamat); !errors.Is(err, model.ErrVoucherKuotaUserHabis) {
+		t.Fatalf("third use: got error %v, want %v", err, model.ErrVoucherKuotaUserHabis)
+	}
+	if _, err := vt.checkout(otherBuyer, otherAlamat); err != nil {
+		t.Errorf("another buyer: %v", err)
+	}
+	if terpakai := vt.terpakai(); terpakai != 3 {
+		t.Errorf("terpakai is %d, want 3", terpakai)
+	}
+}
+
+func TestStoreTrxVoucherKuotaTotalConcurrent(t *testing.T) {
+	const kuota = 3
+	const checkouts = 10
+
+	vt := newVoucherQuotaTest(t, kuota, 0)
+	buyers := []*model.User{}
+	alamatList := []*model.Alamat{}
+	for i := 0; i < checkouts; i++ {
+		buyer := vt.f.createUser(false)
+		buyers = append(buyers, buyer)
+		alamatList = append(alamatList, vt.f.createAlamat(buyer.ID))
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, checkouts)
+	for i := 0; i < checkouts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := vt.checkout(buyers[i], alamatList[i])
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		if !errors.Is(err, model.ErrVoucherKuotaHabis) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	if succeeded != kuota {
+		t.Errorf("%d checkouts used a voucher with a quota of %d", succeeded, kuota)
+	}
+	if terpakai := vt.terpakai(); terpakai != kuota {
+		t.Errorf("terpakai is %d, want %d", terpakai, kuota)
+	}
+}
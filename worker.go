@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"log"
+	"marketplace-api/model"
+	"time"
+)
+
+// runPaymentExpirySweeper cancels unpaid trx past the payment deadline every interval until ctx is done
+func runPaymentExpirySweeper(ctx context.Context, trxUsecase model.TrxUsecase, deadline time.Duration, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			expired, err := trxUsecase.ExpireUnpaidTrx(ctx, time.Now().Add(-deadline))
+			if expired > 0 {
+				log.Printf("payment expiry sweeper cancelled %d unpaid transaction(s)", expired)
+			}
+			if err != nil && ctx.Err() == nil {
+				log.Println("payment expiry sweeper:", err)
+			}
+		}
+	}
+}
+
+// runOrphanUploadCollector looks for orphaned uploads every interval until ctx is done,
+// they are only deleted when req.Delete is set
+func runOrphanUploadCollector(
+	ctx context.Context,
+	orphanUploadUsecase model.OrphanUploadUsecase,
+	interval time.Duration,
+	req *model.OrphanUploadRequest,
+) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report, err := orphanUploadUsecase.CollectOrphanUploads(ctx, req)
+			if report != nil && len(report.Orphans) > 0 {
+				log.Printf("orphan upload collector found %d orphaned upload(s), deleted %d", len(report.Orphans), report.Deleted)
+			}
+			if report != nil {
+				for _, failure := range report.Failures {
+					log.Printf("orphan upload collector: delete %s: %v", failure.Key, failure.Err)
+				}
+			}
+			if err != nil && ctx.Err() == nil {
+				log.Println("orphan upload collector:", err)
+			}
+		}
+	}
+}
+
+// runIdempotencyKeyCleaner removes the expired idempotency keys every interval until ctx is done
+func runIdempotencyKeyCleaner(ctx context.Context, idempotencyKeyUsecase model.IdempotencyKeyUsecase, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := idempotencyKeyUsecase.DeleteExpiredKeys(ctx)
+			if deleted > 0 {
+				log.Printf("idempotency key cleaner deleted %d expired key(s)", deleted)
+			}
+			if err != nil && ctx.Err() == nil {
+				log.Println("idempotency key cleaner:", err)
+			}
+		}
+	}
+}